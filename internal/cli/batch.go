@@ -3,12 +3,43 @@ package cli
 import (
 	"bufio"
 	"fmt"
+	"math/rand/v2"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/guiyumin/vget/internal/downloader"
+	"github.com/spf13/cobra"
 )
 
-// runBatch reads URLs from a file and downloads each one
-func runBatch(filename string) error {
+var batchCmd = &cobra.Command{
+	Use:   "batch <file>",
+	Short: "Download every URL listed in a file",
+	Long: `Download the URLs in a file one after another, same as vget -f <file>.
+The file has one URL per line; blank lines and lines starting with # are
+skipped. Failed URLs don't stop the batch and are listed at the end.
+
+Examples:
+  vget batch urls.txt
+  vget batch urls.txt --sleep-interval 5 -o downloads/`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBatch(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+}
+
+// runBatch reads URLs from a file and downloads each one. Failures are
+// summarized at the end unless --abort-on-error stops at the first one.
+func runBatch(filename string) (err error) {
+	if checksum != "" {
+		return fmt.Errorf("--checksum can't be used in batch mode")
+	}
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return fmt.Errorf("failed to open file: %w", err)
@@ -34,24 +65,68 @@ func runBatch(filename string) error {
 		return fmt.Errorf("no URLs found in file")
 	}
 
+	budget, err := parseSize(maxTotalSize)
+	if err != nil {
+		return err
+	}
+
 	fmt.Printf("Found %d URL(s) to download\n\n", len(urls))
 
+	// One archive for the whole batch
+	closeArchive, err := openSharedArchive()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := closeArchive(); err == nil {
+			err = cerr
+		}
+	}()
+
 	var succeeded, failed int
 	var failedURLs []string
+	stoppedAt := -1
+
+	// Track overall progress across all downloads in the batch
+	progress := downloader.NewAggregate(len(urls))
+	defer progress.Finish()
 
 	for i, url := range urls {
+		// Only what was actually downloaded counts, not skipped files
+		if budget > 0 && progress.Transferred() >= budget {
+			stoppedAt = i
+			break
+		}
+		if i > 0 {
+			sleepBetweenDownloads()
+		}
 		fmt.Printf("[%d/%d] %s\n", i+1, len(urls), truncateURL(url, 60))
 
-		if err := runDownload(url); err != nil {
+		progress.StartFile()
+		lastDownloaded = nil
+		err := runDownload(url)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "  Error: %v\n", err)
 			failed++
 			failedURLs = append(failedURLs, url)
+			if abortOnError {
+				progress.FileDone()
+				return fmt.Errorf("aborted after %d/%d: %w", i+1, len(urls), err)
+			}
 		} else {
 			succeeded++
 		}
+		progress.FileDone()
+		fmt.Printf("  Overall: %s\n", progress.Headline())
 		fmt.Println()
 	}
 
+	if failed > 0 {
+		notify("vget: batch finished with errors", fmt.Sprintf("%d/%d downloaded, %d failed", succeeded, len(urls), failed))
+	} else {
+		notify("vget: batch complete", fmt.Sprintf("%d/%d downloaded", succeeded, len(urls)))
+	}
+
 	// Print summary
 	fmt.Println("----------------------------------------")
 	fmt.Printf("Completed: %d/%d", succeeded, len(urls))
@@ -59,6 +134,10 @@ func runBatch(filename string) error {
 		fmt.Printf(", Failed: %d", failed)
 	}
 	fmt.Println()
+	if stoppedAt >= 0 {
+		fmt.Printf("Stopped after %s (--max-total-size %s); %d URL(s) not started\n",
+			formatSize(progress.Transferred()), maxTotalSize, len(urls)-stoppedAt)
+	}
 
 	// List failed URLs if any
 	if len(failedURLs) > 0 {
@@ -68,9 +147,48 @@ func runBatch(filename string) error {
 		}
 	}
 
+	// Exit non-zero when anything failed so scripts can notice
+	if failed > 0 {
+		return fmt.Errorf("%d of %d downloads failed", failed, len(urls))
+	}
 	return nil
 }
 
+// sleepBetweenDownloads pauses for --sleep-interval seconds, or a random
+// time up to --max-sleep-interval when that is larger
+func sleepBetweenDownloads() {
+	if sleepInterval <= 0 {
+		return
+	}
+	d := sleepInterval
+	if maxSleepInterval > sleepInterval {
+		d += rand.Float64() * (maxSleepInterval - sleepInterval)
+	}
+	wait := time.Duration(d * float64(time.Second))
+	fmt.Printf("  Sleeping %s...\n", wait.Round(100*time.Millisecond))
+	time.Sleep(wait)
+}
+
+// parseSize parses a size like "500M" or "10G" (binary units); "" means 0
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	num := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B"), "I")
+	mult := int64(1)
+	if n := len(num); n > 0 {
+		if i := strings.IndexByte("KMGT", num[n-1]); i >= 0 {
+			mult = int64(1) << (10 * (i + 1))
+			num = num[:n-1]
+		}
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 500M, 10G)", s)
+	}
+	return int64(v * float64(mult)), nil
+}
+
 // truncateURL shortens a URL for display
 func truncateURL(url string, maxLen int) string {
 	if len(url) <= maxLen {
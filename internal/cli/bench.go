@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/guiyumin/vget/internal/downloader"
+	"github.com/spf13/cobra"
+)
+
+var benchDuration time.Duration
+
+// benchStreams are the stream counts vget bench compares
+var benchStreams = []int{1, 2, 4, 8, 16}
+
+var benchCmd = &cobra.Command{
+	Use:    "bench <url>",
+	Short:  "Measure download throughput for different stream counts",
+	Hidden: true,
+	Long: `Download a URL several times with 1, 2, 4, 8 and 16 parallel streams,
+discarding the data, and report the throughput of each run. Each run stops
+after --duration, so large files don't have to be fetched in full.
+
+Examples:
+  vget bench https://example.com/large-file.mp4
+  vget bench --duration 30s https://example.com/large-file.mp4`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Printf("%-8s %-10s %-8s %s\n", "STREAMS", "BYTES", "TIME", "THROUGHPUT")
+		for _, streams := range benchStreams {
+			r, err := downloader.Benchmark(context.Background(), args[0], streams, benchDuration)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%-8d %-10s %-8s %s/s\n", r.Streams, formatSize(r.Bytes),
+				r.Elapsed.Round(100*time.Millisecond), formatSize(int64(r.Throughput())))
+		}
+		return nil
+	},
+}
+
+func init() {
+	benchCmd.Flags().DurationVar(&benchDuration, "duration", 10*time.Second, "stop each run after this long (0 for the whole file)")
+	rootCmd.AddCommand(benchCmd)
+}
@@ -37,6 +37,7 @@ type browseModel struct {
 	loading      bool
 	done         bool
 	selectedFile string // Full path of selected file for download
+	selectedDir  string // Full path of directory to download recursively
 	keyBindings  browseKeyMap
 }
 
@@ -45,6 +46,7 @@ type browseKeyMap struct {
 	Down  key.Binding
 	Enter key.Binding
 	Back  key.Binding
+	All   key.Binding
 	Quit  key.Binding
 }
 
@@ -66,6 +68,10 @@ func defaultBrowseKeyMap() browseKeyMap {
 			key.WithKeys("backspace", "b", "left", "h"),
 			key.WithHelp("b/backspace", "back"),
 		),
+		All: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "download all"),
+		),
 		Quit: key.NewBinding(
 			key.WithKeys("q", "esc", "ctrl+c"),
 			key.WithHelp("q/esc", "quit"),
@@ -212,6 +218,12 @@ func (m browseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.Quit
 			}
 
+		case key.Matches(msg, m.keyBindings.All):
+			// Download the current directory recursively
+			m.selectedDir = m.currentPath
+			m.done = true
+			return m, tea.Quit
+
 		case key.Matches(msg, m.keyBindings.Back):
 			return m.goUp()
 		}
@@ -298,7 +310,7 @@ func (m browseModel) View() string {
 	b.WriteString("\n")
 
 	// Help text
-	help := "↑/↓ navigate • enter select • b back • q quit"
+	help := "↑/↓ navigate • enter select • a download all • b back • q quit"
 	b.WriteString(browseHelpStyle.Render("  " + help) + "\n")
 
 	content := browseContainerStyle.Render(b.String())
@@ -313,6 +325,7 @@ func (m browseModel) View() string {
 // BrowseResult holds the result of browsing
 type BrowseResult struct {
 	SelectedFile string // Full remote path of selected file
+	SelectedDir  string // Full remote path of directory to download recursively
 	Cancelled    bool   // User quit without selecting
 }
 
@@ -330,6 +343,9 @@ func RunBrowseTUI(client *webdav.Client, serverName, initialPath string) (*Brows
 	if m.done && m.selectedFile != "" {
 		return &BrowseResult{SelectedFile: m.selectedFile}, nil
 	}
+	if m.done && m.selectedDir != "" {
+		return &BrowseResult{SelectedDir: m.selectedDir}, nil
+	}
 
 	return &BrowseResult{Cancelled: true}, nil
 }
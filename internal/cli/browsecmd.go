@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/guiyumin/vget/internal/config"
+	"github.com/guiyumin/vget/internal/webdav"
+	"github.com/spf13/cobra"
+)
+
+var browseCmd = &cobra.Command{
+	Use:   "browse <remote>:[path]",
+	Short: "Browse a remote and download the files you pick",
+	Long: `Open a file browser on a WebDAV remote. Use the arrow keys to move,
+enter to open a directory or download a file, and a to download the whole
+directory you are in.
+
+Examples:
+  vget browse pikpak:
+  vget browse pikpak:/Movies`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remote, dirPath, err := webdav.ParseRemotePath(args[0])
+		if err != nil {
+			return err
+		}
+		client, err := remoteClient(remote)
+		if err != nil {
+			return err
+		}
+		if remote == "" {
+			remote = config.LoadOrDefault().DefaultRemote
+		}
+
+		ctx := context.Background()
+		info, err := client.Stat(ctx, dirPath)
+		if err != nil {
+			return fmt.Errorf("failed to access path: %w", err)
+		}
+		if !info.IsDir {
+			return fmt.Errorf("'%s' is not a directory", args[0])
+		}
+
+		result, err := RunBrowseTUI(client, remote, dirPath)
+		if err != nil {
+			return fmt.Errorf("browse failed: %w", err)
+		}
+		lang := config.LoadOrDefault().Language
+		switch {
+		case result.Cancelled:
+			return nil
+		case result.SelectedDir != "":
+			return downloadWebDAVDir(ctx, client, result.SelectedDir, lang)
+		}
+
+		fileInfo, err := client.Stat(ctx, result.SelectedFile)
+		if err != nil {
+			return fmt.Errorf("failed to get file info: %w", err)
+		}
+		return downloadWebDAVFile(ctx, client, result.SelectedFile, fileInfo, "", lang)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(browseCmd)
+}
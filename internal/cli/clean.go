@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guiyumin/vget/internal/downloader"
+	"github.com/guiyumin/vget/internal/extractor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanYes       bool
+	cleanOlderThan string
+	cleanCache     bool
+)
+
+// partialPattern matches the files single-stream downloads write to until
+// they complete, and no one else's .part files
+const partialPattern = "*" + downloader.PartSuffix
+
+// sidecarSuffix marks the output of an unfinished multi-stream download
+const sidecarSuffix = ".vget-part"
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove leftover partial downloads and stale cache files",
+	Long: `Scan the output directory and the temp directory for files left
+behind by interrupted downloads. An unfinished multi-stream download is
+removed together with its .vget-part sidecar. With --cache, expired
+extraction cache entries are included too.
+
+Only files older than --older-than (1h by default) are considered, so
+downloads that are still running are left alone. Without --yes the files
+are only listed.
+
+Examples:
+  vget clean
+  vget clean --older-than 7d --cache --yes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		age, err := parseAge(cleanOlderThan)
+		if err != nil {
+			return err
+		}
+
+		files, err := findStaleFiles(outputDirectory(), age, cleanCache)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			fmt.Println("Nothing to clean.")
+			return nil
+		}
+
+		var total int64
+		for _, f := range files {
+			fmt.Printf("  %s (%s)\n", f.path, formatSize(f.size))
+			total += f.size
+		}
+
+		if !cleanYes {
+			fmt.Printf("\n%d files, %s. Run with --yes to delete them.\n", len(files), formatSize(total))
+			return nil
+		}
+
+		var removed int
+		for _, f := range files {
+			if err := os.RemoveAll(f.path); err != nil {
+				fmt.Fprintf(os.Stderr, "  failed to remove %s: %v\n", f.path, err)
+				continue
+			}
+			removed++
+		}
+		fmt.Printf("\nRemoved %d files, %s.\n", removed, formatSize(total))
+		return nil
+	},
+}
+
+// staleFile is a file found by vget clean
+type staleFile struct {
+	path string
+	size int64
+}
+
+// findStaleFiles collects partial downloads in outputDir and vget temp
+// files that are at least age old, plus expired cache entries if cache is set
+func findStaleFiles(outputDir string, age time.Duration, cache bool) ([]staleFile, error) {
+	if outputDir == "" {
+		outputDir = "."
+	}
+	now := time.Now()
+	var files []staleFile
+
+	add := func(path string, info os.FileInfo, minAge time.Duration) {
+		if now.Sub(info.ModTime()) >= max(age, minAge) {
+			files = append(files, staleFile{path: path, size: info.Size()})
+		}
+	}
+
+	// Partial downloads in the output directory
+	matches, err := filepath.Glob(filepath.Join(outputDir, partialPattern))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil && !info.IsDir() {
+			add(m, info, 0)
+		}
+	}
+
+	// Unfinished multi-stream downloads: the preallocated output goes with
+	// its sidecar, or it would later pass for a complete file
+	matches, err = filepath.Glob(filepath.Join(outputDir, "*"+sidecarSuffix))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil || info.IsDir() || now.Sub(info.ModTime()) < age {
+			continue
+		}
+		files = append(files, staleFile{path: m, size: info.Size()})
+		output := strings.TrimSuffix(m, sidecarSuffix)
+		if info, err := os.Stat(output); err == nil && !info.IsDir() {
+			files = append(files, staleFile{path: output, size: info.Size()})
+		}
+	}
+
+	// Temp files (e.g. copied browser cookie databases)
+	if matches, err := filepath.Glob(filepath.Join(os.TempDir(), "vget-*")); err == nil {
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil {
+				add(m, info, 0)
+			}
+		}
+	}
+
+	// Extraction cache entries past their TTL
+	if !cache {
+		return files, nil
+	}
+	if dir, err := extractor.CacheDir(); err == nil {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if info, err := e.Info(); err == nil && !e.IsDir() {
+				add(filepath.Join(dir, e.Name()), info, extractor.CacheTTL)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// parseAge parses a duration like "7d", "12h" or "30m"
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q (e.g. 7d, 12h)", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (e.g. 7d, 12h)", s)
+	}
+	return d, nil
+}
+
+func init() {
+	cleanCmd.Flags().BoolVarP(&cleanYes, "yes", "y", false, "delete the files instead of only listing them")
+	cleanCmd.Flags().StringVar(&cleanOlderThan, "older-than", "1h", "only files older than this (e.g. 7d, 12h)")
+	cleanCmd.Flags().BoolVar(&cleanCache, "cache", false, "also remove expired extraction cache entries")
+	rootCmd.AddCommand(cleanCmd)
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFindStaleFilesKeepsForeignPartFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+	for _, name := range []string{"video.mp4.vget.part", "other-tool.zip.part", "album.zip"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, old, old)
+	}
+
+	files, err := findStaleFiles(dir, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		if filepath.Dir(f.path) == dir {
+			names = append(names, filepath.Base(f.path))
+		}
+	}
+	if want := []string{"video.mp4.vget.part"}; !slices.Equal(names, want) {
+		t.Errorf("found %v, want %v", names, want)
+	}
+}
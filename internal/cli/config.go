@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -12,6 +13,8 @@ import (
 	"golang.org/x/term"
 )
 
+var configShowJSON bool
+
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Manage vget configuration",
@@ -22,15 +25,26 @@ var configCmd = &cobra.Command{
 var configShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show current configuration",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg := config.LoadOrDefault()
 
+		// The effective config: file, defaults and environment (e.g. proxy)
+		if configShowJSON {
+			out, err := json.MarshalIndent(cfg.Redacted(), "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
 		fmt.Println("Current configuration:")
 		fmt.Printf("  Language:  %s\n", cfg.Language)
 		fmt.Printf("  Proxy:     %s\n", orDefault(cfg.Proxy, "(none)"))
 		fmt.Printf("  OutputDir: %s\n", cfg.OutputDir)
 		fmt.Printf("  Format:    %s\n", cfg.Format)
 		fmt.Printf("  Quality:   %s\n", cfg.Quality)
+		fmt.Printf("  Channel:   %s\n", orDefault(cfg.UpdateChannel, "stable"))
 		fmt.Printf("  Config:    %s\n", config.SavePath())
 
 		if len(cfg.WebDAVServers) > 0 {
@@ -39,6 +53,7 @@ var configShowCmd = &cobra.Command{
 				fmt.Printf("  %s: %s\n", name, server.URL)
 			}
 		}
+		return nil
 	},
 }
 
@@ -51,6 +66,25 @@ var configPathCmd = &cobra.Command{
 	},
 }
 
+// vget config migrate - upgrade config file to the current schema
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade config file to the current format",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backup, err := config.MigrateFile()
+		if err != nil {
+			return err
+		}
+		if backup == "" {
+			fmt.Printf("Config is up to date (version %d)\n", config.CurrentVersion)
+			return nil
+		}
+		fmt.Printf("Migrated %s to version %d\n", config.SavePath(), config.CurrentVersion)
+		fmt.Printf("Backup saved to %s\n", backup)
+		return nil
+	},
+}
+
 // --- WebDAV remote management ---
 
 var configWebdavCmd = &cobra.Command{
@@ -73,7 +107,14 @@ var configWebdavListCmd = &cobra.Command{
 
 		fmt.Println("WebDAV servers:")
 		for name, server := range cfg.WebDAVServers {
-			if server.Username != "" {
+			if name == cfg.DefaultRemote {
+				name += " (default)"
+			}
+			if server.Token != "" {
+				fmt.Printf("  %s: %s (token)\n", name, server.URL)
+			} else if server.Username != "" && strings.EqualFold(server.AuthType, "digest") {
+				fmt.Printf("  %s: %s (user: %s, digest)\n", name, server.URL, server.Username)
+			} else if server.Username != "" {
 				fmt.Printf("  %s: %s (user: %s)\n", name, server.URL, server.Username)
 			} else {
 				fmt.Printf("  %s: %s\n", name, server.URL)
@@ -82,6 +123,8 @@ var configWebdavListCmd = &cobra.Command{
 	},
 }
 
+var webdavAddToken bool
+
 var configWebdavAddCmd = &cobra.Command{
 	Use:   "add <name>",
 	Short: "Add a new WebDAV server",
@@ -90,13 +133,17 @@ var configWebdavAddCmd = &cobra.Command{
 Examples:
   vget config webdav add pikpak
   vget config webdav add nextcloud
+  vget config webdav add pikpak --token   # bearer token instead of user/pass
+
+You are asked for the auth type: basic (default), digest, or bearer for a
+static token.
 
 After adding, download files like:
   vget pikpak:/Movies/video.mp4`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		name := args[0]
-		cfg := config.LoadOrDefault()
+		cfg := config.LoadForEdit()
 
 		if cfg.GetWebDAVServer(name) != nil {
 			fmt.Fprintf(os.Stderr, "WebDAV server '%s' already exists.\n", name)
@@ -115,13 +162,45 @@ After adding, download files like:
 			os.Exit(1)
 		}
 
-		// Get username
-		fmt.Print("Username (enter to skip): ")
-		username, _ := reader.ReadString('\n')
-		username = strings.TrimSpace(username)
+		// Get auth type
+		authType := "bearer"
+		if !webdavAddToken {
+			fmt.Print("Auth type (basic, digest, bearer) [basic]: ")
+			authType, _ = reader.ReadString('\n')
+			authType = strings.ToLower(strings.TrimSpace(authType))
+			switch authType {
+			case "":
+				authType = "basic"
+			case "basic", "digest", "bearer":
+			default:
+				fmt.Fprintf(os.Stderr, "Unknown auth type %q\n", authType)
+				os.Exit(1)
+			}
+		}
+
+		// Get token
+		var username, password, token string
+		if authType == "bearer" {
+			fmt.Print("Token: ")
+			tokenBytes, err := term.ReadPassword(int(syscall.Stdin))
+			fmt.Println()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to read token: %v\n", err)
+				os.Exit(1)
+			}
+			token = strings.TrimSpace(string(tokenBytes))
+			if token == "" {
+				fmt.Fprintln(os.Stderr, "Token is required")
+				os.Exit(1)
+			}
+		} else {
+			// Get username
+			fmt.Print("Username (enter to skip): ")
+			username, _ = reader.ReadString('\n')
+			username = strings.TrimSpace(username)
+		}
 
 		// Get password
-		var password string
 		if username != "" {
 			fmt.Print("Password: ")
 			passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
@@ -133,11 +212,17 @@ After adding, download files like:
 			password = string(passwordBytes)
 		}
 
-		cfg.SetWebDAVServer(name, config.WebDAVServer{
+		server := config.WebDAVServer{
 			URL:      urlStr,
 			Username: username,
 			Password: password,
-		})
+			Token:    token,
+		}
+		// Basic is the default and bearer is implied by the token
+		if authType == "digest" && username != "" {
+			server.AuthType = authType
+		}
+		cfg.SetWebDAVServer(name, server)
 
 		if err := config.Save(cfg); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to save: %v\n", err)
@@ -156,7 +241,7 @@ var configWebdavDeleteCmd = &cobra.Command{
 	Args:    cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		name := args[0]
-		cfg := config.LoadOrDefault()
+		cfg := config.LoadForEdit()
 
 		if cfg.GetWebDAVServer(name) == nil {
 			fmt.Fprintf(os.Stderr, "WebDAV server '%s' not found.\n", name)
@@ -174,6 +259,44 @@ var configWebdavDeleteCmd = &cobra.Command{
 	},
 }
 
+var configWebdavSetDefaultCmd = &cobra.Command{
+	Use:   "set-default <name>",
+	Short: "Use a WebDAV server for :/path (empty remote name)",
+	Long: `Set the default WebDAV server, so its files can be downloaded without
+the remote name:
+
+  vget config webdav set-default pikpak
+  vget :/Movies/video.mp4`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		cfg := config.LoadForEdit()
+
+		if cfg.GetWebDAVServer(name) == nil {
+			fmt.Fprintf(os.Stderr, "WebDAV server '%s' not found.\n", name)
+			os.Exit(1)
+		}
+
+		cfg.DefaultRemote = name
+
+		if err := config.Save(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Default WebDAV server set to '%s'.\n", name)
+		fmt.Println("Usage: vget :/path/to/file.mp4")
+	},
+}
+
+// remoteNotFound returns the error for an unknown remote name
+func remoteNotFound(name string) error {
+	if name == "" {
+		return fmt.Errorf("no default WebDAV server. Set one with 'vget config webdav set-default <name>'")
+	}
+	return fmt.Errorf("WebDAV server '%s' not found. Add it with 'vget config webdav add %s'", name, name)
+}
+
 var configWebdavShowCmd = &cobra.Command{
 	Use:   "show <name>",
 	Short: "Show details of a WebDAV server",
@@ -190,7 +313,10 @@ var configWebdavShowCmd = &cobra.Command{
 
 		fmt.Printf("Name:     %s\n", name)
 		fmt.Printf("URL:      %s\n", server.URL)
-		if server.Username != "" {
+		if server.Token != "" {
+			fmt.Printf("Token:    %s\n", strings.Repeat("*", len(server.Token)))
+		} else if server.Username != "" {
+			fmt.Printf("Auth:     %s\n", orDefault(server.AuthType, "basic"))
 			fmt.Printf("Username: %s\n", server.Username)
 			fmt.Printf("Password: %s\n", strings.Repeat("*", len(server.Password)))
 		}
@@ -207,13 +333,17 @@ func orDefault(s, def string) string {
 func init() {
 	// config subcommands
 	configCmd.AddCommand(configShowCmd)
+	configShowCmd.Flags().BoolVar(&configShowJSON, "json", false, "print the effective config as JSON (secrets redacted)")
 	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configMigrateCmd)
 
 	// config webdav subcommands
 	configWebdavCmd.AddCommand(configWebdavListCmd)
 	configWebdavCmd.AddCommand(configWebdavAddCmd)
+	configWebdavAddCmd.Flags().BoolVar(&webdavAddToken, "token", false, "authenticate with a bearer token (prompted) instead of username/password")
 	configWebdavCmd.AddCommand(configWebdavDeleteCmd)
 	configWebdavCmd.AddCommand(configWebdavShowCmd)
+	configWebdavCmd.AddCommand(configWebdavSetDefaultCmd)
 	configCmd.AddCommand(configWebdavCmd)
 
 	rootCmd.AddCommand(configCmd)
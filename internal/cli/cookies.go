@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+	"net/http/cookiejar"
+	"net/url"
+
+	"github.com/guiyumin/vget/internal/cookies"
+	"github.com/guiyumin/vget/internal/httpclient"
+)
+
+// loadBrowserCookies loads the browser's cookies for rawURL's site into the
+// shared cookie jar used by extractors
+func loadBrowserCookies(browser, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	found, err := cookies.FromBrowser(browser, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to read %s cookies: %w", browser, err)
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	jar.SetCookies(u, found)
+	httpclient.SetCookieJar(jar)
+
+	fmt.Printf("  Loaded %d cookie(s) from %s\n", len(found), browser)
+	return nil
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/guiyumin/vget/internal/config"
+	"github.com/guiyumin/vget/internal/downloader"
+)
+
+// dedupIndexFile is the --dedup index, stored next to the config file
+const dedupIndexFile = "dedup.json"
+
+// removeDuplicates deletes downloaded files that duplicate an existing file
+// and returns the list with each duplicate replaced by the original
+func removeDuplicates(files []string) []string {
+	configPath, err := config.ConfigPath()
+	if err != nil {
+		return files
+	}
+	idx, err := downloader.LoadDedupIndex(filepath.Join(filepath.Dir(configPath), dedupIndexFile))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: dedup disabled: %v\n", err)
+		return files
+	}
+
+	result := make([]string, 0, len(files))
+	for _, f := range files {
+		dup, err := idx.FindDuplicate(f)
+		if err != nil || dup == "" {
+			result = append(result, f)
+			continue
+		}
+		if err := os.Remove(f); err != nil {
+			result = append(result, f)
+			continue
+		}
+		fmt.Printf("  %s is a duplicate of %s, removed\n", f, dup)
+		result = append(result, dup)
+	}
+
+	if err := idx.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: failed to save dedup index: %v\n", err)
+	}
+	return result
+}
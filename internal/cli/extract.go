@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
@@ -10,6 +13,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/guiyumin/vget/internal/extractor"
 	"github.com/guiyumin/vget/internal/i18n"
+	"golang.org/x/term"
 )
 
 var (
@@ -154,13 +158,40 @@ func (m extractModel) View() string {
 	)
 }
 
-// runExtractWithSpinner runs extraction with a spinner TUI
+// extractContext returns the context for one extraction, limited to --extract-timeout
+func extractContext() (context.Context, context.CancelFunc) {
+	if extractTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), extractTimeout)
+}
+
+// extract runs ext with a fresh extraction context
+func extract(ctx context.Context, ext extractor.Extractor, url string) (extractor.Media, error) {
+	media, err := ext.Extract(ctx, url)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("extraction timed out after %s", extractTimeout)
+	}
+	return media, err
+}
+
+// runExtractWithSpinner runs extraction with a spinner TUI. Quitting the
+// spinner (Ctrl+C) cancels the extraction.
 func runExtractWithSpinner(ext extractor.Extractor, url, lang string) (extractor.Media, error) {
+	ctx, cancel := extractContext()
+	defer cancel()
+
+	// Without a usable terminal just print a line and wait
+	if !term.IsTerminal(int(os.Stdout.Fd())) || os.Getenv("TERM") == "dumb" {
+		fmt.Printf("  %s: %s\n", i18n.T(lang).Download.Extracting, url)
+		return extract(ctx, ext, url)
+	}
+
 	state := &extractState{}
 
 	// Start extraction in background
 	go func() {
-		result, err := ext.Extract(url)
+		result, err := extract(ctx, ext, url)
 		if err != nil {
 			state.setError(err)
 		} else {
@@ -185,3 +216,45 @@ func runExtractWithSpinner(ext extractor.Extractor, url, lang string) (extractor
 
 	return result, nil
 }
+
+// extractWithWait runs extraction and, while the media isn't available yet,
+// retries until wait has elapsed
+func extractWithWait(ext extractor.Extractor, url, lang string, wait time.Duration) (extractor.Media, error) {
+	const interval = 15 * time.Second
+	deadline := time.Now().Add(wait)
+
+	for {
+		media, err := runExtractWithSpinner(ext, url, lang)
+		if !errors.Is(err, extractor.ErrMediaNotReady) {
+			return media, err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "  %v, retrying in %s\n", err, min(interval, remaining).Round(time.Second))
+		time.Sleep(min(interval, remaining))
+	}
+}
+
+// refreshURL re-extracts sourceURL after oldURL (taken from old) expired and
+// returns the URL of the same item in the fresh result
+func refreshURL(ext extractor.Extractor, sourceURL string, old extractor.Media, oldURL string) (string, error) {
+	ctx, cancel := extractContext()
+	defer cancel()
+
+	fresh, err := extract(ctx, ext, sourceURL)
+	if err != nil {
+		return "", err
+	}
+	extractor.SaveCached(sourceURL, fresh) // Best-effort
+
+	oldURLs, newURLs := extractor.MediaURLs(old), extractor.MediaURLs(fresh)
+	for i, u := range oldURLs {
+		if u == oldURL && i < len(newURLs) {
+			return newURLs[i], nil
+		}
+	}
+	return "", fmt.Errorf("media changed since it was extracted")
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/guiyumin/vget/internal/extractor"
+	"github.com/spf13/cobra"
+)
+
+var extractorsJSON bool
+
+// extractorInfo describes one extractor for 'vget list-extractors'
+type extractorInfo struct {
+	Name    string   `json:"name"`
+	Hosts   []string `json:"hosts"`
+	Example string   `json:"example,omitempty"`
+}
+
+var listExtractorsCmd = &cobra.Command{
+	Use:   "list-extractors",
+	Short: "List the supported sites",
+	Long: `Print every extractor with the hosts it handles and an example URL.
+The last two handle any other site: generic finds the video on a web
+page, direct downloads file links.
+
+Examples:
+  vget list-extractors
+  vget list-extractors --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var infos []extractorInfo
+		for _, e := range extractor.List() {
+			info := extractorInfo{Name: e.Name(), Hosts: extractor.Hosts(e)}
+			if info.Hosts == nil {
+				info.Hosts = []string{}
+			}
+			if d, ok := e.(extractor.Describer); ok {
+				info.Example = d.Example()
+			}
+			infos = append(infos, info)
+		}
+
+		if extractorsJSON {
+			output, err := json.MarshalIndent(infos, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			fmt.Println(string(output))
+			return nil
+		}
+
+		nameWidth, hostsWidth := len("NAME"), len("HOSTS")
+		for _, info := range infos {
+			nameWidth = max(nameWidth, len(info.Name))
+			hostsWidth = max(hostsWidth, len(hostList(info.Hosts)))
+		}
+		fmt.Printf("%-*s  %-*s  %s\n", nameWidth, "NAME", hostsWidth, "HOSTS", "EXAMPLE")
+		for _, info := range infos {
+			fmt.Printf("%-*s  %-*s  %s\n", nameWidth, info.Name, hostsWidth, hostList(info.Hosts), info.Example)
+		}
+		return nil
+	},
+}
+
+func init() {
+	listExtractorsCmd.Flags().BoolVar(&extractorsJSON, "json", false, "output as JSON")
+	rootCmd.AddCommand(listExtractorsCmd)
+}
+
+// hostList joins hosts for display, "*" standing for the fallback
+func hostList(hosts []string) string {
+	if len(hosts) == 0 {
+		return "*"
+	}
+	return strings.Join(hosts, ",")
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/guiyumin/vget/internal/extractor"
+)
+
+// listFlat prints the items of a collection URL without extracting or
+// downloading them. The URLs can be saved and fed back with -f.
+func listFlat(ext extractor.Extractor, url string) error {
+	lister, ok := ext.(extractor.Lister)
+	if !ok {
+		return fmt.Errorf("--flat is not supported for %s URLs", ext.Name())
+	}
+
+	entries, err := lister.List(url)
+	if err != nil {
+		return err
+	}
+	entries = selectEntries(entries, itemRanges)
+
+	if jsonFlag {
+		if entries == nil {
+			entries = []extractor.Entry{}
+		}
+		out, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	for _, e := range entries {
+		fmt.Printf("%s\t%s\n", e.URL, e.Title)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/guiyumin/vget/internal/extractor"
+	"github.com/spf13/cobra"
+)
+
+var (
+	formatsFields string
+	formatsJSON   bool
+)
+
+// formatFields are the columns 'vget formats' can print
+var formatFields = []string{"id", "res", "ext", "br", "size"}
+
+// formatRow is one downloadable variant of a media item
+type formatRow struct {
+	ID      string `json:"id"`
+	Res     string `json:"res"`
+	Ext     string `json:"ext"`
+	Bitrate int    `json:"br"`   // bits per second, 0 if unknown
+	Size    int64  `json:"size"` // estimated bytes, 0 if unknown
+}
+
+var formatsCmd = &cobra.Command{
+	Use:   "formats <url>",
+	Short: "List the available formats of a URL",
+	Long: `Print only the formats table of a URL, for choosing a format in scripts.
+Sizes are estimated from bitrate and duration when the site reports both.
+
+Examples:
+  vget formats https://x.com/user/status/123
+  vget formats --fields id,res,br https://x.com/user/status/123
+  vget formats --json https://x.com/user/status/123`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fields, err := parseFormatFields(formatsFields)
+		if err != nil {
+			return err
+		}
+
+		url := args[0]
+		ext := extractor.Match(url)
+		if ext == nil {
+			return fmt.Errorf("no extractor for %s", url)
+		}
+
+		media, cached := extractor.LoadCached(url)
+		if !cached {
+			ctx, cancel := extractContext()
+			defer cancel()
+			if media, err = extract(ctx, ext, url); err != nil {
+				return err
+			}
+			extractor.SaveCached(url, media) // Best-effort
+		}
+
+		rows := formatRows(media)
+		if formatsJSON {
+			if rows == nil {
+				rows = []formatRow{}
+			}
+			out, err := json.MarshalIndent(rows, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+
+		fmt.Println(strings.ToUpper(strings.Join(fields, "\t")))
+		for _, r := range rows {
+			values := make([]string, len(fields))
+			for i, f := range fields {
+				values[i] = r.field(f)
+			}
+			fmt.Println(strings.Join(values, "\t"))
+		}
+		return nil
+	},
+}
+
+func init() {
+	formatsCmd.Flags().StringVar(&formatsFields, "fields", strings.Join(formatFields, ","), "comma-separated columns to print: "+strings.Join(formatFields, ", "))
+	formatsCmd.Flags().BoolVar(&formatsJSON, "json", false, "output all fields as JSON")
+	rootCmd.AddCommand(formatsCmd)
+}
+
+// parseFormatFields validates a --fields list
+func parseFormatFields(s string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		valid := false
+		for _, known := range formatFields {
+			valid = valid || f == known
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown field %q (use %s)", f, strings.Join(formatFields, ", "))
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields selected")
+	}
+	return fields, nil
+}
+
+// formatRows lists the variants of m. IDs match the indexes shown by --info;
+// posts with several videos use "video.format".
+func formatRows(m extractor.Media) []formatRow {
+	var rows []formatRow
+	switch v := m.(type) {
+	case *extractor.VideoMedia:
+		if len(v.Videos) > 1 {
+			for i, video := range v.Videos {
+				for j, f := range video.Formats {
+					rows = append(rows, videoFormatRow(fmt.Sprintf("%d.%d", i+1, j), f, video.Duration))
+				}
+			}
+			break
+		}
+		for i, f := range v.Formats {
+			rows = append(rows, videoFormatRow(strconv.Itoa(i), f, v.Duration))
+		}
+	case *extractor.AudioMedia:
+		rows = append(rows, formatRow{ID: "0", Res: "audio", Ext: v.Ext})
+	case *extractor.ImageMedia:
+		for i, img := range v.Images {
+			rows = append(rows, formatRow{
+				ID:  strconv.Itoa(i + 1),
+				Res: resolution(img.Width, img.Height),
+				Ext: img.Ext,
+			})
+		}
+	}
+	return rows
+}
+
+// videoFormatRow describes f, estimating its size from bitrate and duration
+func videoFormatRow(id string, f extractor.VideoFormat, duration int) formatRow {
+	row := formatRow{
+		ID:      id,
+		Res:     resolution(f.Width, f.Height),
+		Ext:     f.Ext,
+		Bitrate: f.Bitrate,
+	}
+	if row.Res == "" {
+		row.Res = f.QualityLabel()
+	}
+	if f.Bitrate > 0 && duration > 0 {
+		row.Size = int64(f.Bitrate) * int64(duration) / 8
+	}
+	return row
+}
+
+// resolution formats a WxH pair, or "" if unknown
+func resolution(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", width, height)
+}
+
+// field returns the text value of a column, "-" when unknown
+func (r formatRow) field(name string) string {
+	var v string
+	switch name {
+	case "id":
+		v = r.ID
+	case "res":
+		v = r.Res
+	case "ext":
+		v = r.Ext
+	case "br":
+		if r.Bitrate > 0 {
+			v = strconv.Itoa(r.Bitrate)
+		}
+	case "size":
+		if r.Size > 0 {
+			v = strconv.FormatInt(r.Size, 10)
+		}
+	}
+	if v == "" {
+		return "-"
+	}
+	return v
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/guiyumin/vget/internal/extractor"
+)
+
+// htmlArchiveTemplate renders a self-contained snapshot of a post
+var htmlArchiveTemplate = template.Must(template.New("archive").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 640px; margin: 2em auto; padding: 0 1em; color: #0f1419; }
+.author { font-weight: bold; }
+.text { white-space: pre-wrap; font-size: 1.1em; margin: 1em 0; }
+.media img, .media video { max-width: 100%; border-radius: 12px; margin-bottom: 0.5em; }
+.meta { color: #536471; font-size: 0.9em; }
+</style>
+</head>
+<body>
+{{if .Uploader}}<div class="author">@{{.Uploader}}</div>{{end}}
+<div class="text">{{.Text}}</div>
+<div class="media">
+{{range .Files}}{{if eq .Kind "image"}}<img src="{{.Path}}" alt="{{.Alt}}">
+{{else if eq .Kind "video"}}<video src="{{.Path}}" controls></video>
+{{else if eq .Kind "audio"}}<audio src="{{.Path}}" controls></audio>
+{{else}}<a href="{{.Path}}">{{.Path}}</a>
+{{end}}{{end}}</div>
+<div class="meta">
+{{if .Posted}}Posted {{.Posted}}<br>
+{{end}}<a href="{{.SourceURL}}">{{.SourceURL}}</a><br>
+Saved {{.Saved}}
+</div>
+</body>
+</html>
+`))
+
+// htmlArchiveFile is a downloaded file embedded in the archive page
+type htmlArchiveFile struct {
+	Path string
+	Kind string // "image", "video", "audio" or "file"
+	Alt  string // Image alt text
+}
+
+// writeHTML saves an HTML page next to the downloaded files that reconstructs
+// the post (text, author, media) so it can be viewed offline
+func writeHTML(m extractor.Media, sourceURL string, files []string) error {
+	baseName := outputBaseName(m)
+	htmlFile := baseName + ".html"
+	htmlDir := filepath.Dir(htmlFile)
+
+	text := m.GetTitle()
+	var published time.Time
+	switch v := m.(type) {
+	case *extractor.VideoMedia:
+		if v.Description != "" {
+			text = v.Description
+		}
+		published = v.Published
+	case *extractor.ImageMedia:
+		if v.Description != "" {
+			text = v.Description
+		}
+		published = v.Published
+	}
+	var posted string
+	if !published.IsZero() {
+		posted = published.Local().Format("2006-01-02 15:04")
+	}
+
+	// Alt text lines up with the files only if every image was saved
+	var images []extractor.Image
+	if v, ok := m.(*extractor.ImageMedia); ok && len(v.Images) == len(files) {
+		images = v.Images
+	}
+
+	var embedded []htmlArchiveFile
+	for i, f := range files {
+		rel, err := filepath.Rel(htmlDir, f)
+		if err != nil {
+			rel = f
+		}
+		file := htmlArchiveFile{
+			Path: filepath.ToSlash(rel),
+			Kind: fileKind(f),
+		}
+		if images != nil {
+			file.Alt = images[i].AltText
+		}
+		embedded = append(embedded, file)
+	}
+
+	out, err := os.Create(htmlFile)
+	if err != nil {
+		return fmt.Errorf("failed to create HTML file: %w", err)
+	}
+	defer out.Close()
+
+	err = htmlArchiveTemplate.Execute(out, map[string]any{
+		"Title":     m.GetTitle(),
+		"Uploader":  m.GetUploader(),
+		"Text":      text,
+		"Files":     embedded,
+		"Posted":    posted,
+		"SourceURL": sourceURL,
+		"Saved":     time.Now().Format("2006-01-02 15:04"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write HTML file: %w", err)
+	}
+
+	fmt.Printf("  HTML saved to %s\n", htmlFile)
+	return nil
+}
+
+// fileKind classifies a file by extension for embedding
+func fileKind(name string) string {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(name), ".")) {
+	case "jpg", "jpeg", "png", "gif", "webp", "bmp", "svg":
+		return "image"
+	case "mp4", "webm", "mov", "m4v", "ts", "mkv":
+		return "video"
+	case "mp3", "m4a", "aac", "ogg", "wav", "flac", "opus":
+		return "audio"
+	default:
+		return "file"
+	}
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guiyumin/vget/internal/extractor"
+)
+
+func TestWriteHTMLShowsPostDate(t *testing.T) {
+	dir := t.TempDir()
+	output = filepath.Join(dir, "post.jpg")
+	t.Cleanup(func() { output = "" })
+
+	published := time.Date(2018, 10, 10, 20, 19, 24, 0, time.UTC)
+	m := &extractor.ImageMedia{
+		ID:          "1",
+		Description: "a tweet",
+		Uploader:    "user",
+		Published:   published,
+		Images:      []extractor.Image{{AltText: "a cat"}},
+	}
+	if err := writeHTML(m, "https://x.com/user/status/1", []string{output}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "post.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+	for _, want := range []string{
+		"Posted " + published.Local().Format("2006-01-02 15:04"),
+		"@user",
+		"a tweet",
+		`<img src="post.jpg" alt="a cat">`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page doesn't contain %q:\n%s", want, page)
+		}
+	}
+
+	// Without a date there is no "Posted" line
+	m.Published = time.Time{}
+	if err := writeHTML(m, "https://x.com/user/status/1", []string{output}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "post.html")); strings.Contains(string(data), "Posted") {
+		t.Errorf("page without a date has a Posted line:\n%s", data)
+	}
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/guiyumin/vget/internal/extractor"
+)
+
+// itemRange is an inclusive range of 1-based item numbers; end 0 means open-ended
+type itemRange struct {
+	start, end int
+}
+
+// parsePlaylistItems parses a --playlist-items spec like "1,3-5,8" or "10-"
+func parsePlaylistItems(spec string) ([]itemRange, error) {
+	var ranges []itemRange
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		startStr, endStr, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(startStr))
+		if err != nil || start < 1 {
+			return nil, fmt.Errorf("invalid --playlist-items entry %q", part)
+		}
+		r := itemRange{start: start, end: start}
+		if isRange {
+			r.end = 0
+			if endStr = strings.TrimSpace(endStr); endStr != "" {
+				if r.end, err = strconv.Atoi(endStr); err != nil || r.end < start {
+					return nil, fmt.Errorf("invalid --playlist-items entry %q", part)
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("--playlist-items selects nothing")
+	}
+	return ranges, nil
+}
+
+// selected reports whether 1-based item n is in ranges
+func selected(ranges []itemRange, n int) bool {
+	for _, r := range ranges {
+		if n >= r.start && (r.end == 0 || n <= r.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectPlaylistItems returns a copy of m keeping only the --playlist-items
+// of image sets and multi-video posts. m itself is left intact so expired
+// URLs can still be matched against the full extraction.
+func selectPlaylistItems(m extractor.Media, ranges []itemRange) (extractor.Media, error) {
+	if ranges == nil {
+		return m, nil
+	}
+
+	switch v := m.(type) {
+	case *extractor.ImageMedia:
+		c := *v
+		c.Images = nil
+		for i, img := range v.Images {
+			if selected(ranges, i+1) {
+				img.Number = i + 1
+				c.Images = append(c.Images, img)
+			}
+		}
+		if len(c.Images) == 0 {
+			return nil, fmt.Errorf("--playlist-items matches none of the %d images", len(v.Images))
+		}
+		return &c, nil
+
+	case *extractor.VideoMedia:
+		if len(v.Videos) <= 1 {
+			return m, nil
+		}
+		c := *v
+		c.Videos = nil
+		for i, video := range v.Videos {
+			if selected(ranges, i+1) {
+				video.Number = i + 1
+				c.Videos = append(c.Videos, video)
+			}
+		}
+		if len(c.Videos) == 0 {
+			return nil, fmt.Errorf("--playlist-items matches none of the %d videos", len(v.Videos))
+		}
+		first := c.Videos[0]
+		c.Formats, c.Duration, c.Width, c.Height = first.Formats, first.Duration, first.Width, first.Height
+		return &c, nil
+	}
+	return m, nil
+}
+
+// itemNumber returns the number the i-th of count items of a post gets in
+// its filename, or 0 for a lone item. Items picked by --playlist-items keep
+// their position in the post.
+func itemNumber(number, i, count int) int {
+	if number > 0 {
+		return number
+	}
+	if count > 1 {
+		return i + 1
+	}
+	return 0
+}
+
+// selectEntries keeps the --playlist-items of a flat listing
+func selectEntries(entries []extractor.Entry, ranges []itemRange) []extractor.Entry {
+	if ranges == nil {
+		return entries
+	}
+	var result []extractor.Entry
+	for i, e := range entries {
+		if selected(ranges, i+1) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/guiyumin/vget/internal/extractor"
+)
+
+func TestSelectPlaylistItemsKeepsNumbers(t *testing.T) {
+	ranges, err := parsePlaylistItems("2,4-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := &extractor.ImageMedia{Images: make([]extractor.Image, 5)}
+	m, err := selectPlaylistItems(images, ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var numbers []int
+	for i, img := range m.(*extractor.ImageMedia).Images {
+		numbers = append(numbers, itemNumber(img.Number, i, 3))
+	}
+	if want := []int{2, 4, 5}; !slices.Equal(numbers, want) {
+		t.Errorf("image numbers = %v, want %v", numbers, want)
+	}
+
+	// A single video picked from a post keeps its number as well
+	ranges, _ = parsePlaylistItems("3")
+	videos := &extractor.VideoMedia{Videos: []extractor.VideoEntry{{Duration: 1}, {Duration: 2}, {Duration: 3}}}
+	if m, err = selectPlaylistItems(videos, ranges); err != nil {
+		t.Fatal(err)
+	}
+	v := m.(*extractor.VideoMedia)
+	if len(v.Videos) != 1 || v.Videos[0].Number != 3 || v.Duration != 3 {
+		t.Errorf("got %+v, want video 3 alone", v)
+	}
+
+	if n := itemNumber(0, 0, 1); n != 0 {
+		t.Errorf("a lone item is numbered %d", n)
+	}
+}
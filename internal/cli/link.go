@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/guiyumin/vget/internal/extractor"
+)
+
+// outputBaseName returns the output filename without extension for a media item,
+// following the same naming rules as the download functions
+func outputBaseName(m extractor.Media) string {
+	if output != "" {
+		return strings.TrimSuffix(output, filepath.Ext(output))
+	}
+	if title := extractor.SanitizeFilename(m.GetTitle()); title != "" {
+		return filepath.Join(outputDirectory(), title)
+	}
+	return filepath.Join(outputDirectory(), m.GetID())
+}
+
+// writeLink saves a platform-appropriate shortcut file pointing at sourceURL:
+// .url on Windows, .webloc on macOS and .desktop elsewhere
+func writeLink(baseName, sourceURL string) error {
+	var ext, content string
+	switch runtime.GOOS {
+	case "windows":
+		ext = "url"
+		content = fmt.Sprintf("[InternetShortcut]\r\nURL=%s\r\n", sourceURL)
+	case "darwin":
+		ext = "webloc"
+		content = fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>URL</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, html.EscapeString(sourceURL))
+	default:
+		ext = "desktop"
+		content = fmt.Sprintf("[Desktop Entry]\nEncoding=UTF-8\nName=%s\nType=Link\nURL=%s\nIcon=text-html\n",
+			filepath.Base(baseName), sourceURL)
+	}
+
+	linkFile := fmt.Sprintf("%s.%s", baseName, ext)
+	if err := os.WriteFile(linkFile, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write link file: %w", err)
+	}
+	fmt.Printf("  Link saved to %s\n", linkFile)
+	return nil
+}
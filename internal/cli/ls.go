@@ -5,13 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/guiyumin/vget/internal/config"
 	"github.com/guiyumin/vget/internal/webdav"
 	"github.com/spf13/cobra"
 )
 
-var jsonFlag bool
+var (
+	jsonFlag    bool
+	lsRecursive bool
+)
 
 var lsCmd = &cobra.Command{
 	Use:   "ls <remote>:<path>",
@@ -21,13 +25,15 @@ var lsCmd = &cobra.Command{
 Examples:
   vget ls pikpak:/
   vget ls pikpak:/Movies
-  vget ls pikpak:/Movies/Action`,
+  vget ls pikpak:/Movies/Action
+  vget ls -R pikpak:/Movies`,
 	Args: cobra.ExactArgs(1),
 	RunE: runLs,
 }
 
 func init() {
 	lsCmd.Flags().BoolVar(&jsonFlag, "json", false, "output as JSON")
+	lsCmd.Flags().BoolVarP(&lsRecursive, "recursive", "R", false, "list subdirectories recursively")
 	rootCmd.AddCommand(lsCmd)
 }
 
@@ -66,7 +72,7 @@ func runLs(cmd *cobra.Command, args []string) error {
 
 		server := cfg.GetWebDAVServer(serverName)
 		if server == nil {
-			return fmt.Errorf("WebDAV server '%s' not found. Add it with 'vget config webdav add %s'", serverName, serverName)
+			return remoteNotFound(serverName)
 		}
 
 		client, err = webdav.NewClientFromConfig(server)
@@ -100,19 +106,28 @@ func runLs(cmd *cobra.Command, args []string) error {
 	}
 
 	// List directory contents
-	files, err := client.List(ctx, dirPath)
+	var files []webdav.FileInfo
+	if lsRecursive {
+		// Names become paths relative to dirPath, in path order
+		files, err = client.Walk(ctx, dirPath, webdav.DefaultWalkWorkers)
+		for i := range files {
+			files[i].Name = strings.TrimPrefix(strings.TrimPrefix(files[i].Path, dirPath), "/")
+		}
+	} else {
+		files, err = client.List(ctx, dirPath)
+
+		// Sort: directories first, then files, alphabetically
+		sort.Slice(files, func(i, j int) bool {
+			if files[i].IsDir != files[j].IsDir {
+				return files[i].IsDir // dirs first
+			}
+			return files[i].Name < files[j].Name
+		})
+	}
 	if err != nil {
 		return fmt.Errorf("failed to list directory: %w", err)
 	}
 
-	// Sort: directories first, then files, alphabetically
-	sort.Slice(files, func(i, j int) bool {
-		if files[i].IsDir != files[j].IsDir {
-			return files[i].IsDir // dirs first
-		}
-		return files[i].Name < files[j].Name
-	})
-
 	// Build remote path prefix for full paths
 	remotePrefix := remotePath
 	if remotePrefix[len(remotePrefix)-1] != '/' {
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/guiyumin/vget/internal/config"
+	"github.com/guiyumin/vget/internal/webdav"
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <remote>:<path> <remote>:<path>",
+	Short: "Move or rename a file on a remote",
+	Long: `Move or rename a file or directory on a WebDAV remote. Both paths must
+be on the same remote; an existing destination is never overwritten.
+
+Examples:
+  vget mv pikpak:/Downloads/video.mp4 pikpak:/Movies/video.mp4
+  vget mv pikpak:/Movies/Old pikpak:/Movies/Archive`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srcRemote, src, err := webdav.ParseRemotePath(args[0])
+		if err != nil {
+			return err
+		}
+		dstRemote, dst, err := webdav.ParseRemotePath(args[1])
+		if err != nil {
+			return err
+		}
+		if srcRemote != dstRemote {
+			return fmt.Errorf("can't move between remotes (%s and %s)", args[0], args[1])
+		}
+
+		client, err := remoteClient(srcRemote)
+		if err != nil {
+			return err
+		}
+		if err := client.Move(context.Background(), src, dst); err != nil {
+			return err
+		}
+		fmt.Printf("Moved %s to %s\n", src, dst)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+}
+
+// remoteClient returns a client for the configured remote called name, or
+// for the default remote if name is empty
+func remoteClient(name string) (*webdav.Client, error) {
+	cfg := config.LoadOrDefault()
+	if name == "" {
+		name = cfg.DefaultRemote
+	}
+	server := cfg.GetWebDAVServer(name)
+	if server == nil {
+		return nil, remoteNotFound(name)
+	}
+	client, err := webdav.NewClientFromConfig(server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WebDAV client: %w", err)
+	}
+	return client, nil
+}
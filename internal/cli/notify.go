@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// lastDownloaded holds the files written by the most recent runDownload,
+// for the --notify message
+var lastDownloaded []string
+
+// notify shows a desktop notification for --notify. It does nothing on
+// headless systems or when no notifier is available.
+func notify(title, message string) {
+	if !notifyFlag {
+		return
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(5000, '%s', '%s', 'Info')
+Start-Sleep -Seconds 5
+$n.Dispose()`, powerShellEscape(title), powerShellEscape(message))
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", script)
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return // Headless
+		}
+		cmd = exec.Command("notify-send", "--app-name=vget", title, message)
+	}
+
+	_ = cmd.Run() // Best-effort
+}
+
+// notifyDownload reports the outcome of a single download
+func notifyDownload(url string, err error) {
+	if err != nil {
+		notify("vget: download failed", fmt.Sprintf("%s\n%v", url, err))
+		return
+	}
+	message := url
+	if len(lastDownloaded) > 0 {
+		names := make([]string, len(lastDownloaded))
+		for i, f := range lastDownloaded {
+			names[i] = filepath.Base(f)
+		}
+		message = strings.Join(names, ", ")
+	}
+	notify("vget: download complete", message)
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// powerShellEscape escapes s for a single-quoted PowerShell string
+func powerShellEscape(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/guiyumin/vget/internal/config"
+	"github.com/guiyumin/vget/internal/s3"
+)
+
+// resolvedOutputDir is the output directory of this run, set once by
+// resolveOutputDir; "" is the current directory
+var resolvedOutputDir string
+
+// resolveOutputDir sets the output directory from --output-dir, or else
+// the configured output_dir, with ~ expanded
+func resolveOutputDir(cfg *config.Config) {
+	dir := outputDir
+	if dir == "" {
+		dir = cfg.OutputDir
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[1:])
+		}
+	}
+	if dir == "." {
+		dir = ""
+	}
+	resolvedOutputDir = dir
+}
+
+// outputDirectory returns the output directory, or "" for the current
+// directory
+func outputDirectory() string {
+	return resolvedOutputDir
+}
+
+// resolveOutput places a relative -o name in the output directory; only
+// an absolute path (or an S3 URL) is used as is
+func resolveOutput() error {
+	if output == "" {
+		return nil
+	}
+	var err error
+	output, err = inOutputDir(output)
+	return err
+}
+
+// inOutputDir places a filename in the output directory, creating the
+// directory if needed. Absolute paths and S3 URLs are returned unchanged.
+func inOutputDir(name string) (string, error) {
+	dir := outputDirectory()
+	if dir == "" || s3.IsS3URL(name) || filepath.IsAbs(name) {
+		return name, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/guiyumin/vget/internal/config"
+)
+
+func TestOutputDirectoryPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("version: 1\noutput_dir: /from/file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VGET_CONFIG", path)
+	t.Setenv("VGET_OUTPUT_DIR", "")
+	saved := outputDir
+	t.Cleanup(func() {
+		outputDir = saved
+		resolvedOutputDir = ""
+	})
+	outputDir = ""
+
+	resolveOutputDir(config.LoadOrDefault())
+	if got := outputDirectory(); got != "/from/file" {
+		t.Errorf("config file only: got %q", got)
+	}
+
+	t.Setenv("VGET_OUTPUT_DIR", "/from/env")
+	resolveOutputDir(config.LoadOrDefault())
+	if got := outputDirectory(); got != "/from/env" {
+		t.Errorf("VGET_OUTPUT_DIR should win over the config file: got %q", got)
+	}
+
+	outputDir = "/from/flag"
+	resolveOutputDir(config.LoadOrDefault())
+	if got := outputDirectory(); got != "/from/flag" {
+		t.Errorf("--output-dir should win over VGET_OUTPUT_DIR: got %q", got)
+	}
+}
+
+func TestResolveOutputJoinsRelativeNames(t *testing.T) {
+	dir := t.TempDir()
+	resolvedOutputDir = filepath.Join(dir, "downloads")
+	t.Cleanup(func() {
+		resolvedOutputDir = ""
+		output = ""
+	})
+
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"video.mp4", filepath.Join(dir, "downloads", "video.mp4")},
+		{filepath.Join("sub", "video.mp4"), filepath.Join(dir, "downloads", "sub", "video.mp4")},
+		{filepath.Join(dir, "video.mp4"), filepath.Join(dir, "video.mp4")},
+		{"s3://bucket/video.mp4", "s3://bucket/video.mp4"},
+	}
+	for _, tt := range tests {
+		output = tt.output
+		if err := resolveOutput(); err != nil {
+			t.Fatal(err)
+		}
+		if output != tt.want {
+			t.Errorf("-o %s resolved to %s, want %s", tt.output, output, tt.want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "downloads")); err != nil {
+		t.Errorf("output directory wasn't created: %v", err)
+	}
+}
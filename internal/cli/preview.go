@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// previewWidth is the width in pixels of sixel previews
+const previewWidth = 240
+
+// previewProtocol returns the inline image protocol the terminal supports
+// ("iterm" or "sixel"), or "" if none is detected
+func previewProtocol() string {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return ""
+	}
+
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm":
+		return "iterm"
+	}
+	if os.Getenv("LC_TERMINAL") == "iTerm2" {
+		return "iterm"
+	}
+
+	termName := os.Getenv("TERM")
+	if strings.Contains(termName, "sixel") || strings.HasPrefix(termName, "mlterm") || strings.HasPrefix(termName, "foot") {
+		return "sixel"
+	}
+	return ""
+}
+
+// showPreview renders a small inline preview of an image file. Unsupported
+// terminals and undecodable images are skipped silently.
+func showPreview(file string) {
+	protocol := previewProtocol()
+	if protocol == "" {
+		return
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+
+	switch protocol {
+	case "iterm":
+		fmt.Printf("\033]1337;File=inline=1;width=30;preserveAspectRatio=1;size=%d:%s\a\n",
+			len(data), base64.StdEncoding.EncodeToString(data))
+	case "sixel":
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		fmt.Print(encodeSixel(img, previewWidth))
+		fmt.Println()
+	}
+}
+
+// encodeSixel renders img scaled to width pixels as a sixel sequence using
+// a fixed 6x6x6 color cube
+func encodeSixel(img image.Image, width int) string {
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return ""
+	}
+	if bounds.Dx() < width {
+		width = bounds.Dx()
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height == 0 {
+		height = 1
+	}
+
+	// Nearest-neighbor scale and quantize to palette indexes
+	pixels := make([]int, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x*bounds.Dx()/width, bounds.Min.Y+y*bounds.Dy()/height).RGBA()
+			pixels[y*width+x] = int(r>>8*6/256)*36 + int(g>>8*6/256)*6 + int(b>>8*6/256)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\033Pq")
+	for i := 0; i < 216; i++ {
+		fmt.Fprintf(&sb, "#%d;2;%d;%d;%d", i, i/36*20, i/6%6*20, i%6*20)
+	}
+
+	for band := 0; band < height; band += 6 {
+		// Colors used in this band
+		used := make(map[int]bool)
+		for y := band; y < band+6 && y < height; y++ {
+			for x := 0; x < width; x++ {
+				used[pixels[y*width+x]] = true
+			}
+		}
+
+		first := true
+		for color := range used {
+			if !first {
+				sb.WriteByte('$') // Back to start of the band
+			}
+			first = false
+			fmt.Fprintf(&sb, "#%d", color)
+
+			// Run-length encode the sixels for this color
+			var prev byte
+			run := 0
+			flush := func() {
+				if run == 0 {
+					return
+				}
+				if run > 3 {
+					fmt.Fprintf(&sb, "!%d%c", run, prev)
+				} else {
+					sb.WriteString(strings.Repeat(string(prev), run))
+				}
+			}
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && band+dy < height; dy++ {
+					if pixels[(band+dy)*width+x] == color {
+						bits |= 1 << dy
+					}
+				}
+				ch := 63 + bits
+				if run > 0 && ch == prev {
+					run++
+					continue
+				}
+				flush()
+				prev, run = ch, 1
+			}
+			flush()
+		}
+		sb.WriteByte('-') // Next band
+	}
+	sb.WriteString("\033\\")
+	return sb.String()
+}
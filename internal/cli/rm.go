@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/guiyumin/vget/internal/webdav"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var rmForce bool
+
+var rmCmd = &cobra.Command{
+	Use:   "rm <remote>:<path>",
+	Short: "Delete a file or directory on a remote",
+	Long: `Delete a file or directory (with everything in it) on a WebDAV remote.
+
+You are asked to confirm first. Without a terminal to ask on, --force is
+required.
+
+Examples:
+  vget rm pikpak:/Downloads/video.mp4
+  vget rm --force pikpak:/Downloads/Old`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remote, filePath, err := webdav.ParseRemotePath(args[0])
+		if err != nil {
+			return err
+		}
+		// "remote:", "remote://" and "remote:/." all name the root
+		filePath = path.Clean(filePath)
+		if filePath == "/" || filePath == "." {
+			return fmt.Errorf("refusing to delete the root of a remote")
+		}
+
+		client, err := remoteClient(remote)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		info, err := client.Stat(ctx, filePath)
+		if err != nil {
+			return fmt.Errorf("failed to access path: %w", err)
+		}
+
+		if !rmForce {
+			if !term.IsTerminal(int(os.Stdin.Fd())) {
+				return fmt.Errorf("not deleting %s without confirmation; use --force", args[0])
+			}
+			what := "file"
+			if info.IsDir {
+				what = "directory and everything in it"
+			}
+			fmt.Printf("Delete %s %s? [y/N] ", what, args[0])
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				fmt.Println("Cancelled")
+				return nil
+			}
+		}
+
+		if err := client.Remove(ctx, filePath); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	rmCmd.Flags().BoolVar(&rmForce, "force", false, "delete without asking for confirmation")
+	rootCmd.AddCommand(rmCmd)
+}
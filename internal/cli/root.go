@@ -2,23 +2,92 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/guiyumin/vget/internal/config"
 	"github.com/guiyumin/vget/internal/downloader"
 	"github.com/guiyumin/vget/internal/extractor"
+	"github.com/guiyumin/vget/internal/httpclient"
 	"github.com/guiyumin/vget/internal/i18n"
+	"github.com/guiyumin/vget/internal/muxer"
+	"github.com/guiyumin/vget/internal/s3"
 	"github.com/guiyumin/vget/internal/version"
 	"github.com/guiyumin/vget/internal/webdav"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
-	output    string
-	quality   string
-	info      bool
-	inputFile string
+	output             string
+	quality            string
+	info               bool
+	inputFile          string
+	overwriteIfSmaller bool
+	force              bool
+	continueDL         bool
+	noOverwrite        bool
+	existing           downloader.ExistingMode
+	noMtime            bool
+	subLangs           string
+	listSubs           bool
+	writeLinkFlag      bool
+	writeHTMLFlag      bool
+	sourceAddress      string
+	zipOutput          string
+	geoBypassCountry   string
+	noProgress         bool
+	progressStyle      string
+	maxConnsPerHost    int
+	printTraffic       bool
+	verbose            bool
+	cookiesFromBrowser string
+	httpVersion        string
+	previewFlag        bool
+	refresh            bool
+	flat               bool
+	thread             bool
+	noInferExt         bool
+	waitForVideo       int
+	configFile         string
+	extractAudio       bool
+	audioOnly          bool
+	checksum           string
+	audioFormat        string
+	audioQuality       string
+	keepVideo          bool
+	trimRange          string
+	notifyFlag         bool
+	remuxVideo         string
+	sleepInterval      float64
+	maxSleepInterval   float64
+	dedup              bool
+	abortOnError       bool
+	writeAllFormats    bool
+	extractTimeout     time.Duration
+	offline            bool
+	mergeOutputFormat  string
+	noCheckCertificate bool
+	partRetries        int
+	playlistItems      string
+	itemRanges         []itemRange
+	keepFragments      bool
+	fragmentDir        string
+	recursive          bool
+	strict             bool
+	maxTotalSize       string
+	limitRate          string
+	proxyURL           string
+	outputDir          string
+	trimStart          time.Duration
+	trimEnd            time.Duration
 )
 
 var rootCmd = &cobra.Command{
@@ -26,6 +95,74 @@ var rootCmd = &cobra.Command{
 	Short:   "Versatile command-line toolkit for downloading audio, video, podcasts, and more",
 	Version: version.Version,
 	Args:    cobra.MaximumNArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if configFile != "" {
+			config.SetPath(configFile)
+		}
+		switch progressStyle {
+		case "", "bar":
+		case "detailed":
+			downloader.SetProgressMode(downloader.ProgressDetailed)
+		default:
+			return fmt.Errorf("invalid --progress %q (use bar or detailed)", progressStyle)
+		}
+		if noProgress {
+			downloader.SetProgressMode(downloader.ProgressNone)
+		}
+		if noInferExt {
+			extractor.SetInferExtensions(false)
+		}
+		if partRetries < 0 {
+			return fmt.Errorf("invalid --part-retries: %d", partRetries)
+		}
+		downloader.SetPartRetries(partRetries)
+		rate, err := parseSize(limitRate)
+		if err != nil {
+			return fmt.Errorf("invalid --limit-rate: %w", err)
+		}
+		downloader.SetRateLimit(rate)
+		if existing, err = existingMode(); err != nil {
+			return err
+		}
+		downloader.SetHLSFragments(fragmentDir, keepFragments)
+		downloader.SetPreserveMtime(!noMtime)
+		cfg := config.LoadOrDefault()
+		resolveOutputDir(cfg)
+		extractor.SetOrder(cfg.ExtractorOrder)
+		extractor.Disable(cfg.DisabledExtractors...)
+		extractor.SetTwitterBearerToken(cfg.TwitterBearerToken)
+		if cfg.TwitterTimeout != "" {
+			// Only Twitter uses it, so a typo mustn't stop every other command
+			timeout, err := time.ParseDuration(cfg.TwitterTimeout)
+			if err != nil || timeout < 0 {
+				fmt.Fprintf(os.Stderr, "\033[33mWarning: invalid twitter_timeout %q in config; using the default.\033[0m\n", cfg.TwitterTimeout)
+				timeout = 0
+			}
+			extractor.SetTwitterCallTimeout(timeout)
+		}
+		if !cmd.Flags().Changed("limit-concurrent-per-host") && cfg.MaxConnsPerHost != nil {
+			maxConnsPerHost = *cfg.MaxConnsPerHost
+		}
+		if !cmd.Flags().Changed("proxy") {
+			proxyURL = cfg.Proxy
+		}
+		if noCheckCertificate {
+			fmt.Fprintln(os.Stderr, "\033[33mWARNING: TLS certificate verification is disabled; connections can be intercepted.\033[0m")
+		}
+		return httpclient.Configure(httpclient.Options{
+			SourceAddress:      sourceAddress,
+			GeoBypassCountry:   geoBypassCountry,
+			PrintTraffic:       printTraffic,
+			Verbose:            verbose,
+			HTTPVersion:        httpVersion,
+			MaxConnsPerHost:    maxConnsPerHost,
+			InsecureSkipVerify: noCheckCertificate,
+			Proxy:              proxyURL,
+		})
+	},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return resolveOutput()
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Batch mode: read URLs from file
 		if inputFile != "" {
@@ -40,7 +177,9 @@ var rootCmd = &cobra.Command{
 			cmd.Help()
 			return
 		}
-		if err := runDownload(args[0]); err != nil {
+		err := runDownload(args[0])
+		notifyDownload(args[0], err)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -48,10 +187,94 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&output, "output", "o", "", "output filename")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "use this config file instead of the default (also VGET_CONFIG)")
+	rootCmd.PersistentFlags().StringVar(&sourceAddress, "source-address", "", "bind outgoing connections to this local IP address")
+	rootCmd.PersistentFlags().StringVar(&geoBypassCountry, "geo-bypass-country", "", "fake X-Forwarded-For from this country (e.g. US) to work around simple geo-blocks (best-effort)")
+	rootCmd.PersistentFlags().BoolVar(&noProgress, "no-progress", false, "don't show download progress, only start and finish lines")
+	rootCmd.PersistentFlags().StringVar(&progressStyle, "progress", "bar", "progress display: bar or detailed (one bar per download stream)")
+	rootCmd.PersistentFlags().StringVar(&httpVersion, "http-version", "", "force HTTP version: 1.1 or 2 (default: auto; http:// URLs always use 1.1)")
+	rootCmd.PersistentFlags().BoolVar(&keepFragments, "keep-fragments", false, "keep HLS segments in <output>.fragments after downloading")
+	rootCmd.PersistentFlags().StringVar(&fragmentDir, "fragment-dir", "", "stage HLS segments in <output>.fragments below this directory")
+	rootCmd.PersistentFlags().IntVar(&partRetries, "part-retries", 2, "times to download a part again from scratch when its content doesn't match the requested range")
+	rootCmd.PersistentFlags().StringVar(&proxyURL, "proxy", "", "send requests through this HTTP(S) or SOCKS5 proxy, e.g. socks5://127.0.0.1:1080 (default: proxy from config or environment)")
+	rootCmd.PersistentFlags().StringVar(&outputDir, "output-dir", "", "save downloads in this directory (default: output_dir from config)")
+	rootCmd.PersistentFlags().StringVar(&limitRate, "limit-rate", "", "cap the download rate, in bytes per second (e.g. 500K, 2M)")
+	rootCmd.PersistentFlags().IntVar(&maxConnsPerHost, "limit-concurrent-per-host", httpclient.DefaultMaxConnsPerHost, "maximum open connections to a single host (0 for no limit)")
+	rootCmd.PersistentFlags().BoolVar(&noCheckCertificate, "no-check-certificate", false, "don't verify TLS certificates (e.g. behind a TLS-intercepting proxy); unsafe")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "print connection details")
+	rootCmd.PersistentFlags().BoolVar(&printTraffic, "print-traffic", false, "dump HTTP requests and responses to stderr")
+	rootCmd.PersistentFlags().MarkHidden("print-traffic")
+
+	rootCmd.Flags().StringVarP(&output, "output", "o", "", "output filename, relative to the output directory unless absolute (or s3://bucket/key to upload to S3)")
 	rootCmd.Flags().StringVarP(&quality, "quality", "q", "", "preferred quality (e.g., 1080p, 720p)")
 	rootCmd.Flags().BoolVar(&info, "info", false, "show video info without downloading")
 	rootCmd.Flags().StringVarP(&inputFile, "file", "f", "", "read URLs from file (one per line)")
+	rootCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "download every file below a WebDAV directory")
+	rootCmd.Flags().StringVar(&cookiesFromBrowser, "cookies-from-browser", "", "use cookies from your browser (chrome or firefox) for the target site")
+	rootCmd.Flags().BoolVar(&flat, "flat", false, "list the items of a podcast/collection URL without downloading")
+	rootCmd.Flags().BoolVar(&thread, "thread", false, "download the media of every tweet in the author's thread, numbered in order")
+	rootCmd.Flags().BoolVar(&jsonFlag, "json", false, "output --flat listing as JSON")
+	rootCmd.Flags().BoolVar(&noInferExt, "no-infer-ext", false, "don't add an extension from Content-Type to extension-less files")
+	rootCmd.Flags().IntVar(&waitForVideo, "wait-for-video", 0, "keep retrying for up to this many seconds while a video is still processing")
+	rootCmd.Flags().BoolVarP(&extractAudio, "extract-audio", "x", false, "extract the audio track of downloaded videos (requires ffmpeg)")
+	rootCmd.Flags().BoolVar(&audioOnly, "audio-only", false, "save only the audio: a site's separate audio stream if it has one, otherwise the audio track of the video as m4a (requires ffmpeg; without it the video is kept)")
+	rootCmd.Flags().StringVar(&audioFormat, "audio-format", "", "audio format for --extract-audio: mp3 or m4a (default mp3, m4a with --audio-only)")
+	rootCmd.Flags().StringVar(&audioQuality, "audio-quality", "", "audio quality for --extract-audio: VBR level 0 (best) to 9, or a bitrate like 192k (default: VBR 2 for mp3, 192k for m4a)")
+	rootCmd.Flags().StringVar(&subLangs, "sub-langs", "", "download subtitles in these languages, comma-separated, or \"all\" (e.g. en,ja)")
+	rootCmd.Flags().BoolVar(&listSubs, "list-subs", false, "list the available subtitle languages without downloading")
+	rootCmd.Flags().BoolVar(&keepVideo, "keep-video", true, "keep the original video or audio file after --extract-audio")
+	rootCmd.Flags().StringVar(&trimRange, "trim", "", "keep only this time range of videos, e.g. 00:01:00-00:02:30 (requires ffmpeg)")
+	rootCmd.Flags().StringVar(&remuxVideo, "remux-video", "", "remux downloaded videos into this container without re-encoding: mp4, mkv or webm (requires ffmpeg)")
+	rootCmd.Flags().StringVar(&mergeOutputFormat, "merge-output-format", "mkv", "container for formats with separate video and audio streams: mkv or mp4 (requires ffmpeg)")
+	rootCmd.Flags().Float64Var(&sleepInterval, "sleep-interval", 0, "seconds to wait between downloads in batch mode")
+	rootCmd.Flags().Float64Var(&maxSleepInterval, "max-sleep-interval", 0, "wait a random time between --sleep-interval and this many seconds")
+	rootCmd.Flags().StringVar(&maxTotalSize, "max-total-size", "", "in batch mode, stop starting downloads once this much has been downloaded (e.g. 10G)")
+	rootCmd.Flags().DurationVar(&extractTimeout, "extract-timeout", 3*time.Minute, "give up extracting media info after this long (0 for no limit)")
+	rootCmd.Flags().BoolVar(&writeAllFormats, "write-all-formats", false, "download every available format instead of the best one (uses a lot of bandwidth)")
+	rootCmd.Flags().MarkHidden("write-all-formats")
+	rootCmd.Flags().StringVar(&playlistItems, "playlist-items", "", "only download these items of an image set, multi-video post or --flat listing, e.g. 1,3-5,8")
+	rootCmd.Flags().BoolVar(&abortOnError, "abort-on-error", false, "stop a batch or image set at the first failed download (default: continue and report failures at the end)")
+	rootCmd.Flags().BoolVar(&dedup, "dedup", false, "delete new downloads whose content matches a file already in the output directory")
+	rootCmd.Flags().BoolVar(&notifyFlag, "notify", false, "show a desktop notification when the download or batch finishes")
+	rootCmd.Flags().BoolVar(&refresh, "refresh", false, "ignore cached extraction results")
+	rootCmd.Flags().BoolVar(&offline, "offline", false, "only use cached extraction results, never contact the site's API")
+	rootCmd.Flags().BoolVar(&writeLinkFlag, "write-link", false, "save a shortcut file (.url/.desktop/.webloc) pointing at the source URL")
+	rootCmd.Flags().BoolVar(&writeHTMLFlag, "write-html", false, "save a self-contained HTML page with the post text, author and downloaded media")
+	rootCmd.Flags().BoolVar(&previewFlag, "preview", false, "show downloaded images inline (iTerm2 or sixel terminals)")
+	rootCmd.Flags().StringVar(&zipOutput, "zip", "", "write image sets and WebDAV directories into a zip archive")
+	rootCmd.Flags().StringVar(&checksum, "checksum", "", "verify the downloaded file against sha256:<hex>, sha1:<hex> or md5:<hex>, deleting it on a mismatch")
+	rootCmd.Flags().BoolVar(&strict, "strict", false, "fail when a downloaded file turns out to be a web page rather than media")
+	rootCmd.Flags().BoolVar(&force, "force", false, "always download from scratch, overwriting existing files")
+	rootCmd.Flags().BoolVarP(&continueDL, "continue", "c", false, "resume .vget.part files and fail if the server can't resume instead of starting over")
+	rootCmd.Flags().BoolVarP(&noOverwrite, "no-overwrite", "w", false, "skip every file that already exists")
+	rootCmd.Flags().BoolVar(&noMtime, "no-mtime", false, "don't set the file modification time from the server (Last-Modified)")
+	rootCmd.Flags().BoolVar(&overwriteIfSmaller, "overwrite-if-smaller", false, "skip files that are already complete, redownload incomplete ones")
+	rootCmd.Flags().MarkDeprecated("overwrite-if-smaller", "this is the default now")
+	rootCmd.Flags().StringVar(&inputFile, "batch-file", "", "same as --file")
+
+	// vget batch takes the same download flags as vget itself
+	batchCmd.Flags().AddFlagSet(rootCmd.Flags())
+}
+
+// existingMode returns how existing files are handled. By default complete
+// files are skipped and .part files resumed; --force, --continue and
+// --no-overwrite pick one behavior and can't be combined.
+func existingMode() (downloader.ExistingMode, error) {
+	mode := downloader.ExistingAuto
+	var flags []string
+	if force {
+		mode, flags = downloader.ExistingForce, append(flags, "--force")
+	}
+	if continueDL {
+		mode, flags = downloader.ExistingContinue, append(flags, "--continue")
+	}
+	if noOverwrite {
+		mode, flags = downloader.ExistingNoOverwrite, append(flags, "--no-overwrite")
+	}
+	if len(flags) > 1 {
+		return mode, fmt.Errorf("%s can't be combined", strings.Join(flags, " and "))
+	}
+	return mode, nil
 }
 
 func Execute() error {
@@ -67,6 +290,12 @@ func runDownload(url string) error {
 		fmt.Fprintf(os.Stderr, "\033[33m%s. Run 'vget init'.\033[0m\n", t.Errors.ConfigNotFound)
 	}
 
+	if checksum != "" {
+		if err := downloader.ValidateChecksum(checksum); err != nil {
+			return fmt.Errorf("invalid --checksum: %w", err)
+		}
+	}
+
 	// Handle WebDAV URLs specially
 	if webdav.IsWebDAVURL(url) {
 		return runWebDAVDownload(url, cfg.Language)
@@ -78,25 +307,197 @@ func runDownload(url string) error {
 		return fmt.Errorf("%s: %s", t.Errors.NoExtractor, url)
 	}
 
-	// Extract media info with spinner
-	media, err := runExtractWithSpinner(ext, url, cfg.Language)
-	if err != nil {
+	if cookiesFromBrowser != "" {
+		if err := loadBrowserCookies(cookiesFromBrowser, url); err != nil {
+			return err
+		}
+	}
+
+	if offline && (flat || refresh) {
+		return fmt.Errorf("--offline can't be used with --flat or --refresh")
+	}
+	var err error
+	if playlistItems != "" {
+		if itemRanges, err = parsePlaylistItems(playlistItems); err != nil {
+			return err
+		}
+	}
+
+	if flat {
+		return listFlat(ext, url)
+	}
+	if thread {
+		return runThread(ext, url)
+	}
+
+	if audioOnly {
+		// --audio-only is -x --keep-video=false, saving m4a by default
+		extractAudio, keepVideo = true, false
+		if audioFormat == "" {
+			audioFormat = "m4a"
+		}
+	}
+	if extractAudio {
+		if audioFormat == "" {
+			audioFormat = "mp3"
+		}
+		if err := muxer.ValidateAudioFormat(audioFormat); err != nil {
+			return err
+		}
+		if err := muxer.ValidateAudioQuality(audioQuality); err != nil {
+			return err
+		}
+	}
+	if trimRange != "" {
+		if trimStart, trimEnd, err = muxer.ParseTimeRange(trimRange); err != nil {
+			return err
+		}
+	}
+	if remuxVideo != "" {
+		if err := muxer.ValidateContainer(remuxVideo); err != nil {
+			return err
+		}
+	}
+	if err := muxer.ValidateMergeFormat(mergeOutputFormat); err != nil {
 		return err
 	}
 
+	// Reuse a recent extraction (e.g. from --info) unless --refresh. With
+	// --offline any cached result is used, however old, and nothing is fetched.
+	var media extractor.Media
+	var cached bool
+	if offline {
+		if media, cached = extractor.LoadCachedMaxAge(url, 0); !cached {
+			return fmt.Errorf("%s has no cached extraction result (--offline)", url)
+		}
+	} else {
+		media, cached = extractor.LoadCached(url)
+	}
+	if refresh || !cached {
+		// Extract media info with spinner
+		media, err = extractWithWait(ext, url, cfg.Language, time.Duration(waitForVideo)*time.Second)
+		if errors.Is(err, extractor.ErrNotMedia) {
+			return fmt.Errorf("%s: %s (web page, not directly downloadable)", t.Errors.NoExtractor, url)
+		}
+		if err != nil {
+			return err
+		}
+		extractor.SaveCached(url, media) // Best-effort
+	}
+
 	dl := downloader.New(cfg.Language)
+	dl.Existing = existing
+	if !offline {
+		dl.Refresh = func(oldURL string) (string, error) {
+			return refreshURL(ext, url, media, oldURL)
+		}
+	}
+
+	selectedMedia, err := selectPlaylistItems(media, itemRanges)
+	if err != nil {
+		return err
+	}
+	selectedMedia = withTitlePrefix(selectedMedia)
+
+	if listSubs {
+		v, ok := selectedMedia.(*extractor.VideoMedia)
+		if !ok {
+			return fmt.Errorf("--list-subs only applies to videos")
+		}
+		printSubtitles(v)
+		return nil
+	}
+
+	// The --quality flag wins over per-extractor and global config
+	pref := cfg.PreferenceFor(ext.Name())
+	if quality != "" {
+		pref.Quality = quality
+	}
+
+	// A thread or batch archive only takes images
+	if sharedArchive != nil && !info {
+		if _, ok := selectedMedia.(*extractor.ImageMedia); !ok {
+			return fmt.Errorf("--zip only archives images; %s is not an image post", url)
+		}
+	}
 
 	// Handle based on media type
-	switch m := media.(type) {
+	var files []string
+	switch m := selectedMedia.(type) {
 	case *extractor.VideoMedia:
-		return downloadVideo(m, dl, t, cfg.Language)
+		files, err = downloadVideo(m, dl, pref, t, cfg.Language)
 	case *extractor.AudioMedia:
-		return downloadAudio(m, dl)
+		files, err = downloadAudio(m, dl)
 	case *extractor.ImageMedia:
-		return downloadImages(m, dl)
+		files, err = downloadImages(m, dl)
 	default:
 		return fmt.Errorf("unsupported media type")
 	}
+	if err != nil {
+		return err
+	}
+	if !info && !s3.IsS3URL(output) {
+		if err := verifyMediaTypes(files); err != nil {
+			return err
+		}
+		if err := verifyUserChecksum(files); err != nil {
+			return err
+		}
+	}
+	if dedup && !info && !s3.IsS3URL(output) {
+		files = removeDuplicates(files)
+	}
+	lastDownloaded = files
+
+	if info || s3.IsS3URL(output) {
+		return nil
+	}
+	if writeHTMLFlag {
+		if err := writeHTML(selectedMedia, url, files); err != nil {
+			return err
+		}
+	}
+	if writeLinkFlag {
+		return writeLink(outputBaseName(media), url)
+	}
+	return nil
+}
+
+// verifyUserChecksum checks the downloaded file against --checksum and
+// deletes it if it doesn't match
+func verifyUserChecksum(files []string) error {
+	if checksum == "" {
+		return nil
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("--checksum needs a download of a single file, got %d files", len(files))
+	}
+
+	if err := downloader.VerifyChecksum(files[0], checksum); err != nil {
+		if os.Remove(files[0]) == nil {
+			return fmt.Errorf("%w; deleted %s", err, files[0])
+		}
+		return err
+	}
+	algo, _, _ := strings.Cut(checksum, ":")
+	fmt.Printf("  Checksum OK (%s)\n", algo)
+	return nil
+}
+
+// verifyMediaTypes warns about downloaded files that turn out to be text
+// rather than media, failing instead with --strict
+func verifyMediaTypes(files []string) error {
+	for _, f := range files {
+		err := downloader.VerifyMediaType(f)
+		if err == nil || errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if strict {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "  Warning: %v\n", err)
+	}
+	return nil
 }
 
 func runWebDAVDownload(rawURL, lang string) error {
@@ -114,10 +515,13 @@ func runWebDAVDownload(rawURL, lang string) error {
 		if err != nil {
 			return err
 		}
+		if serverName == "" {
+			serverName = cfg.DefaultRemote
+		}
 
 		server := cfg.GetWebDAVServer(serverName)
 		if server == nil {
-			return fmt.Errorf("WebDAV server '%s' not found. Add it with 'vget config webdav add %s'", serverName, serverName)
+			return remoteNotFound(serverName)
 		}
 
 		client, err = webdav.NewClientFromConfig(server)
@@ -145,8 +549,21 @@ func runWebDAVDownload(rawURL, lang string) error {
 		return fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	// If it's a directory, open the TUI browser
+	// Zip the whole directory when requested
+	if fileInfo.IsDir && zipOutput != "" {
+		return zipWebDAVDir(ctx, client, filePath, zipOutput)
+	}
+
+	if fileInfo.IsDir && recursive {
+		return downloadWebDAVDir(ctx, client, filePath, lang)
+	}
+
+	// A directory without --recursive: let the user pick from the TUI
+	// browser, or list it when there's no terminal to browse in
 	if fileInfo.IsDir {
+		if !term.IsTerminal(int(os.Stdout.Fd())) || os.Getenv("TERM") == "dumb" {
+			return listWebDAVDir(ctx, client, rawURL, filePath)
+		}
 		result, err := RunBrowseTUI(client, serverName, filePath)
 		if err != nil {
 			return fmt.Errorf("browse failed: %w", err)
@@ -154,6 +571,9 @@ func runWebDAVDownload(rawURL, lang string) error {
 		if result.Cancelled {
 			return nil // User cancelled, no error
 		}
+		if result.SelectedDir != "" {
+			return downloadWebDAVDir(ctx, client, result.SelectedDir, lang)
+		}
 		// User selected a file, update filePath and continue with download
 		filePath = result.SelectedFile
 		// Re-fetch file info for the selected file
@@ -163,28 +583,79 @@ func runWebDAVDownload(rawURL, lang string) error {
 		}
 	}
 
-	// Determine output filename
-	outputFile := output
+	return downloadWebDAVFile(ctx, client, filePath, fileInfo, output, lang)
+}
+
+// downloadWebDAVFile downloads a single remote file to outputFile, naming
+// it after the remote file when outputFile is empty
+func downloadWebDAVFile(ctx context.Context, client *webdav.Client, filePath string, fileInfo *webdav.FileInfo, outputFile, lang string) error {
 	if outputFile == "" {
-		outputFile = webdav.ExtractFilename(filePath)
+		outputFile = webdav.ExtractFilename(filePath, fileInfo.DisplayName)
+		if filepath.Ext(outputFile) == "" {
+			if ext := extractor.ExtensionForType(fileInfo.MIMEType); ext != "" {
+				outputFile += "." + ext
+			}
+		}
+		var err error
+		if outputFile, err = inOutputDir(outputFile); err != nil {
+			return err
+		}
 	}
 
 	fmt.Printf("  WebDAV: %s (%s)\n", fileInfo.Name, formatSize(fileInfo.Size))
 
-	// Use multi-stream download for better performance
-	fileURL := client.GetFileURL(filePath)
-	authHeader := client.GetAuthHeader()
-	msConfig := downloader.DefaultMultiStreamConfig()
-
-	return downloader.RunMultiStreamDownloadWithAuthTUI(
-		fileURL,
-		authHeader,
-		outputFile,
-		fileInfo.Name,
-		lang,
-		fileInfo.Size,
-		msConfig,
-	)
+	dl := downloader.New(lang)
+	dl.Existing = existing
+	if !dl.ShouldDownload(outputFile, fileInfo.Size) {
+		return nil
+	}
+
+	var err error
+	if client.UsesDigestAuth() {
+		// Digest auth can't be passed on as a header, so stream through the client
+		reopen := func(offset int64) (io.ReadCloser, bool, error) {
+			return client.OpenAt(ctx, filePath, offset)
+		}
+		reader, _, openErr := reopen(0)
+		if openErr != nil {
+			return openErr
+		}
+		err = dl.DownloadFromReader(ctx, reader, fileInfo.Size, outputFile, fileInfo.Name, reopen)
+	} else {
+		// Use multi-stream download for better performance
+		err = downloader.RunMultiStreamDownloadWithAuthTUI(
+			client.GetFileURL(filePath),
+			client.GetAuthHeader(),
+			outputFile,
+			fileInfo.Name,
+			lang,
+			fileInfo.Size,
+			downloader.DefaultMultiStreamConfig(),
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Verify against the server's checksum when it advertises one
+	if fileInfo.Checksum != "" && !s3.IsS3URL(outputFile) {
+		if err := downloader.VerifyChecksum(outputFile, fileInfo.Checksum); err != nil {
+			return err
+		}
+		algo, _, _ := strings.Cut(fileInfo.Checksum, ":")
+		fmt.Printf("  Checksum OK (%s)\n", algo)
+	}
+	if !s3.IsS3URL(outputFile) {
+		if err := verifyUserChecksum([]string{outputFile}); err != nil {
+			return err
+		}
+	}
+
+	// Keep the remote timestamp; failing to is not worth an error
+	if !noMtime && !fileInfo.ModTime.IsZero() && !s3.IsS3URL(outputFile) {
+		os.Chtimes(outputFile, fileInfo.ModTime, fileInfo.ModTime)
+	}
+	return nil
 }
 
 func formatSize(b int64) string {
@@ -200,52 +671,321 @@ func formatSize(b int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGTPE"[exp])
 }
 
-func downloadVideo(m *extractor.VideoMedia, dl *downloader.Downloader, t *i18n.Translations, lang string) error {
+func downloadVideo(m *extractor.VideoMedia, dl *downloader.Downloader, pref config.FormatPreference, t *i18n.Translations, lang string) ([]string, error) {
+	if writeAllFormats && !info {
+		return downloadAllFormats(m, dl, t, lang)
+	}
+
+	// Posts with several videos are downloaded one by one with an index
+	// suffix, as is a video picked out of one by --playlist-items
+	if len(m.Videos) > 1 || len(m.Videos) == 1 && m.Videos[0].Number > 0 {
+		if info {
+			for i, v := range m.Videos {
+				n := itemNumber(v.Number, i, len(m.Videos))
+				if v.Width > 0 && v.Height > 0 {
+					fmt.Printf("  Video %d (%dx%d):\n", n, v.Width, v.Height)
+				} else {
+					fmt.Printf("  Video %d:\n", n)
+				}
+				printVideoFormats(v.Formats)
+			}
+			return nil, nil
+		}
+
+		var files []string
+		for i, v := range m.Videos {
+			n := itemNumber(v.Number, i, len(m.Videos))
+			if err := checkTrim(v.Duration); err != nil {
+				return files, fmt.Errorf("video %d: %w", n, err)
+			}
+			file, err := downloadVideoFormats(m, v.Formats, fmt.Sprintf("_%d", n), dl, pref, t, lang)
+			if err != nil {
+				return files, fmt.Errorf("failed to download video %d: %w", n, err)
+			}
+			out, err := postProcessVideo(file)
+			files = append(files, out...)
+			if err != nil {
+				return files, err
+			}
+		}
+		return files, nil
+	}
+
 	// Info only mode
 	if info {
-		for i, f := range m.Formats {
-			fmt.Printf("  [%d] %s %dx%d (%s)\n", i, f.Quality, f.Width, f.Height, f.Ext)
+		if ratio := m.AspectRatio(); ratio != "" {
+			fmt.Printf("  Dimensions: %dx%d (%s)\n", m.Width, m.Height, ratio)
 		}
-		return nil
+		printVideoFormats(m.Formats)
+		return nil, nil
+	}
+
+	if err := checkTrim(m.Duration); err != nil {
+		return nil, err
+	}
+
+	file, err := downloadVideoFormats(m, m.Formats, "", dl, pref, t, lang)
+	if err != nil {
+		return nil, err
+	}
+	files, err := postProcessVideo(file)
+	if err != nil || subLangs == "" || s3.IsS3URL(file) {
+		return files, err
+	}
+	subs, err := downloadSubtitles(m, file, dl)
+	return append(files, subs...), err
+}
+
+// postProcessVideo applies --remux-video and --extract-audio to a
+// downloaded video and returns the files that remain
+func postProcessVideo(file string) ([]string, error) {
+	if s3.IsS3URL(file) {
+		return []string{file}, nil
 	}
 
-	// Select best format (or by quality flag)
-	format := selectVideoFormat(m.Formats, quality)
+	// --audio-only may have fetched a separate audio stream already
+	if audioOnly && fileKind(file) == "audio" {
+		return transcodeAudio(file)
+	}
+	if audioOnly && !muxer.Available() {
+		fmt.Fprintln(os.Stderr, "  Warning: ffmpeg not found; keeping the video (install ffmpeg to extract its audio)")
+		return []string{file}, nil
+	}
+
+	if remuxVideo != "" {
+		remuxed, err := muxer.Remux(file, remuxVideo)
+		if err != nil {
+			return []string{file}, err
+		}
+		if remuxed != file {
+			fmt.Printf("  Remuxed to %s\n", remuxed)
+		}
+		file = remuxed
+	}
+
+	if !extractAudio {
+		return []string{file}, nil
+	}
+
+	audioFile := strings.TrimSuffix(file, filepath.Ext(file)) + "." + audioFormat
+	if err := muxer.ExtractAudio(file, audioFile, audioFormat, audioQuality); err != nil {
+		return []string{file}, fmt.Errorf("failed to extract audio: %w", err)
+	}
+	fmt.Printf("  Audio saved to %s\n", audioFile)
+
+	if keepVideo {
+		return []string{file, audioFile}, nil
+	}
+	if err := os.Remove(file); err != nil {
+		return []string{file, audioFile}, err
+	}
+	return []string{audioFile}, nil
+}
+
+func printVideoFormats(formats []extractor.VideoFormat) {
+	for i, f := range formats {
+		fmt.Printf("  [%d] %s %dx%d (%s)\n", i, f.Quality, f.Width, f.Height, f.Ext)
+	}
+}
+
+// downloadVideoFormats picks the best of formats and downloads it, adding
+// suffix to the output name. It returns the output filename.
+func downloadVideoFormats(m *extractor.VideoMedia, formats []extractor.VideoFormat, suffix string, dl *downloader.Downloader, pref config.FormatPreference, t *i18n.Translations, lang string) (string, error) {
+	// Select best format (or by quality preference)
+	format := selectVideoFormat(formats, pref)
 	if format == nil {
-		return fmt.Errorf("%s", t.Download.NoFormats)
+		return "", fmt.Errorf("%s", t.Download.NoFormats)
 	}
 
 	fmt.Printf("  %s: %s (%s)\n", t.Download.SelectedFormat, format.Quality, format.Ext)
 
 	// Determine output filename
 	outputFile := output
-	if outputFile == "" {
+	if outputFile != "" {
+		ext := filepath.Ext(outputFile)
+		outputFile = strings.TrimSuffix(outputFile, ext) + suffix + ext
+	} else {
 		title := extractor.SanitizeFilename(m.Title)
 		// For m3u8, output as .ts (MPEG-TS container)
 		ext := format.Ext
 		if ext == "m3u8" {
 			ext = "ts"
 		}
+		if format.AudioURL != "" {
+			ext = mergeOutputFormat
+		}
+		if ext != "" {
+			ext = "." + ext
+		}
 		if title != "" {
-			outputFile = fmt.Sprintf("%s.%s", title, ext)
+			outputFile = fmt.Sprintf("%s%s%s", title, suffix, ext)
 		} else {
-			outputFile = fmt.Sprintf("%s.%s", m.ID, ext)
+			outputFile = fmt.Sprintf("%s%s%s", m.ID, suffix, ext)
+		}
+		var err error
+		if outputFile, err = inOutputDir(outputFile); err != nil {
+			return "", err
 		}
 	}
 
-	// Use HLS downloader for m3u8 streams
+	if trimRange != "" && s3.IsS3URL(outputFile) {
+		return "", fmt.Errorf("--trim can't be used with S3 output")
+	}
+	if keepFragments && fragmentDir == "" && s3.IsS3URL(outputFile) {
+		return "", fmt.Errorf("--keep-fragments with S3 output needs a local --fragment-dir")
+	}
+
+	// Use HLS downloader for m3u8 streams; with --trim only the segments
+	// covering the range are fetched
 	if format.Ext == "m3u8" {
-		return downloader.RunHLSDownloadTUI(format.URL, outputFile, m.ID, lang)
+		// The playlist's total size isn't known up front
+		if !dl.ShouldDownload(outputFile, -1) {
+			return outputFile, nil
+		}
+		if trimRange == "" {
+			return outputFile, downloader.RunHLSDownloadTUI(format.URL, outputFile, m.ID, lang)
+		}
+		offset, err := downloader.RunHLSClipTUI(format.URL, outputFile, m.ID, lang, trimStart, trimEnd)
+		if err != nil {
+			return outputFile, err
+		}
+		end := trimEnd
+		if end > 0 {
+			end -= offset
+		}
+		return outputFile, trimVideo(outputFile, trimStart-offset, end)
+	}
+
+	if audioOnly && format.AudioURL != "" && trimRange == "" {
+		// The audio is served separately; the video stream isn't needed
+		return downloadAudioStream(format, outputFile, m.ID, dl)
+	}
+
+	if format.AudioURL != "" {
+		var err error
+		if outputFile, err = downloadAndMerge(format, outputFile, m.ID, dl); err != nil || trimRange == "" {
+			return outputFile, err
+		}
+		return outputFile, trimVideo(outputFile, trimStart, trimEnd)
+	}
+
+	if err := dl.Download(format.URL, outputFile, m.ID); err != nil || trimRange == "" {
+		return outputFile, err
+	}
+	return outputFile, trimVideo(outputFile, trimStart, trimEnd)
+}
+
+// downloadAudioStream downloads only the separate audio stream of a format
+// (--audio-only), named like outputFile with the audio's extension
+func downloadAudioStream(format *extractor.VideoFormat, outputFile, id string, dl *downloader.Downloader) (string, error) {
+	audioExt := format.AudioExt
+	if audioExt == "" {
+		audioExt = "m4a"
+	}
+	audioFile := strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + "." + audioExt
+	return audioFile, dl.Download(format.AudioURL, audioFile, id)
+}
+
+// downloadAndMerge downloads a format's separate video and audio streams and
+// merges them into outputFile. If the streams don't fit in an mp4 the result
+// is written as mkv instead. It returns the file actually written.
+func downloadAndMerge(format *extractor.VideoFormat, outputFile, id string, dl *downloader.Downloader) (string, error) {
+	if s3.IsS3URL(outputFile) {
+		return "", fmt.Errorf("formats with separate audio can't be written to S3")
+	}
+
+	audioExt := format.AudioExt
+	if audioExt == "" {
+		audioExt = "m4a"
+	}
+	videoPart := outputFile + ".video." + format.Ext
+	audioPart := outputFile + ".audio." + audioExt
+	defer os.Remove(videoPart)
+	defer os.Remove(audioPart)
+
+	if err := dl.Download(format.URL, videoPart, id); err != nil {
+		return "", err
+	}
+	if err := dl.Download(format.AudioURL, audioPart, id); err != nil {
+		return "", err
+	}
+
+	err := muxer.Merge(videoPart, audioPart, outputFile)
+	if err != nil && strings.EqualFold(filepath.Ext(outputFile), ".mp4") {
+		mkv := strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + ".mkv"
+		fmt.Fprintf(os.Stderr, "  Warning: %v; writing %s instead\n", err, mkv)
+		outputFile, err = mkv, muxer.Merge(videoPart, audioPart, mkv)
+	}
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("  Merged video and audio into %s\n", outputFile)
+	return outputFile, nil
+}
+
+// downloadAllFormats saves every format of every video in m (--write-all-formats),
+// with the quality in the filename, for debugging extractors or archiving.
+// Images have a single variant and are named the same way by downloadImages.
+func downloadAllFormats(m *extractor.VideoMedia, dl *downloader.Downloader, t *i18n.Translations, lang string) ([]string, error) {
+	videos := m.Videos
+	if len(videos) == 0 {
+		videos = []extractor.VideoEntry{{Formats: m.Formats}}
 	}
 
-	return dl.Download(format.URL, outputFile, m.ID)
+	var files []string
+	for i, v := range videos {
+		seen := make(map[string]bool)
+		for j, f := range v.Formats {
+			suffix := "_" + extractor.SanitizeFilename(f.QualityLabel())
+			if n := itemNumber(v.Number, i, len(videos)); n > 0 {
+				suffix = fmt.Sprintf("_%d%s", n, suffix)
+			}
+			// Formats with the same label (e.g. several bitrates) get their position too
+			if key := suffix + "." + f.Ext; seen[key] {
+				suffix = fmt.Sprintf("%s_%d", suffix, j+1)
+			} else {
+				seen[key] = true
+			}
+
+			file, err := downloadVideoFormats(m, []extractor.VideoFormat{f}, suffix, dl, config.FormatPreference{}, t, lang)
+			if err != nil {
+				return files, fmt.Errorf("failed to download format %s: %w", f.QualityLabel(), err)
+			}
+			files = append(files, file)
+		}
+	}
+	return files, nil
 }
 
-func downloadAudio(m *extractor.AudioMedia, dl *downloader.Downloader) error {
+// checkTrim validates the --trim range against a video's duration (seconds)
+func checkTrim(duration int) error {
+	if trimRange == "" || duration <= 0 {
+		return nil
+	}
+	length := time.Duration(duration) * time.Second
+	if trimStart >= length {
+		return fmt.Errorf("--trim start %s is past the end of the video (%s)", trimStart, length)
+	}
+	if trimEnd > length+time.Second {
+		return fmt.Errorf("--trim end %s is past the end of the video (%s)", trimEnd, length)
+	}
+	return nil
+}
+
+// trimVideo cuts a downloaded video to the --trim range
+func trimVideo(file string, start, end time.Duration) error {
+	if err := muxer.Trim(file, start, end); err != nil {
+		return fmt.Errorf("failed to trim %s: %w", file, err)
+	}
+	fmt.Printf("  Trimmed to %s\n", trimRange)
+	return nil
+}
+
+func downloadAudio(m *extractor.AudioMedia, dl *downloader.Downloader) ([]string, error) {
 	// Info only mode
 	if info {
 		fmt.Printf("  Audio: %s (%s)\n", m.Title, m.Ext)
-		return nil
+		return nil, nil
 	}
 
 	// Determine output filename
@@ -257,61 +997,215 @@ func downloadAudio(m *extractor.AudioMedia, dl *downloader.Downloader) error {
 		} else {
 			outputFile = fmt.Sprintf("%s.%s", m.ID, m.Ext)
 		}
+		var err error
+		if outputFile, err = inOutputDir(outputFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := dl.Download(m.URL, outputFile, m.ID); err != nil {
+		return []string{outputFile}, err
+	}
+	return transcodeAudio(outputFile)
+}
+
+// transcodeAudio converts a downloaded audio file to --audio-format when
+// --extract-audio is set and the file isn't already in that format
+func transcodeAudio(file string) ([]string, error) {
+	if !extractAudio || s3.IsS3URL(file) || strings.EqualFold(strings.TrimPrefix(filepath.Ext(file), "."), audioFormat) {
+		return []string{file}, nil
+	}
+
+	audioFile := strings.TrimSuffix(file, filepath.Ext(file)) + "." + audioFormat
+	if err := muxer.ExtractAudio(file, audioFile, audioFormat, audioQuality); err != nil {
+		return []string{file}, fmt.Errorf("failed to convert audio: %w", err)
 	}
+	fmt.Printf("  Audio converted to %s\n", audioFile)
 
-	return dl.Download(m.URL, outputFile, m.ID)
+	if keepVideo {
+		return []string{file, audioFile}, nil
+	}
+	if err := os.Remove(file); err != nil {
+		return []string{file, audioFile}, err
+	}
+	return []string{audioFile}, nil
 }
 
-func downloadImages(m *extractor.ImageMedia, dl *downloader.Downloader) error {
+func downloadImages(m *extractor.ImageMedia, dl *downloader.Downloader) ([]string, error) {
 	// Info only mode
 	if info {
 		fmt.Printf("  Images (%d):\n", len(m.Images))
 		for i, img := range m.Images {
-			fmt.Printf("    [%d] %dx%d (%s)\n", i+1, img.Width, img.Height, img.Ext)
+			fmt.Printf("    [%d] %dx%d (%s)\n", max(img.Number, i+1), img.Width, img.Height, img.Ext)
+			if img.AltText != "" {
+				fmt.Printf("        Alt: %s\n", img.AltText)
+			}
 		}
-		return nil
+		return nil, nil
 	}
 
 	fmt.Printf("  Downloading %d image(s)...\n", len(m.Images))
 
+	var archive *downloader.ZipArchive
+	closeArchive := func() error { return nil }
+	if zipOutput != "" {
+		var err error
+		if archive, closeArchive, err = openArchive(zipOutput); err != nil {
+			return nil, err
+		}
+	}
+
+	outputs := make([]string, len(m.Images))
 	for i, img := range m.Images {
-		var outputFile string
-		if output != "" {
-			// If custom output specified, add suffix for multiple images
-			if len(m.Images) > 1 {
-				outputFile = fmt.Sprintf("%s_%d.%s", output, i+1, img.Ext)
-			} else {
-				outputFile = fmt.Sprintf("%s.%s", output, img.Ext)
-			}
-		} else {
-			// Use sanitized title or ID with index suffix
-			baseFilename := m.ID
+		// Use the custom output, or the sanitized title or ID
+		baseFilename := output
+		if baseFilename == "" {
+			baseFilename = m.ID
 			if title := extractor.SanitizeFilename(m.Title); title != "" {
 				baseFilename = title
 			}
-			if len(m.Images) > 1 {
-				outputFile = fmt.Sprintf("%s_%d.%s", baseFilename, i+1, img.Ext)
-			} else {
-				outputFile = fmt.Sprintf("%s.%s", baseFilename, img.Ext)
+		}
+		// Sets of images get an index suffix
+		if n := itemNumber(img.Number, i, len(m.Images)); n > 0 {
+			baseFilename = fmt.Sprintf("%s_%d", baseFilename, n)
+		}
+		// --write-all-formats names images by quality like video formats
+		if writeAllFormats {
+			baseFilename += "_" + extractor.SanitizeFilename(img.QualityLabel())
+		}
+		outputs[i] = baseFilename + "." + img.Ext
+	}
+
+	if archive != nil {
+		var failed int
+		for i, img := range m.Images {
+			// The archive prints per-entry errors
+			if err := archive.AddURL(img.URL, "", outputs[i]); err != nil {
+				if abortOnError {
+					closeArchive()
+					return nil, fmt.Errorf("failed to download image %d: %w", max(img.Number, i+1), err)
+				}
+				failed++
 			}
 		}
+		// The run reports a shared archive once it's closed
+		var files []string
+		if archive != sharedArchive {
+			files = []string{zipOutput}
+		}
+		if err := closeArchive(); err != nil {
+			return files, err
+		}
+		if failed > 0 {
+			return files, fmt.Errorf("%d of %d images failed", failed, len(m.Images))
+		}
+		return files, nil
+	}
 
-		if err := dl.Download(img.URL, outputFile, m.ID); err != nil {
-			return fmt.Errorf("failed to download image %d: %w", i+1, err)
+	if output == "" {
+		for i := range outputs {
+			var err error
+			if outputs[i], err = inOutputDir(outputs[i]); err != nil {
+				return nil, err
+			}
 		}
 	}
-	return nil
+
+	errs, started := downloadConcurrently(m.Images, outputs, m.ID, dl)
+
+	// Report in image order, whichever finished first
+	var files []string
+	var failed int
+	for i, err := range errs {
+		if err != nil {
+			if abortOnError {
+				return files, fmt.Errorf("failed to download image %d: %w", max(m.Images[i].Number, i+1), err)
+			}
+			fmt.Fprintf(os.Stderr, "  Error: image %d: %v\n", max(m.Images[i].Number, i+1), err)
+			failed++
+			continue
+		}
+		if !started[i] {
+			continue
+		}
+		files = append(files, outputs[i])
+
+		if previewFlag && !s3.IsS3URL(outputs[i]) {
+			showPreview(outputs[i])
+		}
+	}
+
+	if failed > 0 {
+		return files, fmt.Errorf("%d of %d images failed", failed, len(m.Images))
+	}
+	return files, nil
 }
 
-func selectVideoFormat(formats []extractor.VideoFormat, preferred string) *extractor.VideoFormat {
+// imageWorkers is how many images of a set are downloaded at once
+const imageWorkers = 4
+
+// downloadConcurrently downloads images[i] to outputs[i], imageWorkers at a
+// time. It returns each image's error and whether it was started at all;
+// with --abort-on-error no new downloads start after the first failure.
+func downloadConcurrently(images []extractor.Image, outputs []string, id string, dl *downloader.Downloader) ([]error, []bool) {
+	errs := make([]error, len(images))
+	started := make([]bool, len(images))
+
+	// Several progress displays at once would garble the terminal
+	quiet := *dl
+	quiet.Quiet = len(images) > 1
+
+	var failed atomic.Bool
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(imageWorkers, len(images)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if errs[i] = quiet.Download(images[i].URL, outputs[i], id); errs[i] != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	for i := range images {
+		if abortOnError && failed.Load() {
+			break
+		}
+		started[i] = true
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errs, started
+}
+
+// selectVideoFormat picks the format matching pref, preferring pref.Format
+// when available and falling back to the highest bitrate
+func selectVideoFormat(formats []extractor.VideoFormat, pref config.FormatPreference) *extractor.VideoFormat {
 	if len(formats) == 0 {
 		return nil
 	}
 
+	// Narrow to the preferred container if any format has it
+	if pref.Format != "" && pref.Format != "best" {
+		var matching []extractor.VideoFormat
+		for _, f := range formats {
+			if f.Ext == pref.Format {
+				matching = append(matching, f)
+			}
+		}
+		if len(matching) > 0 {
+			formats = matching
+		}
+	}
+
 	// If quality specified, try to match
-	if preferred != "" {
+	if pref.Quality != "" && pref.Quality != "best" {
 		for i := range formats {
-			if formats[i].Quality == preferred {
+			if formats[i].Quality == pref.Quality {
 				return &formats[i]
 			}
 		}
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +17,8 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/guiyumin/vget/internal/config"
 	"github.com/guiyumin/vget/internal/downloader"
+	"github.com/guiyumin/vget/internal/extractor"
+	"github.com/guiyumin/vget/internal/httpclient"
 	"github.com/guiyumin/vget/internal/i18n"
 	"github.com/spf13/cobra"
 )
@@ -125,7 +126,7 @@ func searchXiaoyuzhou(query string) error {
 		}
 		req.Header.Set("Content-Type", "application/json")
 
-		client := &http.Client{}
+		client := httpclient.New(30 * time.Second)
 		resp, err := client.Do(req)
 		if err != nil {
 			searchErr = err
@@ -278,7 +279,7 @@ func searchITunes(query string) error {
 			defer wg.Done()
 			podcastURL := fmt.Sprintf("https://itunes.apple.com/search?term=%s&media=podcast&entity=podcast&limit=50",
 				url.QueryEscape(query))
-			resp, err := http.Get(podcastURL)
+			resp, err := httpclient.New(30 * time.Second).Get(podcastURL)
 			if err != nil {
 				podcastErr = err
 				return
@@ -294,7 +295,7 @@ func searchITunes(query string) error {
 			defer wg.Done()
 			episodeURL := fmt.Sprintf("https://itunes.apple.com/search?term=%s&media=podcast&entity=podcastEpisode&limit=200",
 				url.QueryEscape(query))
-			resp, err := http.Get(episodeURL)
+			resp, err := httpclient.New(30 * time.Second).Get(episodeURL)
 			if err != nil {
 				episodeErr = err
 				return
@@ -480,7 +481,7 @@ func fetchITunesEpisodes(podcastID string) ([]SearchItem, error) {
 	// Use iTunes Lookup API to get episodes
 	lookupURL := fmt.Sprintf("https://itunes.apple.com/lookup?id=%s&entity=podcastEpisode&limit=50", podcastID)
 
-	resp, err := http.Get(lookupURL)
+	resp, err := httpclient.New(30 * time.Second).Get(lookupURL)
 	if err != nil {
 		return nil, err
 	}
@@ -531,7 +532,7 @@ func fetchXiaoyuzhouEpisodes(podcastID string) ([]SearchItem, error) {
 	// Fetch podcast page which contains __NEXT_DATA__ with episodes
 	pageURL := fmt.Sprintf("https://www.xiaoyuzhoufm.com/podcast/%s", podcastID)
 
-	resp, err := http.Get(pageURL)
+	resp, err := httpclient.New(30 * time.Second).Get(pageURL)
 	if err != nil {
 		return nil, err
 	}
@@ -646,42 +647,16 @@ func runDirectDownload(downloadURL, title string) error {
 		ext = "m4a"
 	}
 
-	filename := sanitizeFilenameForDownload(title) + "." + ext
-	outputDir := cfg.OutputDir
-	if outputDir == "" {
-		outputDir = "."
+	filename := extractor.SanitizeFilename(title) + "." + ext
+	outputPath, err := inOutputDir(filename)
+	if err != nil {
+		return err
 	}
 
-	// Join directory and filename to create full path
-	outputPath := filepath.Join(outputDir, filename)
-
 	d := downloader.New(cfg.Language)
 	return d.Download(downloadURL, outputPath, title)
 }
 
-// sanitizeFilenameForDownload removes invalid characters from filename
-func sanitizeFilenameForDownload(name string) string {
-	// Replace invalid characters
-	replacer := strings.NewReplacer(
-		"/", "-",
-		"\\", "-",
-		":", "-",
-		"*", "",
-		"?", "",
-		"\"", "",
-		"<", "",
-		">", "",
-		"|", "",
-	)
-	result := replacer.Replace(name)
-	// Trim spaces and limit length
-	result = strings.TrimSpace(result)
-	if len(result) > 200 {
-		result = result[:200]
-	}
-	return result
-}
-
 // Search spinner model
 type searchSpinnerModel struct {
 	spinner spinner.Model
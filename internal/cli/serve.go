@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/guiyumin/vget/internal/webdav"
+	"github.com/spf13/cobra"
+)
+
+var serveListen string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve <remote>:<path>",
+	Short: "Stream a remote file to a local player",
+	Long: `Serve a file on a WebDAV remote at a local URL, for players that can't
+log in to the remote themselves. Range requests are passed on, so the player
+can seek. The URL works until vget is stopped with Ctrl+C.
+
+Examples:
+  vget serve pikpak:/Movies/film.mkv
+  vget serve --listen 127.0.0.1:8080 pikpak:/Movies/film.mkv`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remote, filePath, err := webdav.ParseRemotePath(args[0])
+		if err != nil {
+			return err
+		}
+		client, err := remoteClient(remote)
+		if err != nil {
+			return err
+		}
+		info, err := client.Stat(context.Background(), filePath)
+		if err != nil {
+			return fmt.Errorf("failed to access path: %w", err)
+		}
+		if info.IsDir {
+			return fmt.Errorf("%s is a directory", args[0])
+		}
+
+		ln, err := net.Listen("tcp", serveListen)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Serving %s at http://%s/%s\n", args[0], ln.Addr(), url.PathEscape(path.Base(filePath)))
+		fmt.Println("Press Ctrl+C to stop")
+		return http.Serve(ln, client.ProxyHandler(filePath))
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:0", "address to serve on (port 0 picks a free one)")
+	rootCmd.AddCommand(serveCmd)
+}
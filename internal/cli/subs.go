@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/guiyumin/vget/internal/downloader"
+	"github.com/guiyumin/vget/internal/extractor"
+)
+
+// selectSubtitles returns the subtitles matching langs, a comma-separated
+// list of language codes or "all". "en" also matches regional tracks
+// such as "en-US".
+func selectSubtitles(subs []extractor.Subtitle, langs string) []extractor.Subtitle {
+	var selected []extractor.Subtitle
+	for _, sub := range subs {
+		for _, lang := range strings.Split(langs, ",") {
+			lang = strings.ToLower(strings.TrimSpace(lang))
+			code := strings.ToLower(sub.Lang)
+			if lang == "all" || lang == code || strings.HasPrefix(code, lang+"-") {
+				selected = append(selected, sub)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// printSubtitles lists the subtitle tracks of a video for --list-subs
+func printSubtitles(m *extractor.VideoMedia) {
+	if len(m.Subtitles) == 0 {
+		fmt.Println("  No subtitles available")
+		return
+	}
+	fmt.Printf("  Subtitles (%d):\n", len(m.Subtitles))
+	for _, sub := range m.Subtitles {
+		if sub.Name != "" {
+			fmt.Printf("    %-8s %-4s %s\n", sub.Lang, sub.Ext, sub.Name)
+		} else {
+			fmt.Printf("    %-8s %s\n", sub.Lang, sub.Ext)
+		}
+	}
+}
+
+// downloadSubtitles saves the tracks selected by --sub-langs next to
+// videoFile as <name>.<lang>.<ext> and returns the files written
+func downloadSubtitles(m *extractor.VideoMedia, videoFile string, dl *downloader.Downloader) ([]string, error) {
+	subs := selectSubtitles(m.Subtitles, subLangs)
+	if len(subs) == 0 {
+		fmt.Fprintf(os.Stderr, "  No subtitles for --sub-langs %s (see --list-subs)\n", subLangs)
+		return nil, nil
+	}
+
+	base := strings.TrimSuffix(videoFile, filepath.Ext(videoFile))
+	seen := make(map[string]int)
+	var files []string
+	for _, sub := range subs {
+		seen[sub.Lang]++
+		file := subtitlePath(base, sub, seen[sub.Lang])
+		if err := dl.Download(sub.URL, file, m.ID); err != nil {
+			return files, fmt.Errorf("failed to download %s subtitles: %w", sub.Lang, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// subtitlePath returns the file the nth track in sub's language is saved
+// to next to base. Language and extension come from the page, so they are
+// sanitized like any other filename part.
+func subtitlePath(base string, sub extractor.Subtitle, n int) string {
+	name := extractor.SanitizeFilename(sub.Lang)
+	if name == "" {
+		name = "und"
+	}
+	// Several tracks in one language get numbered
+	if n > 1 {
+		name = fmt.Sprintf("%s.%d", name, n)
+	}
+	ext := extractor.SanitizeFilename(sub.Ext)
+	if ext == "" {
+		ext = "vtt"
+	}
+	return fmt.Sprintf("%s.%s.%s", base, name, ext)
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/guiyumin/vget/internal/extractor"
+)
+
+func TestSubtitlePath(t *testing.T) {
+	base := filepath.Join("out", "video")
+	tests := []struct {
+		lang, ext string
+		n         int
+		want      string
+	}{
+		{"en", "vtt", 1, "video.en.vtt"},
+		{"en", "vtt", 2, "video.en.2.vtt"},
+		{"../../etc/x", "vtt", 1, "video.-..-etc-x.vtt"},
+		{`a\b:c`, "srt", 1, "video.a-b-c.srt"},
+		{"..", "", 1, "video.und.vtt"},
+	}
+	for _, tt := range tests {
+		got := subtitlePath(base, extractor.Subtitle{Lang: tt.lang, Ext: tt.ext}, tt.n)
+		if want := filepath.Join("out", tt.want); got != want {
+			t.Errorf("subtitlePath(%q, %q, %d) = %q, want %q", tt.lang, tt.ext, tt.n, got, want)
+		}
+		if filepath.Dir(got) != "out" {
+			t.Errorf("subtitlePath(%q) escapes the output directory: %q", tt.lang, got)
+		}
+	}
+}
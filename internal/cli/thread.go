@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/guiyumin/vget/internal/extractor"
+)
+
+// titlePrefix is put in front of the title of each thread post so the
+// files sort in thread order
+var titlePrefix string
+
+// runThread downloads the media of every post in the thread url belongs
+// to, numbering the files in thread order
+func runThread(ext extractor.Extractor, url string) (err error) {
+	threader, ok := ext.(extractor.Threader)
+	if !ok {
+		return fmt.Errorf("--thread is not supported for %s URLs", ext.Name())
+	}
+	if output != "" {
+		return fmt.Errorf("--thread can't be used with -o; use --output-dir")
+	}
+	if checksum != "" {
+		return fmt.Errorf("--checksum can't be used with --thread")
+	}
+
+	ctx, cancel := extractContext()
+	entries, err := threader.Thread(ctx, url)
+	cancel()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Found %d post(s) with media in the thread\n\n", len(entries))
+
+	// One archive for the whole thread
+	closeArchive, err := openSharedArchive()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := closeArchive(); err == nil {
+			err = cerr
+		}
+	}()
+
+	// Each post is downloaded like a URL of its own
+	thread = false
+	defer func() {
+		thread = true
+		titlePrefix = ""
+	}()
+
+	var files []string
+	var failed int
+	for i, e := range entries {
+		if i > 0 {
+			sleepBetweenDownloads()
+		}
+		fmt.Printf("[%d/%d] %s\n", i+1, len(entries), e.URL)
+
+		titlePrefix = fmt.Sprintf("%0*d ", max(2, len(fmt.Sprint(len(entries)))), i+1)
+		lastDownloaded = nil
+		if err := runDownload(e.URL); err != nil {
+			fmt.Fprintf(os.Stderr, "  Error: %v\n", err)
+			failed++
+			if abortOnError {
+				return fmt.Errorf("aborted after %d/%d: %w", i+1, len(entries), err)
+			}
+		}
+		files = append(files, lastDownloaded...)
+		fmt.Println()
+	}
+	lastDownloaded = files
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d thread posts failed", failed, len(entries))
+	}
+	return nil
+}
+
+// withTitlePrefix returns m with titlePrefix added to its title, leaving
+// the (possibly cached) original alone
+func withTitlePrefix(m extractor.Media) extractor.Media {
+	if titlePrefix == "" {
+		return m
+	}
+	switch v := m.(type) {
+	case *extractor.VideoMedia:
+		c := *v
+		c.Title = prefixedTitle(c.Title, c.ID)
+		return &c
+	case *extractor.AudioMedia:
+		c := *v
+		c.Title = prefixedTitle(c.Title, c.ID)
+		return &c
+	case *extractor.ImageMedia:
+		c := *v
+		c.Title = prefixedTitle(c.Title, c.ID)
+		return &c
+	}
+	return m
+}
+
+// prefixedTitle adds titlePrefix to title, or to id for untitled posts
+func prefixedTitle(title, id string) string {
+	if title == "" {
+		title = id
+	}
+	return titlePrefix + title
+}
@@ -1,18 +1,33 @@
 package cli
 
 import (
+	"github.com/guiyumin/vget/internal/config"
 	"github.com/guiyumin/vget/internal/updater"
 	"github.com/spf13/cobra"
 )
 
+var (
+	updateChannel    string
+	updateVersionTag string
+)
+
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update vget to the latest version",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return updater.Update()
+		channel := updateChannel
+		if channel == "" {
+			channel = config.LoadOrDefault().UpdateChannel
+		}
+		return updater.Update(updater.Options{
+			Channel:    channel,
+			VersionTag: updateVersionTag,
+		})
 	},
 }
 
 func init() {
+	updateCmd.Flags().StringVar(&updateChannel, "channel", "", "release channel: stable or nightly (default from config, else stable)")
+	updateCmd.Flags().StringVar(&updateVersionTag, "version-tag", "", "install a specific release (e.g. v0.5.0)")
 	rootCmd.AddCommand(updateCmd)
 }
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/guiyumin/vget/internal/config"
+	"github.com/guiyumin/vget/internal/downloader"
+	"github.com/guiyumin/vget/internal/webdav"
+	"github.com/spf13/cobra"
+)
+
+var (
+	uploadPartSize string
+	uploadStreams  int
+	uploadRetries  int
+)
+
+var uploadCmd = &cobra.Command{
+	Use:   "upload <local> <remote>:<path>",
+	Short: "Upload a file to a remote",
+	Long: `Upload a local file to a WebDAV remote. Missing directories are created.
+If the remote path ends in "/" or is an existing directory, the file keeps
+its local name inside it. An existing remote file is replaced.
+
+Servers that support partial updates (Nextcloud, ownCloud and other
+sabre/dav based servers) receive large files as --part-size parts,
+--streams at a time. Other servers get the file in a single request.
+
+Examples:
+  vget upload video.mp4 pikpak:/Movies/
+  vget upload video.mp4 pikpak:/Movies/renamed.mp4
+  vget upload --part-size 32M --streams 8 video.mp4 nextcloud:/Movies/`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		local := args[0]
+		info, err := os.Stat(local)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory; only files can be uploaded", local)
+		}
+
+		uploadConfig := webdav.DefaultUploadConfig()
+		if uploadConfig.PartSize, err = parseSize(uploadPartSize); err != nil {
+			return fmt.Errorf("invalid --part-size: %w", err)
+		}
+		if uploadStreams < 1 {
+			return fmt.Errorf("invalid --streams: %d", uploadStreams)
+		}
+		if uploadRetries < 0 {
+			return fmt.Errorf("invalid --retries: %d", uploadRetries)
+		}
+		uploadConfig.Streams = uploadStreams
+		uploadConfig.Retries = uploadRetries
+
+		remoteName, remotePath, err := webdav.ParseRemotePath(args[1])
+		if err != nil {
+			return err
+		}
+		client, err := remoteClient(remoteName)
+		if err != nil {
+			return err
+		}
+
+		// Cancelled if the progress display fails, so the upload stops too
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if strings.HasSuffix(remotePath, "/") {
+			remotePath = path.Join(remotePath, filepath.Base(local))
+		} else if fi, err := client.Stat(ctx, remotePath); err == nil && fi.IsDir {
+			remotePath = path.Join(remotePath, filepath.Base(local))
+		}
+
+		f, err := os.Open(local)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		cfg := config.LoadOrDefault()
+		return downloader.RunUploadTUI(f, info.Size(), remotePath, filepath.Base(local), cfg.Language, cancel,
+			func(r io.Reader) error {
+				return client.UploadFile(ctx, r.(io.ReaderAt), info.Size(), remotePath, uploadConfig)
+			})
+	},
+}
+
+func init() {
+	uploadCmd.Flags().StringVar(&uploadPartSize, "part-size", "8M", "size of the parts large files are sent in, if the server supports it (0 for a single request)")
+	uploadCmd.Flags().IntVar(&uploadStreams, "streams", 4, "parts uploaded at the same time")
+	uploadCmd.Flags().IntVar(&uploadRetries, "retries", 3, "times a failed part is sent again")
+	rootCmd.AddCommand(uploadCmd)
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/guiyumin/vget/internal/s3"
+	"github.com/guiyumin/vget/internal/webdav"
+)
+
+// downloadWebDAVDir downloads every file below dirPath, keeping the
+// directory structure. Files go under -o when given, otherwise under a
+// directory named after dirPath in the output directory.
+func downloadWebDAVDir(ctx context.Context, client *webdav.Client, dirPath, lang string) error {
+	if checksum != "" {
+		return fmt.Errorf("--checksum can't be used when downloading a directory")
+	}
+
+	root := output
+	if root == "" {
+		root = path.Base(dirPath)
+		if root == "/" {
+			root = "."
+		}
+		var err error
+		if root, err = inOutputDir(root); err != nil {
+			return err
+		}
+	}
+
+	entries, walkErr := client.Walk(ctx, dirPath, webdav.DefaultWalkWorkers)
+
+	var files []webdav.FileInfo
+	for _, entry := range entries {
+		if !entry.IsDir {
+			files = append(files, entry)
+		}
+	}
+	fmt.Printf("  Downloading %d file(s) from %s into %s\n", len(files), dirPath, root)
+
+	var failed int
+	for i, entry := range files {
+		rel := strings.TrimPrefix(strings.TrimPrefix(entry.Path, dirPath), "/")
+		fmt.Printf("[%d/%d] %s\n", i+1, len(files), rel)
+
+		var dest string
+		if s3.IsS3URL(root) {
+			dest = strings.TrimSuffix(root, "/") + "/" + rel
+		} else {
+			dest = filepath.Join(root, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+		}
+
+		if err := downloadWebDAVFile(ctx, client, entry.Path, &entry, dest, lang); err != nil {
+			fmt.Fprintf(os.Stderr, "  Error: %v\n", err)
+			failed++
+			if abortOnError {
+				return err
+			}
+		}
+	}
+
+	if walkErr != nil {
+		return fmt.Errorf("failed to list %s: %w", dirPath, walkErr)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d downloads failed", failed, len(files))
+	}
+	return nil
+}
+
+// listWebDAVDir prints the contents of a directory that was given where a
+// file was expected, with a hint on how to download from it
+func listWebDAVDir(ctx context.Context, client *webdav.Client, rawURL, dirPath string) error {
+	entries, err := client.List(ctx, dirPath)
+	if err != nil {
+		return fmt.Errorf("failed to list directory: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+
+	fmt.Printf("%s is a directory:\n", rawURL)
+	for _, e := range entries {
+		if e.IsDir {
+			fmt.Printf("  📁 %s/\n", e.Name)
+		} else {
+			fmt.Printf("  📄 %-40s %s\n", e.Name, formatSize(e.Size))
+		}
+	}
+	fmt.Println()
+
+	return fmt.Errorf("%s is a directory; download a file inside it (e.g. %s/<name>), or use --recursive or --zip for all of it",
+		rawURL, strings.TrimSuffix(rawURL, "/"))
+}
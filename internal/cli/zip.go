@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/guiyumin/vget/internal/downloader"
+	"github.com/guiyumin/vget/internal/webdav"
+)
+
+// sharedArchive is the --zip archive of a thread or batch run, which every
+// download in the run adds to. Creating the archive per download would
+// truncate it each time, leaving only the last post or URL.
+var sharedArchive *downloader.ZipArchive
+
+// openSharedArchive creates sharedArchive for a run over several posts or
+// URLs. The returned function closes it, making the archive the run's
+// output. Nested runs (a thread in a batch) keep the outer archive.
+func openSharedArchive() (func() error, error) {
+	if zipOutput == "" || info || sharedArchive != nil {
+		return func() error { return nil }, nil
+	}
+	archive, err := downloader.CreateZip(zipOutput)
+	if err != nil {
+		return nil, err
+	}
+	sharedArchive = archive
+	return func() error {
+		sharedArchive = nil
+		lastDownloaded = []string{zipOutput}
+		return archive.Close()
+	}, nil
+}
+
+// openArchive returns the archive a single download writes to: the shared
+// one when a run has opened it, or a new one at zipFile. The returned
+// function closes a new archive and leaves the shared one open.
+func openArchive(zipFile string) (*downloader.ZipArchive, func() error, error) {
+	if sharedArchive != nil {
+		return sharedArchive, func() error { return nil }, nil
+	}
+	archive, err := downloader.CreateZip(zipFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return archive, archive.Close, nil
+}
+
+// zipWebDAVDir downloads every file below dirPath into a zip archive,
+// keeping the directory structure relative to dirPath
+func zipWebDAVDir(ctx context.Context, client *webdav.Client, dirPath, zipFile string) error {
+	archive, closeArchive, err := openArchive(zipFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  Zipping %s into %s...\n", dirPath, zipFile)
+
+	entries, walkErr := client.Walk(ctx, dirPath, webdav.DefaultWalkWorkers)
+
+	authHeader := client.GetAuthHeader()
+	var files, failed int
+	for _, entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+		files++
+		name := strings.TrimPrefix(strings.TrimPrefix(entry.Path, dirPath), "/")
+		// The archive prints per-entry errors
+		if client.UsesDigestAuth() {
+			err = archive.AddReader(name, func() (io.ReadCloser, int64, error) {
+				return client.Open(ctx, entry.Path)
+			})
+		} else {
+			err = archive.AddURL(client.GetFileURL(entry.Path), authHeader, name)
+		}
+		if err != nil {
+			if abortOnError {
+				closeArchive()
+				return fmt.Errorf("failed to download %s: %w", entry.Path, err)
+			}
+			failed++
+		}
+	}
+
+	if err := closeArchive(); err != nil {
+		return err
+	}
+	if walkErr != nil {
+		return walkErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, files)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"archive/zip"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/guiyumin/vget/internal/extractor"
+)
+
+func TestSharedArchiveKeepsEveryPost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("image " + r.URL.Path))
+	}))
+	defer srv.Close()
+
+	zipOutput = filepath.Join(t.TempDir(), "thread.zip")
+	t.Cleanup(func() { zipOutput = "" })
+
+	closeArchive, err := openSharedArchive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"01 first", "02 second"} {
+		m := &extractor.ImageMedia{ID: id, Images: []extractor.Image{{URL: srv.URL + "/" + id, Ext: "jpg"}}}
+		if _, err := downloadImages(m, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := closeArchive(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.OpenReader(zipOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	if want := []string{"01 first.jpg", "02 second.jpg"}; !slices.Equal(names, want) {
+		t.Errorf("archive has %v, want %v", names, want)
+	}
+	if !slices.Equal(lastDownloaded, []string{zipOutput}) {
+		t.Errorf("lastDownloaded = %v, want the archive", lastDownloaded)
+	}
+}
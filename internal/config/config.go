@@ -25,9 +25,26 @@ func ConfigDir() (string, error) {
 	return filepath.Join(home, ".config", AppDirName), nil
 }
 
+// pathOverride is the config file set with SetPath (--config)
+var pathOverride string
+
+// SetPath makes Load and Save use the given config file instead of the
+// default location
+func SetPath(path string) {
+	pathOverride = path
+}
+
 // ConfigPath returns the path to the config file.
-// e.g., ~/.config/vget/config.yml
+// e.g., ~/.config/vget/config.yml, unless overridden with SetPath or the
+// VGET_CONFIG environment variable
 func ConfigPath() (string, error) {
+	if pathOverride != "" {
+		return pathOverride, nil
+	}
+	if env := os.Getenv("VGET_CONFIG"); env != "" {
+		return env, nil
+	}
+
 	dir, err := ConfigDir()
 	if err != nil {
 		return "", err
@@ -35,7 +52,14 @@ func ConfigPath() (string, error) {
 	return filepath.Join(dir, ConfigFileName), nil
 }
 
+// Config is the vget configuration. Settings are resolved from, highest
+// precedence first: command-line flags, VGET_* environment variables (see
+// envOverrides), the standard proxy variables (proxy only), the config
+// file, and DefaultConfig.
 type Config struct {
+	// Version is the config schema version, used by Migrate
+	Version int `yaml:"version,omitempty"`
+
 	// Language for metadata (e.g., "en", "zh", "ja")
 	Language string `yaml:"language,omitempty"`
 
@@ -54,8 +78,61 @@ type Config struct {
 	// Default output filename template
 	FilenameTemplate string `yaml:"filename_template,omitempty"`
 
+	// Per-extractor format/quality overrides, keyed by extractor name
+	// (e.g. "twitter"). Unset fields fall back to Format and Quality.
+	Extractors map[string]FormatPreference `yaml:"extractors,omitempty"`
+
+	// ExtractorOrder lists extractor names to try first when several
+	// match the same host (e.g. ["mirror", "twitter"])
+	ExtractorOrder []string `yaml:"extractor_order,omitempty"`
+
+	// DisabledExtractors are never used; "generic" stops scraping pages of
+	// unknown sites, while direct file downloads always work
+	DisabledExtractors []string `yaml:"disabled_extractors,omitempty"`
+
+	// TwitterBearerToken replaces the built-in web client token of the
+	// Twitter extractor, for when Twitter rotates it
+	TwitterBearerToken string `yaml:"twitter_bearer_token,omitempty"`
+
+	// TwitterTimeout bounds each request to the Twitter API, e.g. "45s"
+	// (default 20s)
+	TwitterTimeout string `yaml:"twitter_timeout,omitempty"`
+
+	// Maximum open connections to a single host (default 16, 0 for no limit)
+	MaxConnsPerHost *int `yaml:"max_conns_per_host,omitempty"`
+
+	// Update channel for 'vget update': "stable" or "nightly"
+	UpdateChannel string `yaml:"update_channel,omitempty"`
+
 	// WebDAV servers configuration
 	WebDAVServers map[string]WebDAVServer `yaml:"webdavServers,omitempty"`
+
+	// DefaultRemote is the WebDAV server used for ":/path" (empty remote name)
+	DefaultRemote string `yaml:"default_remote,omitempty"`
+}
+
+// FormatPreference selects which format to download
+type FormatPreference struct {
+	// Format is the preferred container/extension (e.g., "mp4", "best")
+	Format string `yaml:"format,omitempty"`
+
+	// Quality is the preferred quality (e.g., "1080p", "best")
+	Quality string `yaml:"quality,omitempty"`
+}
+
+// PreferenceFor returns the format preference for an extractor,
+// applying its overrides on top of the global settings
+func (c *Config) PreferenceFor(extractor string) FormatPreference {
+	pref := FormatPreference{Format: c.Format, Quality: c.Quality}
+	if o, ok := c.Extractors[extractor]; ok {
+		if o.Format != "" {
+			pref.Format = o.Format
+		}
+		if o.Quality != "" {
+			pref.Quality = o.Quality
+		}
+	}
+	return pref
 }
 
 // WebDAVServer represents a WebDAV server configuration
@@ -68,11 +145,22 @@ type WebDAVServer struct {
 
 	// Password for authentication
 	Password string `yaml:"password,omitempty"`
+
+	// Token is sent as "Authorization: Bearer <token>" instead of basic auth
+	Token string `yaml:"token,omitempty"`
+
+	// AuthType is how Username/Password are sent: "basic" (default) or
+	// "digest". "bearer" requires Token.
+	AuthType string `yaml:"auth_type,omitempty"`
 }
 
-// GetWebDAVServer returns a WebDAV server by name, or nil if not found
+// GetWebDAVServer returns a WebDAV server by name, or nil if not found.
+// An empty name selects the default remote.
 func (c *Config) GetWebDAVServer(name string) *WebDAVServer {
-	if c.WebDAVServers == nil {
+	if name == "" {
+		name = c.DefaultRemote
+	}
+	if c.WebDAVServers == nil || name == "" {
 		return nil
 	}
 	if s, ok := c.WebDAVServers[name]; ok {
@@ -94,11 +182,15 @@ func (c *Config) DeleteWebDAVServer(name string) {
 	if c.WebDAVServers != nil {
 		delete(c.WebDAVServers, name)
 	}
+	if c.DefaultRemote == name {
+		c.DefaultRemote = ""
+	}
 }
 
 // DefaultConfig returns a config with sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
+		Version:          CurrentVersion,
 		Language:         "en",
 		Proxy:            "",
 		OutputDir:        ".",
@@ -118,7 +210,7 @@ func Exists() bool {
 	return err == nil
 }
 
-// Load reads the config from ~/.config/vget/config.yml
+// Load reads the config file (~/.config/vget/config.yml by default)
 func Load() (*Config, error) {
 	path, err := ConfigPath()
 	if err != nil {
@@ -138,8 +230,9 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
-// Save writes the config to ~/.config/vget/config.yml
+// Save writes the config file (~/.config/vget/config.yml by default)
 func Save(cfg *Config) error {
+	cfg.Version = CurrentVersion
 	data, err := yaml.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to serialize config: %w", err)
@@ -180,16 +273,55 @@ func Init() error {
 	return Save(DefaultConfig())
 }
 
-// LoadOrDefault loads config if it exists, otherwise returns defaults
+// LoadOrDefault loads config if it exists, otherwise returns defaults.
+// Environment overrides are applied on top either way.
 func LoadOrDefault() *Config {
+	cfg := LoadForEdit()
+	loadEnvProxy(cfg)
+	loadEnvOverrides(cfg)
+	return cfg
+}
+
+// LoadForEdit is LoadOrDefault without environment overrides, for
+// commands that modify and Save the config file
+func LoadForEdit() *Config {
 	cfg, err := Load()
 	if err != nil {
 		cfg = DefaultConfig()
+	} else if cfg.Version < CurrentVersion {
+		if backup, err := MigrateFile(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to migrate config: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Config upgraded to version %d (backup: %s)\n", CurrentVersion, backup)
+			Migrate(cfg)
+		}
 	}
-	loadEnvProxy(cfg)
 	return cfg
 }
 
+// envOverrides maps VGET_* environment variables to the settings they override
+var envOverrides = []struct {
+	key   string
+	field func(*Config) *string
+}{
+	{"VGET_PROXY", func(c *Config) *string { return &c.Proxy }},
+	{"VGET_OUTPUT_DIR", func(c *Config) *string { return &c.OutputDir }},
+	{"VGET_LANGUAGE", func(c *Config) *string { return &c.Language }},
+	{"VGET_QUALITY", func(c *Config) *string { return &c.Quality }},
+	{"VGET_FORMAT", func(c *Config) *string { return &c.Format }},
+	{"VGET_TWITTER_BEARER", func(c *Config) *string { return &c.TwitterBearerToken }},
+}
+
+// loadEnvOverrides applies the non-empty VGET_* variables in envOverrides
+// to cfg. They win over the config file and the standard proxy variables.
+func loadEnvOverrides(cfg *Config) {
+	for _, o := range envOverrides {
+		if value := strings.TrimSpace(os.Getenv(o.key)); value != "" {
+			*o.field(cfg) = value
+		}
+	}
+}
+
 // loadEnvProxy checks environment variables for proxy settings and applies them to cfg.
 // It checks in order: HTTPS_PROXY, https_proxy, HTTP_PROXY, http_proxy, ALL_PROXY, all_proxy.
 // The first valid proxy URL found is used.
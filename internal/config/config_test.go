@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfig points VGET_CONFIG at a temp config file with the given body
+func writeConfig(t *testing.T, body string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("version: 1\n"+body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VGET_CONFIG", path)
+}
+
+// clearProxyEnv unsets the proxy variables of the test environment
+func clearProxyEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "ALL_PROXY", "all_proxy", "VGET_PROXY"} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestEnvOverridesConfigFile(t *testing.T) {
+	clearProxyEnv(t)
+	writeConfig(t, "output_dir: /from/file\nlanguage: de\nquality: 720p\nproxy: http://file:8080\n")
+
+	// Without VGET_* variables the file wins
+	t.Setenv("VGET_OUTPUT_DIR", "")
+	t.Setenv("VGET_LANGUAGE", "")
+	t.Setenv("VGET_QUALITY", "")
+	cfg := LoadOrDefault()
+	if cfg.OutputDir != "/from/file" || cfg.Language != "de" || cfg.Quality != "720p" {
+		t.Fatalf("file settings not loaded: %+v", cfg)
+	}
+
+	t.Setenv("VGET_OUTPUT_DIR", "/from/env")
+	t.Setenv("VGET_LANGUAGE", "ja")
+	t.Setenv("HTTPS_PROXY", "http://standard:3128")
+	cfg = LoadOrDefault()
+	if cfg.OutputDir != "/from/env" {
+		t.Errorf("OutputDir = %q, want the VGET_OUTPUT_DIR value", cfg.OutputDir)
+	}
+	if cfg.Language != "ja" {
+		t.Errorf("Language = %q, want the VGET_LANGUAGE value", cfg.Language)
+	}
+	if cfg.Quality != "720p" {
+		t.Errorf("Quality = %q, want the file value when VGET_QUALITY is unset", cfg.Quality)
+	}
+	if cfg.Proxy != "http://standard:3128" {
+		t.Errorf("Proxy = %q, want HTTPS_PROXY over the file", cfg.Proxy)
+	}
+
+	t.Setenv("VGET_PROXY", "socks5://vget:1080")
+	if cfg = LoadOrDefault(); cfg.Proxy != "socks5://vget:1080" {
+		t.Errorf("Proxy = %q, want VGET_PROXY over HTTPS_PROXY", cfg.Proxy)
+	}
+}
+
+func TestLoadForEditIgnoresEnv(t *testing.T) {
+	clearProxyEnv(t)
+	writeConfig(t, "output_dir: /from/file\n")
+	t.Setenv("VGET_OUTPUT_DIR", "/from/env")
+
+	// Saving an edited config must not persist the environment
+	if cfg := LoadForEdit(); cfg.OutputDir != "/from/file" {
+		t.Errorf("OutputDir = %q, want the file value", cfg.OutputDir)
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// CurrentVersion is the config schema version written by this build
+const CurrentVersion = 1
+
+// migrations upgrade a config from version i to i+1
+var migrations = []func(cfg *Config){
+	// 0 -> 1: unversioned files may lack settings that now drive downloads
+	// (format/quality selection, output directory); fill in the defaults
+	func(cfg *Config) {
+		def := DefaultConfig()
+		if cfg.Language == "" {
+			cfg.Language = def.Language
+		}
+		if cfg.OutputDir == "" {
+			cfg.OutputDir = def.OutputDir
+		}
+		if cfg.Format == "" {
+			cfg.Format = def.Format
+		}
+		if cfg.Quality == "" {
+			cfg.Quality = def.Quality
+		}
+		if cfg.FilenameTemplate == "" {
+			cfg.FilenameTemplate = def.FilenameTemplate
+		}
+	},
+}
+
+// Migrate upgrades cfg in place to CurrentVersion and reports whether
+// anything was changed
+func Migrate(cfg *Config) bool {
+	if cfg.Version >= CurrentVersion {
+		return false
+	}
+	for v := cfg.Version; v < CurrentVersion && v < len(migrations); v++ {
+		migrations[v](cfg)
+	}
+	cfg.Version = CurrentVersion
+	return true
+}
+
+// MigrateFile upgrades the config file on disk to CurrentVersion, keeping
+// a copy of the original next to it. It returns the backup path, or an
+// empty string if the file was already current.
+func MigrateFile() (string, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("config file not found: %w", err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		return "", err
+	}
+
+	from := cfg.Version
+	if !Migrate(cfg) {
+		return "", nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, from)
+	if err := os.WriteFile(backup, original, 0644); err != nil {
+		return "", fmt.Errorf("failed to back up config: %w", err)
+	}
+
+	if err := Save(cfg); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets in config output
+const redacted = "********"
+
+// Redacted returns a copy of c with passwords, tokens and proxy
+// credentials masked, for display
+func (c *Config) Redacted() *Config {
+	r := *c
+	if u, err := url.Parse(c.Proxy); err == nil && u.User != nil {
+		r.Proxy = u.Redacted()
+	}
+	if c.TwitterBearerToken != "" {
+		r.TwitterBearerToken = redacted
+	}
+	if c.WebDAVServers != nil {
+		r.WebDAVServers = make(map[string]WebDAVServer, len(c.WebDAVServers))
+		for name, s := range c.WebDAVServers {
+			if s.Password != "" {
+				s.Password = redacted
+			}
+			if s.Token != "" {
+				s.Token = redacted
+			}
+			r.WebDAVServers[name] = s
+		}
+	}
+	return &r
+}
+
+// MarshalJSON encodes the config with the same keys as config.yml
+func (c *Config) MarshalJSON() ([]byte, error) {
+	data, err := yaml.Marshal((*configYAML)(c))
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		m = map[string]any{}
+	}
+	return json.Marshal(m)
+}
+
+// configYAML has Config's fields without its methods, so marshalling it
+// doesn't recurse into MarshalJSON
+type configYAML Config
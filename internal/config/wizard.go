@@ -400,10 +400,15 @@ func (m model) renderReview() string {
 	return b.String()
 }
 
-// RunInitWizard runs an interactive TUI wizard to configure vget
+// RunInitWizard runs an interactive TUI wizard to configure vget, or plain
+// prompts when there is no usable terminal
 func RunInitWizard() (*Config, error) {
 	// Load existing config or use defaults
-	cfg := LoadOrDefault()
+	cfg := LoadForEdit()
+
+	if !interactive() {
+		return runPlainWizard(cfg)
+	}
 
 	m := initialModel(cfg)
 	p := tea.NewProgram(m, tea.WithAltScreen())
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// interactive reports whether the wizard TUI can run: stdout is a terminal
+// that isn't "dumb"
+func interactive() bool {
+	return term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("TERM") != "dumb"
+}
+
+// runPlainWizard asks the wizard's questions as numbered line prompts, for
+// terminals (or pipes) where the TUI can't run. An empty answer keeps the
+// current value.
+func runPlainWizard(cfg *Config) (*Config, error) {
+	in := bufio.NewReader(os.Stdin)
+	m := initialModel(cfg)
+
+	for m.currentStep = 0; m.currentStep < 5; m.currentStep++ {
+		m.cursor = 0
+		m.setCursorFromConfig()
+
+		fmt.Printf("\n%s\n%s\n", m.getStepTitle(), m.getStepDescription())
+		if m.isInputStep() {
+			fmt.Printf("[%s]: ", m.inputBuffer)
+		} else {
+			for i, opt := range m.getOptions() {
+				marker := " "
+				if i == m.cursor {
+					marker = "*"
+				}
+				fmt.Printf(" %s %d) %s\n", marker, i+1, opt.label)
+			}
+			fmt.Printf("[%d]: ", m.cursor+1)
+		}
+
+		answer, err := readLine(in)
+		if err != nil {
+			return nil, err
+		}
+		if answer != "" {
+			if m.isInputStep() {
+				m.inputBuffer = answer
+			} else {
+				n, err := strconv.Atoi(answer)
+				if err != nil || n < 1 || n > len(m.getOptions()) {
+					fmt.Printf("Invalid choice %q\n", answer)
+					m.currentStep--
+					continue
+				}
+				m.cursor = n - 1
+			}
+		}
+		m.saveCurrentValue()
+	}
+
+	t := m.t()
+	fmt.Printf("\n%s\n%s\n%s", t.Config.Confirm, t.Config.ConfirmDesc, m.renderReview())
+	fmt.Printf("%s? [Y/n]: ", t.Config.YesSave)
+	answer, err := readLine(in)
+	if err != nil {
+		return nil, err
+	}
+	if a := strings.ToLower(answer); a != "" && a != "y" && a != "yes" {
+		return nil, fmt.Errorf("configuration cancelled")
+	}
+
+	if m.config.OutputDir == "" {
+		m.config.OutputDir = "."
+	}
+	return m.config, nil
+}
+
+// readLine reads one trimmed line; end of input counts as an empty answer
+func readLine(in *bufio.Reader) (string, error) {
+	line, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
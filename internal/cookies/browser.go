@@ -0,0 +1,99 @@
+package cookies
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Supported browsers for FromBrowser
+const (
+	BrowserChrome  = "chrome"
+	BrowserFirefox = "firefox"
+)
+
+// FromBrowser reads the cookies for domain (and its subdomains) from the
+// local browser's cookie store. The sqlite3 command-line tool is used to
+// read the database.
+//
+// Only the default Chrome profile is read. Chrome on Windows locks its
+// database while running and protects cookies of recent versions with
+// app-bound encryption; those cookies are skipped.
+func FromBrowser(browser, domain string) ([]*http.Cookie, error) {
+	domain = strings.TrimPrefix(strings.ToLower(domain), "www.")
+
+	switch strings.ToLower(browser) {
+	case BrowserChrome:
+		return chromeCookies(domain)
+	case BrowserFirefox:
+		return firefoxCookies(domain)
+	default:
+		return nil, fmt.Errorf("unsupported browser %q (use %s or %s)", browser, BrowserChrome, BrowserFirefox)
+	}
+}
+
+// queryDB runs a query against a copy of the database (browsers keep
+// their cookie DB locked while running) and decodes the JSON rows
+func queryDB(dbPath, query string, rows any) error {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return fmt.Errorf("sqlite3 is required to read browser cookies: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "vget-cookies-*.sqlite")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	src, err := os.Open(dbPath)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to open cookie database: %w", err)
+	}
+	_, err = io.Copy(tmp, src)
+	src.Close()
+	tmp.Close()
+	if err != nil {
+		return fmt.Errorf("failed to copy cookie database: %w", err)
+	}
+
+	out, err := exec.Command("sqlite3", "-readonly", "-json", tmp.Name(), query).Output()
+	if err != nil {
+		return fmt.Errorf("failed to query cookie database: %w", err)
+	}
+	if len(strings.TrimSpace(string(out))) == 0 {
+		return nil // No rows
+	}
+	return json.Unmarshal(out, rows)
+}
+
+// domainFilter returns a SQL condition matching domain and its subdomains
+func domainFilter(column, domain string) string {
+	d := strings.ReplaceAll(domain, "'", "''")
+	return fmt.Sprintf("(%[1]s = '%[2]s' OR %[1]s = '.%[2]s' OR %[1]s LIKE '%%.%[2]s')", column, d)
+}
+
+// newestFile returns the most recently modified file matching any pattern
+func newestFile(patterns ...string) (string, error) {
+	var matches []string
+	for _, p := range patterns {
+		m, _ := filepath.Glob(p)
+		matches = append(matches, m...)
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("cookie database not found")
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, _ := os.Stat(matches[i])
+		b, _ := os.Stat(matches[j])
+		return a != nil && b != nil && a.ModTime().After(b.ModTime())
+	})
+	return matches[0], nil
+}
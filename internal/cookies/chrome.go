@@ -0,0 +1,258 @@
+package cookies
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// chromeEpoch is the offset of Chrome's timestamps (microseconds since 1601)
+var chromeEpoch = time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// chromeCookies reads and decrypts cookies from the default Chrome profile
+func chromeCookies(domain string) ([]*http.Cookie, error) {
+	profile, err := chromeProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	dbPath, err := newestFile(
+		filepath.Join(profile, "Network", "Cookies"),
+		filepath.Join(profile, "Cookies"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := chromeKeys(filepath.Dir(profile))
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Host      string `json:"host_key"`
+		Name      string `json:"name"`
+		Value     string `json:"value"`
+		Encrypted string `json:"encrypted"`
+		Path      string `json:"path"`
+		Expires   int64  `json:"expires_utc"`
+		IsSecure  int    `json:"is_secure"`
+	}
+	query := "SELECT host_key, name, value, hex(encrypted_value) AS encrypted, path, expires_utc, is_secure FROM cookies WHERE " +
+		domainFilter("host_key", domain)
+	if err := queryDB(dbPath, query, &rows); err != nil {
+		return nil, err
+	}
+
+	var cookies []*http.Cookie
+	for _, r := range rows {
+		value := r.Value
+		if value == "" && r.Encrypted != "" {
+			encrypted, err := hex.DecodeString(r.Encrypted)
+			if err != nil {
+				continue
+			}
+			value, err = decryptChromeValue(encrypted, keys, r.Host)
+			if err != nil {
+				continue // Skip cookies we can't decrypt
+			}
+		}
+
+		cookie := &http.Cookie{
+			Name:   r.Name,
+			Value:  value,
+			Domain: r.Host,
+			Path:   r.Path,
+			Secure: r.IsSecure != 0,
+		}
+		if r.Expires > 0 {
+			cookie.Expires = chromeEpoch.Add(time.Duration(r.Expires) * time.Microsecond)
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, nil
+}
+
+// chromeProfile returns the directory of the default Chrome profile
+func chromeProfile() (string, error) {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), "Google", "Chrome", "User Data", "Default"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library", "Application Support", "Google", "Chrome", "Default"), nil
+	}
+	return filepath.Join(home, ".config", "google-chrome", "Default"), nil
+}
+
+// chromeKey is the key for one version prefix of encrypted cookie values
+type chromeKey struct {
+	key []byte
+	gcm bool // AES-256-GCM (Windows) rather than AES-128-CBC
+}
+
+// chromeKeys returns the keys Chrome encrypts cookie values with, by
+// version prefix. userData is the directory above the profile.
+//
+// On macOS "v10" values use the password in the keychain. On Linux "v10"
+// values use Chrome's built-in "peanuts" password and "v11" values the one
+// in the secret service, if there is one. On Windows "v10" values use an
+// AES-GCM key that is stored DPAPI-protected in the Local State file;
+// "v20" values use app-bound encryption, which only Chrome itself can undo.
+func chromeKeys(userData string) (map[string]chromeKey, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("security", "find-generic-password", "-w", "-s", "Chrome Safe Storage").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Chrome Safe Storage from keychain: %w", err)
+		}
+		key, err := chromeCBCKey(strings.TrimSpace(string(out)), 1003)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]chromeKey{"v10": {key: key}}, nil
+
+	case "windows":
+		key, err := chromeWindowsKey(userData)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]chromeKey{"v10": {key: key, gcm: true}}, nil
+	}
+
+	v10, err := chromeCBCKey("peanuts", 1)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]chromeKey{"v10": {key: v10}}
+	if out, err := exec.Command("secret-tool", "lookup", "application", "chrome").Output(); err == nil {
+		if p := strings.TrimSpace(string(out)); p != "" {
+			v11, err := chromeCBCKey(p, 1)
+			if err != nil {
+				return nil, err
+			}
+			keys["v11"] = chromeKey{key: v11}
+		}
+	}
+	return keys, nil
+}
+
+// chromeCBCKey derives the AES-128 key for password
+func chromeCBCKey(password string, iterations int) ([]byte, error) {
+	return pbkdf2.Key(sha1.New, password, []byte("saltysalt"), iterations, 16)
+}
+
+// chromeWindowsKey reads the cookie key from Chrome's Local State file
+func chromeWindowsKey(userData string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(userData, "Local State"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Chrome Local State: %w", err)
+	}
+	var state struct {
+		OSCrypt struct {
+			EncryptedKey string `json:"encrypted_key"`
+		} `json:"os_crypt"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse Chrome Local State: %w", err)
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(state.OSCrypt.EncryptedKey)
+	if err != nil || !bytes.HasPrefix(encrypted, []byte("DPAPI")) {
+		return nil, fmt.Errorf("Chrome Local State has no DPAPI-protected key")
+	}
+	return dpapiDecrypt(encrypted[len("DPAPI"):])
+}
+
+// decryptChromeValue decrypts an encrypted cookie value with the key for
+// its version prefix
+func decryptChromeValue(encrypted []byte, keys map[string]chromeKey, host string) (string, error) {
+	if len(encrypted) < 3 {
+		return "", fmt.Errorf("unsupported cookie encryption")
+	}
+	version := string(encrypted[:3])
+	key, ok := keys[version]
+	if !ok {
+		if version == "v20" {
+			return "", fmt.Errorf("cookie uses Chrome's app-bound encryption")
+		}
+		return "", fmt.Errorf("unsupported cookie encryption %q", version)
+	}
+
+	var plain []byte
+	var err error
+	if key.gcm {
+		plain, err = decryptGCM(encrypted[3:], key.key)
+	} else {
+		plain, err = decryptCBC(encrypted[3:], key.key)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Newer Chrome versions prefix the value with SHA-256 of the host
+	if len(plain) >= sha256.Size {
+		sum := sha256.Sum256([]byte(host))
+		if bytes.Equal(plain[:sha256.Size], sum[:]) {
+			plain = plain[sha256.Size:]
+		}
+	}
+
+	return string(plain), nil
+}
+
+// decryptCBC decrypts AES-128-CBC with Chrome's fixed IV and PKCS7 padding
+func decryptCBC(data, key []byte) ([]byte, error) {
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid encrypted cookie length")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	iv := bytes.Repeat([]byte{' '}, aes.BlockSize)
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	// Remove PKCS7 padding
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return plain[:len(plain)-padding], nil
+}
+
+// decryptGCM decrypts AES-GCM data laid out as nonce, ciphertext, tag
+func decryptGCM(data, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("invalid encrypted cookie length")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,75 @@
+package cookies
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"testing"
+	"time"
+)
+
+// encryptCBC is the inverse of decryptCBC
+func encryptCBC(t *testing.T, plain, key []byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	data := append(bytes.Clone(plain), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	cipher.NewCBCEncrypter(block, bytes.Repeat([]byte{' '}, aes.BlockSize)).CryptBlocks(data, data)
+	return data
+}
+
+func TestDecryptChromeValue(t *testing.T) {
+	peanuts, err := chromeCBCKey("peanuts", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := chromeCBCKey("from the keyring", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcmKey := bytes.Repeat([]byte{7}, 32)
+	block, _ := aes.NewCipher(gcmKey)
+	gcm, _ := cipher.NewGCM(block)
+	nonce := bytes.Repeat([]byte{1}, gcm.NonceSize())
+	hostHash := sha256.Sum256([]byte(".x.com"))
+
+	cbcKeys := map[string]chromeKey{"v10": {key: peanuts}, "v11": {key: keyring}}
+	gcmKeys := map[string]chromeKey{"v10": {key: gcmKey, gcm: true}}
+	tests := []struct {
+		name      string
+		encrypted []byte
+		keys      map[string]chromeKey
+		want      string
+		wantErr   bool
+	}{
+		{"v10 uses peanuts", append([]byte("v10"), encryptCBC(t, []byte("token"), peanuts)...), cbcKeys, "token", false},
+		{"v11 uses the keyring", append([]byte("v11"), encryptCBC(t, []byte("token"), keyring)...), cbcKeys, "token", false},
+		{"host hash prefix", append([]byte("v10"), encryptCBC(t, append(hostHash[:], "token"...), peanuts)...), cbcKeys, "token", false},
+		{"v11 without a keyring", append([]byte("v11"), encryptCBC(t, []byte("token"), keyring)...), map[string]chromeKey{"v10": {key: peanuts}}, "", true},
+		{"windows gcm", append(append([]byte("v10"), nonce...), gcm.Seal(nil, nonce, []byte("token"), nil)...), gcmKeys, "token", false},
+		{"app-bound", append([]byte("v20"), make([]byte, 32)...), gcmKeys, "", true},
+		{"truncated", []byte("v1"), cbcKeys, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decryptChromeValue(tt.encrypted, tt.keys, ".x.com")
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("got %q, %v; want %q (error: %v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFirefoxExpiry(t *testing.T) {
+	want := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := firefoxExpiry(want.Unix()); !got.Equal(want) {
+		t.Errorf("seconds: got %s, want %s", got, want)
+	}
+	if got := firefoxExpiry(want.UnixMilli()); !got.Equal(want) {
+		t.Errorf("milliseconds: got %s, want %s", got, want)
+	}
+}
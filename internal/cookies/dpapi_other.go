@@ -0,0 +1,10 @@
+//go:build !windows
+
+package cookies
+
+import "fmt"
+
+// dpapiDecrypt is only available on Windows
+func dpapiDecrypt([]byte) ([]byte, error) {
+	return nil, fmt.Errorf("DPAPI is only available on Windows")
+}
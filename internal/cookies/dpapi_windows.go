@@ -0,0 +1,33 @@
+package cookies
+
+import (
+	"bytes"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var procCryptUnprotectData = syscall.NewLazyDLL("crypt32.dll").NewProc("CryptUnprotectData")
+
+// dataBlob is the DATA_BLOB structure of the Windows crypto API
+type dataBlob struct {
+	size uint32
+	data *byte
+}
+
+// dpapiDecrypt decrypts data protected for the current Windows user
+func dpapiDecrypt(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no DPAPI data")
+	}
+	in := dataBlob{size: uint32(len(data)), data: &data[0]}
+	var out dataBlob
+	r, _, err := procCryptUnprotectData.Call(
+		uintptr(unsafe.Pointer(&in)), 0, 0, 0, 0, 0,
+		uintptr(unsafe.Pointer(&out)))
+	if r == 0 {
+		return nil, fmt.Errorf("CryptUnprotectData failed: %w", err)
+	}
+	defer syscall.LocalFree(syscall.Handle(unsafe.Pointer(out.data)))
+	return bytes.Clone(unsafe.Slice(out.data, out.size)), nil
+}
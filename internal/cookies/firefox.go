@@ -0,0 +1,73 @@
+package cookies
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// firefoxCookies reads cookies from the most recently used Firefox profile
+func firefoxCookies(domain string) ([]*http.Cookie, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles string
+	switch runtime.GOOS {
+	case "darwin":
+		profiles = filepath.Join(home, "Library", "Application Support", "Firefox", "Profiles")
+	case "windows":
+		profiles = filepath.Join(os.Getenv("APPDATA"), "Mozilla", "Firefox", "Profiles")
+	default:
+		profiles = filepath.Join(home, ".mozilla", "firefox")
+	}
+
+	dbPath, err := newestFile(filepath.Join(profiles, "*", "cookies.sqlite"))
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Host     string `json:"host"`
+		Name     string `json:"name"`
+		Value    string `json:"value"`
+		Path     string `json:"path"`
+		Expiry   int64  `json:"expiry"`
+		IsSecure int    `json:"isSecure"`
+	}
+	query := "SELECT host, name, value, path, expiry, isSecure FROM moz_cookies WHERE " + domainFilter("host", domain)
+	if err := queryDB(dbPath, query, &rows); err != nil {
+		return nil, err
+	}
+
+	var cookies []*http.Cookie
+	for _, r := range rows {
+		cookie := &http.Cookie{
+			Name:   r.Name,
+			Value:  r.Value,
+			Domain: r.Host,
+			Path:   r.Path,
+			Secure: r.IsSecure != 0,
+		}
+		// Session cookies have no expiry; setting one would make the jar
+		// treat them as expired and drop them
+		if r.Expiry > 0 {
+			cookie.Expires = firefoxExpiry(r.Expiry)
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, nil
+}
+
+// firefoxExpiry converts a moz_cookies expiry, which newer Firefox versions
+// store in milliseconds rather than seconds
+func firefoxExpiry(expiry int64) time.Time {
+	// No cookie expires beyond the year 5000 in seconds
+	if expiry > 1e11 {
+		return time.UnixMilli(expiry)
+	}
+	return time.Unix(expiry, 0)
+}
@@ -0,0 +1,109 @@
+package downloader
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Aggregate tracks overall progress across the downloads of a batch run.
+// Every downloadState reports its byte deltas into the active aggregate, so
+// the progress display can show a headline such as
+// "47% of 12 files, 3.1 GB/8.0 GB" in addition to per-file progress.
+type Aggregate struct {
+	files     int64
+	filesDone int64 // atomic
+	current   int64 // atomic, bytes downloaded across all jobs
+	total     int64 // atomic, known total bytes across all jobs
+
+	// transferred counts the bytes received in this run, unlike current
+	// without the parts of resumed files that were already on disk
+	transferred int64 // atomic
+
+	// Byte counters at the start of the current file, used to estimate
+	// the fraction of the in-flight file
+	fileStartCurrent int64 // atomic
+	fileStartTotal   int64 // atomic
+}
+
+var activeAggregate atomic.Pointer[Aggregate]
+
+// NewAggregate creates an aggregate for a batch of the given number of files
+// and makes it the active one. Call Finish when the batch is done.
+func NewAggregate(files int) *Aggregate {
+	a := &Aggregate{files: int64(files)}
+	activeAggregate.Store(a)
+	return a
+}
+
+// Finish detaches the aggregate so later downloads no longer report into it
+func (a *Aggregate) Finish() {
+	activeAggregate.CompareAndSwap(a, nil)
+}
+
+// StartFile marks the beginning of the next file in the batch
+func (a *Aggregate) StartFile() {
+	atomic.StoreInt64(&a.fileStartCurrent, atomic.LoadInt64(&a.current))
+	atomic.StoreInt64(&a.fileStartTotal, atomic.LoadInt64(&a.total))
+}
+
+// FileDone marks the current file as finished (successfully or not)
+func (a *Aggregate) FileDone() {
+	atomic.AddInt64(&a.filesDone, 1)
+}
+
+// add records byte deltas reported by a single download
+func (a *Aggregate) add(currentDelta, totalDelta int64) {
+	if currentDelta != 0 {
+		atomic.AddInt64(&a.current, currentDelta)
+	}
+	if totalDelta != 0 {
+		atomic.AddInt64(&a.total, totalDelta)
+	}
+}
+
+// addTransferred records n bytes received by a single download
+func (a *Aggregate) addTransferred(n int64) {
+	atomic.AddInt64(&a.transferred, n)
+}
+
+// Transferred returns the bytes downloaded so far in this run. Files that
+// were skipped and the resumed part of partial files don't count.
+func (a *Aggregate) Transferred() int64 {
+	return atomic.LoadInt64(&a.transferred)
+}
+
+// Progress returns the overall completion (0-1) along with the aggregated byte counts
+func (a *Aggregate) Progress() (fraction float64, current, total int64) {
+	done := atomic.LoadInt64(&a.filesDone)
+	current = atomic.LoadInt64(&a.current)
+	total = atomic.LoadInt64(&a.total)
+	if a.files <= 0 {
+		return 0, current, total
+	}
+
+	// Weight the in-flight file by its own byte progress
+	var inFlight float64
+	if done < a.files {
+		fileCurrent := current - atomic.LoadInt64(&a.fileStartCurrent)
+		fileTotal := total - atomic.LoadInt64(&a.fileStartTotal)
+		if fileTotal > 0 {
+			inFlight = float64(fileCurrent) / float64(fileTotal)
+			if inFlight > 1 {
+				inFlight = 1
+			}
+		}
+	}
+
+	return (float64(done) + inFlight) / float64(a.files), current, total
+}
+
+// Headline returns a one-line summary like "47% of 12 files, 3.1 GB/8.0 GB"
+func (a *Aggregate) Headline() string {
+	fraction, current, total := a.Progress()
+	return fmt.Sprintf("%.0f%% of %d files, %s/%s",
+		fraction*100,
+		a.files,
+		formatBytes(current),
+		formatBytes(total),
+	)
+}
@@ -0,0 +1,28 @@
+package downloader
+
+import "testing"
+
+func TestAggregateTransferred(t *testing.T) {
+	agg := NewAggregate(2)
+	defer agg.Finish()
+
+	// A resumed file: 100 bytes were on disk, 200 are downloaded
+	resumed := &downloadState{}
+	resumed.resumed(100)
+	resumed.update(100, 300)
+	resumed.update(250, 300)
+	resumed.update(300, 300)
+
+	// A file the server sent again from the start after 50 bytes
+	restarted := &downloadState{}
+	restarted.update(50, 100)
+	restarted.update(0, 100)
+	restarted.update(100, 100)
+
+	if got := agg.Transferred(); got != 200+150 {
+		t.Errorf("Transferred = %d, want 350", got)
+	}
+	if _, current, _ := agg.Progress(); current != 400 {
+		t.Errorf("progress counts %d bytes, want 400", current)
+	}
+}
@@ -0,0 +1,60 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// BenchResult is the outcome of one Benchmark run
+type BenchResult struct {
+	Streams int
+	Bytes   int64
+	Elapsed time.Duration
+}
+
+// Throughput returns the achieved speed in bytes per second
+func (r BenchResult) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.Elapsed.Seconds()
+}
+
+// ErrNoRangeSupport is returned by Benchmark when the server can only send
+// the whole file in one stream
+var ErrNoRangeSupport = errors.New("server doesn't support range requests, so the stream count makes no difference")
+
+// Benchmark downloads url with the given number of streams, discarding the
+// data. The run stops after limit (if > 0), which is not treated as an error.
+func Benchmark(ctx context.Context, url string, streams int, limit time.Duration) (BenchResult, error) {
+	if limit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limit)
+		defer cancel()
+	}
+
+	config := DefaultMultiStreamConfig()
+	config.Streams = streams
+
+	// Single-stream fallbacks would ignore the limit and measure nothing useful
+	size, supportsRange, _, err := probeRangeSupport(ctx, newMultiStreamClient(config), url, "")
+	if err != nil {
+		return BenchResult{}, fmt.Errorf("failed to probe server: %w", err)
+	}
+	if !supportsRange || size <= 0 {
+		return BenchResult{}, ErrNoRangeSupport
+	}
+
+	state := &downloadState{startTime: time.Now()}
+	err = MultiStreamDownload(ctx, url, os.DevNull, config, state)
+	elapsed := time.Since(state.startTime)
+	current, _, _, _, _ := state.get()
+	// Hitting the time limit just ends the measurement
+	if err != nil && !(ctx.Err() == context.DeadlineExceeded && current > 0) {
+		return BenchResult{}, err
+	}
+	return BenchResult{Streams: streams, Bytes: current, Elapsed: elapsed}, nil
+}
@@ -0,0 +1,183 @@
+package downloader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// dedupSampleSize is how much of the start and end of a file is hashed
+const dedupSampleSize = 64 * 1024
+
+// DedupIndex remembers a quick content hash of downloaded files so new
+// downloads that duplicate an existing file can be detected
+type DedupIndex struct {
+	path    string
+	Entries map[string]dedupEntry `json:"entries"` // By absolute path
+}
+
+type dedupEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Hash    string    `json:"hash"`
+}
+
+// LoadDedupIndex reads the index at path; a missing file gives an empty index
+func LoadDedupIndex(path string) (*DedupIndex, error) {
+	idx := &DedupIndex{path: path, Entries: make(map[string]dedupEntry)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if idx.Entries == nil {
+		idx.Entries = make(map[string]dedupEntry)
+	}
+	return idx, nil
+}
+
+// Save writes the index back to disk
+func (idx *DedupIndex) Save() error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(idx.path, data, 0644)
+}
+
+// FindDuplicate indexes the files next to file and returns an existing file
+// with the same content, or "" if there is none. file itself is added to
+// the index when it is unique.
+func (idx *DedupIndex) FindDuplicate(file string) (string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+
+	// Bring the directory's entries up to date (only new or changed files are hashed)
+	entries, err := os.ReadDir(filepath.Dir(abs))
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		p := filepath.Join(filepath.Dir(abs), e.Name())
+		if _, err := idx.entry(p); err != nil {
+			continue
+		}
+	}
+
+	// Drop files that no longer exist
+	for p := range idx.Entries {
+		if _, err := os.Stat(p); err != nil {
+			delete(idx.Entries, p)
+		}
+	}
+
+	target, ok := idx.Entries[abs]
+	if !ok {
+		return "", fmt.Errorf("failed to index %s", file)
+	}
+	for p, e := range idx.Entries {
+		if p == abs || e.Hash != target.Hash || e.Size != target.Size {
+			continue
+		}
+		if same, err := sameContent(abs, p); err == nil && same {
+			delete(idx.Entries, abs)
+			return p, nil
+		}
+	}
+	return "", nil
+}
+
+// entry returns the index entry for path, hashing it if it is new or changed
+func (idx *DedupIndex) entry(path string) (dedupEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return dedupEntry{}, err
+	}
+	if e, ok := idx.Entries[path]; ok && e.Size == info.Size() && e.ModTime.Equal(info.ModTime()) {
+		return e, nil
+	}
+
+	hash, err := quickHash(path, info.Size())
+	if err != nil {
+		return dedupEntry{}, err
+	}
+	e := dedupEntry{Size: info.Size(), ModTime: info.ModTime(), Hash: hash}
+	idx.Entries[path] = e
+	return e, nil
+}
+
+// quickHash hashes the size and the first and last dedupSampleSize bytes
+func quickHash(path string, size int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:", size)
+	if _, err := io.CopyN(h, f, dedupSampleSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	if size > 2*dedupSampleSize {
+		if _, err := f.Seek(-dedupSampleSize, io.SeekEnd); err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(h, f); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sameContent compares two files byte by byte
+func sameContent(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer fb.Close()
+
+	bufA := make([]byte, 256*1024)
+	bufB := make([]byte, 256*1024)
+	for {
+		na, errA := io.ReadFull(fa, bufA)
+		nb, errB := io.ReadFull(fb, bufB)
+		if na != nb || !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+		if errA == io.EOF || errA == io.ErrUnexpectedEOF {
+			return errB == io.EOF || errB == io.ErrUnexpectedEOF, nil
+		}
+		if errA != nil {
+			return false, errA
+		}
+		if errB != nil {
+			return false, errB
+		}
+	}
+}
@@ -1,14 +1,89 @@
 package downloader
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
 	"time"
+
+	"github.com/guiyumin/vget/internal/s3"
 )
 
+// ExistingMode decides what happens when a download's output already exists
+type ExistingMode int
+
+const (
+	// ExistingAuto skips files whose size matches the remote one, leaves
+	// larger ones alone, redownloads smaller ones and resumes a leftover
+	// .part file, starting over if the server can't resume
+	ExistingAuto ExistingMode = iota
+	// ExistingForce always downloads from scratch, overwriting the output
+	ExistingForce
+	// ExistingContinue is ExistingAuto, but fails rather than discard a
+	// .part file the server can't resume
+	ExistingContinue
+	// ExistingNoOverwrite skips any output that already exists
+	ExistingNoOverwrite
+)
+
+// preserveMtime controls whether downloads get the remote modification time
+var preserveMtime = true
+
+// SetPreserveMtime sets whether downloaded files get their modification
+// time from the server's Last-Modified header
+func SetPreserveMtime(preserve bool) {
+	preserveMtime = preserve
+}
+
+// setMtime sets the modification time of path to lastModified, an HTTP
+// date, if preserving it is enabled. Failures are ignored.
+func setMtime(path, lastModified string) {
+	if !preserveMtime || lastModified == "" || s3.IsS3URL(path) {
+		return
+	}
+	if t, err := http.ParseTime(lastModified); err == nil {
+		os.Chtimes(path, t, t)
+	}
+}
+
 // Downloader handles file downloads with progress reporting
 type Downloader struct {
 	lang string
+
+	// Existing is how an existing output (or .part file) is handled
+	Existing ExistingMode
+
+	// Refresh, if set, is called when a download fails because its URL has
+	// expired (403/410). It returns a fresh URL for the same file, which is
+	// retried once.
+	Refresh func(oldURL string) (string, error)
+
+	// Quiet prints only start and finish lines instead of a progress
+	// display, for downloads running side by side
+	Quiet bool
+}
+
+// StatusError is returned when the server answers a download request with
+// an unexpected HTTP status
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("download failed with status %d", e.StatusCode)
+}
+
+// IsExpired reports whether err means the URL is no longer valid, as with
+// signed CDN URLs that time out (403 Forbidden or 410 Gone)
+func IsExpired(err error) bool {
+	var se *StatusError
+	if !errors.As(err, &se) {
+		return false
+	}
+	return se.StatusCode == http.StatusForbidden || se.StatusCode == http.StatusGone
 }
 
 // New creates a new Downloader
@@ -18,15 +93,91 @@ func New(lang string) *Downloader {
 	}
 }
 
-// Download downloads a file from URL to the specified path using TUI
+// PartSuffix is added to the name of a local download until it completes.
+// It is vget-specific so that vget clean only removes vget's own files.
+const PartSuffix = ".vget.part"
+
+// Download downloads a file from URL to the specified path using TUI.
+// Local files are written to output+PartSuffix and renamed when complete,
+// so an interrupted download can be resumed by the next run.
 func (d *Downloader) Download(url, output, videoID string) error {
-	return RunDownloadTUI(url, output, videoID, d.lang)
+	if s3.IsS3URL(output) {
+		return d.download(url, output, output, videoID, 0)
+	}
+
+	if _, err := os.Stat(output); err == nil && !d.ShouldDownload(output, remoteSize(url)) {
+		return nil
+	}
+
+	part := output + PartSuffix
+	var offset int64
+	if d.Existing == ExistingForce {
+		os.Remove(part)
+	} else if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	if err := d.download(url, part, output, videoID, offset); err != nil {
+		return err
+	}
+	return os.Rename(part, output)
 }
 
-// DownloadFromReader downloads from an io.ReadCloser to the specified path using TUI
-// This is useful for WebDAV and other sources that provide a reader instead of URL
-func (d *Downloader) DownloadFromReader(reader io.ReadCloser, size int64, output, displayID string) error {
-	return RunDownloadFromReaderTUI(reader, size, output, displayID, d.lang)
+// download fetches url into target, resuming at offset, and retries once
+// with a refreshed URL if it has expired
+func (d *Downloader) download(url, target, output, videoID string, offset int64) error {
+	mustResume := d.Existing == ExistingContinue
+	err := runDownloadTUI(url, target, output, videoID, d.lang, offset, mustResume, d.Quiet)
+	if d.Refresh == nil || !IsExpired(err) {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "  URL expired (%v), re-extracting...\n", err)
+	fresh, refreshErr := d.Refresh(url)
+	if refreshErr != nil {
+		return fmt.Errorf("%w (re-extraction failed: %v)", err, refreshErr)
+	}
+	return runDownloadTUI(fresh, target, output, videoID, d.lang, offset, mustResume, d.Quiet)
+}
+
+// ShouldDownload applies the existing-file mode to output before a download
+// of a remote file of the given size (-1 if unknown) and reports whether
+// to proceed
+func (d *Downloader) ShouldDownload(output string, size int64) bool {
+	switch d.Existing {
+	case ExistingForce:
+		// Start over rather than resuming the chunks of an earlier run
+		os.Remove(partStatePath(output))
+		return true
+	case ExistingNoOverwrite:
+		// An interrupted multi-stream download isn't a finished file
+		if _, err := os.Stat(output); err == nil && !hasPartState(output) {
+			fmt.Printf("  Skipping %s: already exists\n", output)
+			return false
+		}
+		return true
+	}
+	switch checkExisting(output, size) {
+	case existingSkip:
+		fmt.Printf("  Skipping %s: already downloaded (%s)\n", output, formatBytes(size))
+		return false
+	case existingLarger:
+		fmt.Fprintf(os.Stderr, "  Warning: %s is larger than the remote file (%s), leaving it untouched\n", output, formatBytes(size))
+		return false
+	}
+	return true
+}
+
+// ReopenFunc re-opens a stream at offset after a read error. resumed reports
+// whether the new reader starts at offset; false means it starts over from
+// the beginning of the file.
+type ReopenFunc func(offset int64) (reader io.ReadCloser, resumed bool, err error)
+
+// DownloadFromReader downloads from an io.ReadCloser to the specified path using TUI.
+// If reopen is non-nil, dropped connections are retried from the bytes already written.
+// Cancelling ctx stops the download, also while waiting to retry.
+func (d *Downloader) DownloadFromReader(ctx context.Context, reader io.ReadCloser, size int64, output, displayID string, reopen ReopenFunc) error {
+	return RunDownloadFromReaderTUI(ctx, reader, size, output, displayID, d.lang, reopen)
 }
 
 func formatBytes(b int64) string {
@@ -0,0 +1,55 @@
+package downloader
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/guiyumin/vget/internal/httpclient"
+)
+
+// existingAction describes what to do with a file that may already exist locally
+type existingAction int
+
+const (
+	// existingDownload means the file should be (re)downloaded
+	existingDownload existingAction = iota
+	// existingSkip means the local file is already complete
+	existingSkip
+	// existingLarger means the local file is larger than the remote one
+	existingLarger
+)
+
+// checkExisting compares a local file against the expected remote size.
+// A missing file, an unknown remote size, or a smaller local file (e.g. left
+// behind by an interrupted run) all result in existingDownload. So does a
+// file with a .vget-part sidecar: multi-stream downloads preallocate the
+// full size, so its size says nothing about whether it is complete.
+func checkExisting(output string, remoteSize int64) existingAction {
+	info, err := os.Stat(output)
+	if err != nil || info.IsDir() || remoteSize <= 0 || hasPartState(output) {
+		return existingDownload
+	}
+
+	switch {
+	case info.Size() == remoteSize:
+		return existingSkip
+	case info.Size() > remoteSize:
+		return existingLarger
+	default:
+		return existingDownload
+	}
+}
+
+// remoteSize returns the Content-Length reported by a HEAD request, or -1 if unknown
+func remoteSize(url string) int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := httpclient.New(0)
+	size, _, err := probeWithHEAD(ctx, client, url, "")
+	if err != nil {
+		return -1
+	}
+	return size
+}
@@ -9,17 +9,57 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	tea "github.com/charmbracelet/bubbletea"
+	"github.com/guiyumin/vget/internal/httpclient"
 )
 
 // HLSConfig holds configuration for HLS downloads
 type HLSConfig struct {
 	Workers    int // Number of parallel segment downloads
 	BufferSize int // Buffer size for reading segments
+
+	// Start and End limit the download to the segments covering this time
+	// range; zero End means until the end of the stream
+	Start, End time.Duration
+
+	// FragmentDir, if set, receives a copy of every segment as it is
+	// downloaded, in a subdirectory named after the output (see
+	// segmentDir). The segments are removed after a successful download
+	// unless KeepFragments is set.
+	FragmentDir   string
+	KeepFragments bool
+
+	RateLimit int64 // Combined download rate cap in bytes per second (0 for no limit)
+}
+
+// segmentDir returns the directory the segments of output are staged in,
+// or "" if they aren't. Each output gets its own subdirectory so
+// concurrent downloads sharing FragmentDir don't overwrite each other.
+func (c HLSConfig) segmentDir(output string) string {
+	switch {
+	case c.FragmentDir != "":
+		return filepath.Join(c.FragmentDir, filepath.Base(output)+".fragments")
+	case c.KeepFragments:
+		return output + ".fragments"
+	}
+	return ""
+}
+
+// fragmentDir and keepFragments are the defaults set by SetHLSFragments
+var (
+	fragmentDir   string
+	keepFragments bool
+)
+
+// SetHLSFragments sets where HLS segments are staged and whether they are
+// kept after the download. With keep and no dir, segments go to
+// "<output>.fragments".
+func SetHLSFragments(dir string, keep bool) {
+	fragmentDir, keepFragments = dir, keep
 }
 
 // DefaultHLSConfig returns default HLS configuration
@@ -27,9 +67,19 @@ func DefaultHLSConfig() HLSConfig {
 	return HLSConfig{
 		Workers:    8,
 		BufferSize: 512 * 1024, // 512KB
+
+		FragmentDir:   fragmentDir,
+		KeepFragments: keepFragments,
+
+		RateLimit: rateLimit,
 	}
 }
 
+// segmentPath returns the file segment index is staged in below dir
+func segmentPath(dir string, index int) string {
+	return filepath.Join(dir, fmt.Sprintf("%05d.ts", index))
+}
+
 // hlsState tracks HLS download progress
 type hlsState struct {
 	downloaded     int64 // Segments downloaded (atomic)
@@ -56,13 +106,26 @@ func (s *hlsState) incDownloaded() {
 
 // RunHLSDownloadTUI downloads an HLS stream with TUI progress
 func RunHLSDownloadTUI(m3u8URL, output, displayID, lang string) error {
+	_, err := RunHLSClipTUI(m3u8URL, output, displayID, lang, 0, 0)
+	return err
+}
+
+// RunHLSClipTUI downloads only the segments of an HLS stream that cover
+// start to end (zero end means the whole rest of the stream). It returns
+// the stream time at which the output begins, which is at or before start.
+func RunHLSClipTUI(m3u8URL, output, displayID, lang string, start, end time.Duration) (time.Duration, error) {
 	state := &downloadState{startTime: time.Now()}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	config := DefaultHLSConfig()
+	config.Start, config.End = start, end
+
 	// Start download in background
+	var offset atomic.Int64
 	go func() {
-		err := downloadHLS(ctx, m3u8URL, output, state, DefaultHLSConfig())
+		start, err := downloadHLS(ctx, m3u8URL, output, state, config)
+		offset.Store(int64(start))
 		if err != nil {
 			state.setError(err)
 		} else {
@@ -70,65 +133,91 @@ func RunHLSDownloadTUI(m3u8URL, output, displayID, lang string) error {
 		}
 	}()
 
-	// Run TUI
-	model := newDownloadModel(output, displayID, lang, state)
-	p := tea.NewProgram(model)
-	finalModel, err := p.Run()
-	if err != nil {
-		return err
+	err := runProgress(output, displayID, lang, state, cancel)
+	return time.Duration(offset.Load()), err
+}
+
+// clipSegments returns the segments overlapping start to end and the stream
+// time at which the first of them begins
+func clipSegments(segments []Segment, start, end time.Duration) ([]Segment, time.Duration) {
+	if start <= 0 && end <= 0 {
+		return segments, 0
 	}
 
-	m := finalModel.(downloadModel)
-	_, _, _, _, downloadErr := m.state.get()
-	if downloadErr != nil {
-		return downloadErr
+	var clipped []Segment
+	var offset, pos time.Duration
+	for _, seg := range segments {
+		segEnd := pos + time.Duration(seg.Duration*float64(time.Second))
+		if segEnd > start && (end <= 0 || pos < end) {
+			if len(clipped) == 0 {
+				offset = pos
+			}
+			clipped = append(clipped, seg)
+		}
+		pos = segEnd
 	}
-	return nil
+	return clipped, offset
 }
 
-// downloadHLS downloads an HLS stream
-func downloadHLS(ctx context.Context, m3u8URL, output string, state *downloadState, config HLSConfig) error {
+// downloadHLS downloads an HLS stream. It returns the stream time at which
+// the output begins when config limits the time range.
+func downloadHLS(ctx context.Context, m3u8URL, output string, state *downloadState, config HLSConfig) (offset time.Duration, err error) {
 	// Parse the m3u8 playlist
 	playlist, err := ParseM3U8(m3u8URL)
 	if err != nil {
-		return fmt.Errorf("failed to parse m3u8: %w", err)
+		return 0, fmt.Errorf("failed to parse m3u8: %w", err)
 	}
 
 	// If master playlist, get the best variant and parse it
 	if playlist.IsMaster {
 		variant := playlist.SelectBestVariant()
 		if variant == nil {
-			return fmt.Errorf("no variants found in master playlist")
+			return 0, fmt.Errorf("no variants found in master playlist")
 		}
 		playlist, err = ParseM3U8(variant.URL)
 		if err != nil {
-			return fmt.Errorf("failed to parse variant playlist: %w", err)
+			return 0, fmt.Errorf("failed to parse variant playlist: %w", err)
 		}
 	}
 
 	if len(playlist.Segments) == 0 {
-		return fmt.Errorf("no segments found in playlist")
+		return 0, fmt.Errorf("no segments found in playlist")
+	}
+	// A live playlist (no #EXT-X-ENDLIST) is downloaded as far as it
+	// currently goes; it isn't polled for new segments
+	if !playlist.Ended {
+		state.setNote("live stream: only the segments available so far were saved")
 	}
 
-	// Get encryption key if needed
-	var decryptKey []byte
-	var decryptIV []byte
-	if playlist.IsEncrypted && playlist.KeyURL != "" {
-		decryptKey, err = fetchKey(playlist.KeyURL)
-		if err != nil {
-			return fmt.Errorf("failed to fetch encryption key: %w", err)
-		}
-		if playlist.KeyIV != "" {
-			decryptIV, _ = hex.DecodeString(playlist.KeyIV)
+	playlist.Segments, offset = clipSegments(playlist.Segments, config.Start, config.End)
+	if len(playlist.Segments) == 0 {
+		return 0, fmt.Errorf("requested time range is outside the stream")
+	}
+
+	// Get the encryption keys if needed; decrypting with anything else
+	// would just produce garbage
+	if playlist.IsEncrypted && playlist.KeyMethod != "AES-128" {
+		return 0, fmt.Errorf("unsupported HLS encryption method %s", playlist.KeyMethod)
+	}
+	keys, err := fetchKeys(ctx, playlist.Segments)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch encryption key: %w", err)
+	}
+
+	// From here on FragmentDir is the directory of this output's segments
+	config.FragmentDir = config.segmentDir(output)
+	if config.FragmentDir != "" {
+		if err := os.MkdirAll(config.FragmentDir, 0755); err != nil {
+			return 0, fmt.Errorf("failed to create fragment directory: %w", err)
 		}
 	}
 
 	// Create output file
-	file, err := os.Create(output)
+	file, err := createSink(ctx, output, -1, DefaultMultiStreamConfig().ChunkSize)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return 0, fmt.Errorf("failed to create output file: %w", err)
 	}
-	defer file.Close()
+	defer func() { err = closeSink(file, err) }()
 
 	// Set up progress tracking
 	// For HLS we estimate total size (unknown until download complete)
@@ -160,17 +249,32 @@ func downloadHLS(ctx context.Context, m3u8URL, output string, state *downloadSta
 
 	// Download segments
 	// We need to maintain order, so we download in parallel but write sequentially
-	err = downloadSegmentsOrdered(ctx, playlist.Segments, file, decryptKey, decryptIV, hlsState, config)
+	err = downloadSegmentsOrdered(ctx, playlist.Segments, file, keys, hlsState, config)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	if hlsState.getBytes() == 0 {
+		return 0, ErrEmptyDownload
 	}
 
-	return nil
+	// Fragments of a failed download are left for inspection. Only the
+	// segments written here are removed, never anything else in the directory.
+	if config.FragmentDir != "" && !config.KeepFragments {
+		for _, seg := range playlist.Segments {
+			os.Remove(segmentPath(config.FragmentDir, seg.Index))
+		}
+		os.Remove(config.FragmentDir) // Only succeeds if now empty
+	}
+
+	return offset, nil
 }
 
-// downloadSegmentsOrdered downloads segments in parallel but writes them in order
-func downloadSegmentsOrdered(ctx context.Context, segments []Segment, file *os.File,
-	decryptKey, decryptIV []byte, hlsState *hlsState, config HLSConfig) error {
+// downloadSegmentsOrdered downloads segments in parallel but writes them in order.
+// Segment sizes aren't known up front, so unlike multistream the output can't
+// be placed with WriteAt; instead at most 2*Workers segments may be fetched
+// ahead of the next one to write, which bounds memory for long playlists.
+func downloadSegmentsOrdered(ctx context.Context, segments []Segment, file Sink,
+	keys map[string][]byte, hlsState *hlsState, config HLSConfig) error {
 
 	type segmentResult struct {
 		index int
@@ -178,10 +282,17 @@ func downloadSegmentsOrdered(ctx context.Context, segments []Segment, file *os.F
 		err   error
 	}
 
-	// Buffer to hold downloaded segments waiting to be written
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Downloaded segments waiting for earlier ones to be written
 	results := make(map[int][]byte)
 	resultsChan := make(chan segmentResult, config.Workers)
-	var resultsLock sync.Mutex
+
+	// A slot is taken before a segment is handed out and returned once it
+	// has been written. Segments are handed out in order, so the next one to
+	// write always holds a slot and the window can't deadlock.
+	slots := make(chan struct{}, 2*config.Workers)
 
 	// Segment queue
 	segmentChan := make(chan Segment, len(segments))
@@ -191,32 +302,39 @@ func downloadSegmentsOrdered(ctx context.Context, segments []Segment, file *os.F
 	close(segmentChan)
 
 	// Create HTTP client
+	transport := httpclient.NewTransport()
+	transport.MaxIdleConnsPerHost = config.Workers * 2
+	transport.DisableCompression = true
 	client := &http.Client{
-		Timeout: 60 * time.Second,
-		Transport: &http.Transport{
-			MaxIdleConnsPerHost: config.Workers * 2,
-			DisableCompression:  true,
-		},
+		Timeout:   60 * time.Second,
+		Transport: httpclient.Wrap(transport),
 	}
 
+	// Shared by all workers and downloads so the cap applies to all of them
+	limiter := sharedRateLimiter(config.RateLimit)
+
 	// Start workers
 	var wg sync.WaitGroup
 	for i := 0; i < config.Workers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for seg := range segmentChan {
+			for {
 				select {
+				case slots <- struct{}{}:
 				case <-ctx.Done():
 					return
-				default:
+				}
+				seg, ok := <-segmentChan
+				if !ok {
+					return
 				}
 
-				data, err := downloadSegment(client, seg.URL, decryptKey, decryptIV, seg.Index, config.BufferSize)
-				resultsChan <- segmentResult{
-					index: seg.Index,
-					data:  data,
-					err:   err,
+				data, err := downloadSegment(ctx, client, seg, keys, limiter)
+				select {
+				case resultsChan <- segmentResult{index: seg.Index, data: data, err: err}:
+				case <-ctx.Done():
+					return
 				}
 			}
 		}()
@@ -229,48 +347,56 @@ func downloadSegmentsOrdered(ctx context.Context, segments []Segment, file *os.F
 	}()
 
 	// Collect results and write in order
-	nextIndex := 0
+	nextIndex := segments[0].Index
 	var writeErr error
 
 	for result := range resultsChan {
+		if writeErr != nil {
+			continue // Drain until the workers notice the cancellation
+		}
 		if result.err != nil {
 			writeErr = result.err
+			cancel()
 			continue
 		}
 
-		resultsLock.Lock()
 		results[result.index] = result.data
 		hlsState.incDownloaded()
 
 		// Write all consecutive segments we have
-		for {
-			if data, ok := results[nextIndex]; ok {
-				_, err := file.Write(data)
-				if err != nil {
+		for data, ok := results[nextIndex]; ok; data, ok = results[nextIndex] {
+			if config.FragmentDir != "" {
+				if err := os.WriteFile(segmentPath(config.FragmentDir, nextIndex), data, 0644); err != nil {
 					writeErr = err
-					resultsLock.Unlock()
+					cancel()
 					break
 				}
-				hlsState.addBytes(int64(len(data)))
-				delete(results, nextIndex)
-				nextIndex++
-			} else {
+			}
+			if _, err := file.Write(data); err != nil {
+				writeErr = err
+				cancel()
 				break
 			}
+			hlsState.addBytes(int64(len(data)))
+			delete(results, nextIndex)
+			nextIndex++
+			<-slots
 		}
-		resultsLock.Unlock()
 	}
 
 	if writeErr != nil {
 		return fmt.Errorf("failed to write segment: %w", writeErr)
 	}
+	if err := ctx.Err(); err != nil && nextIndex < segments[0].Index+len(segments) {
+		return err
+	}
 
 	return nil
 }
 
 // downloadSegment downloads a single segment
-func downloadSegment(client *http.Client, url string, decryptKey, decryptIV []byte, index, bufferSize int) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func downloadSegment(ctx context.Context, client *http.Client, seg Segment, keys map[string][]byte, limiter *rateLimiter) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", seg.URL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -283,29 +409,60 @@ func downloadSegment(client *http.Client, url string, decryptKey, decryptIV []by
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("segment %d returned status %d", index, resp.StatusCode)
+		return nil, fmt.Errorf("segment %d returned status %d", seg.Index, resp.StatusCode)
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(limitReader(ctx, resp.Body, limiter))
 	if err != nil {
 		return nil, err
 	}
 
 	// Decrypt if needed
-	if decryptKey != nil {
-		data, err = decryptAES128(data, decryptKey, decryptIV, index)
+	if seg.KeyURL != "" {
+		var iv []byte
+		if seg.KeyIV != "" {
+			if iv, err = hex.DecodeString(seg.KeyIV); err != nil || len(iv) != 16 {
+				return nil, fmt.Errorf("segment %d has an invalid IV: %s", seg.Index, seg.KeyIV)
+			}
+		}
+		data, err = decryptAES128(data, keys[seg.KeyURL], iv, seg.Sequence)
 		if err != nil {
-			return nil, fmt.Errorf("failed to decrypt segment %d: %w", index, err)
+			return nil, fmt.Errorf("failed to decrypt segment %d: %w", seg.Index, err)
 		}
 	}
 
 	return data, nil
 }
 
+// fetchKeys fetches every distinct key the segments are encrypted with
+func fetchKeys(ctx context.Context, segments []Segment) (map[string][]byte, error) {
+	client := httpclient.New(30 * time.Second)
+	keys := make(map[string][]byte)
+	for _, seg := range segments {
+		if seg.KeyURL == "" {
+			continue
+		}
+		if _, ok := keys[seg.KeyURL]; ok {
+			continue
+		}
+		key, err := fetchKey(ctx, client, seg.KeyURL)
+		if err != nil {
+			return nil, err
+		}
+		keys[seg.KeyURL] = key
+	}
+	return keys, nil
+}
+
 // fetchKey fetches the encryption key from the URL
-func fetchKey(url string) ([]byte, error) {
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Get(url)
+func fetchKey(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -315,24 +472,31 @@ func fetchKey(url string) ([]byte, error) {
 		return nil, fmt.Errorf("key server returned status %d", resp.StatusCode)
 	}
 
-	return io.ReadAll(resp.Body)
+	key, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 16 {
+		return nil, fmt.Errorf("AES-128 key from %s is %d bytes, not 16", url, len(key))
+	}
+	return key, nil
 }
 
 // decryptAES128 decrypts AES-128-CBC encrypted data
-func decryptAES128(data, key, iv []byte, segmentIndex int) ([]byte, error) {
+func decryptAES128(data, key, iv []byte, sequence int) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
 
-	// If no IV provided, use segment index as IV (per HLS spec)
+	// If no IV provided, use the media sequence number as IV (per HLS spec)
 	if iv == nil {
 		iv = make([]byte, 16)
 		// Segment sequence number as big-endian 128-bit integer
-		iv[15] = byte(segmentIndex)
-		iv[14] = byte(segmentIndex >> 8)
-		iv[13] = byte(segmentIndex >> 16)
-		iv[12] = byte(segmentIndex >> 24)
+		iv[15] = byte(sequence)
+		iv[14] = byte(sequence >> 8)
+		iv[13] = byte(sequence >> 16)
+		iv[12] = byte(sequence >> 24)
 	}
 
 	if len(data)%aes.BlockSize != 0 {
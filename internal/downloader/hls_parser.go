@@ -10,6 +10,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/guiyumin/vget/internal/httpclient"
 )
 
 // M3U8Playlist represents a parsed m3u8 playlist
@@ -19,8 +21,11 @@ type M3U8Playlist struct {
 	TotalDuration float64   // Total duration in seconds
 	IsMaster      bool      // True if this is a master playlist
 	IsEncrypted   bool      // True if segments are encrypted
+	KeyMethod     string    // Encryption method, e.g. "AES-128"
 	KeyURL        string    // URL of encryption key
 	KeyIV         string    // Initialization vector for encryption
+	MediaSequence int       // Sequence number of the first segment
+	Ended         bool      // False for live playlists still being appended to
 }
 
 // Variant represents a stream variant in a master playlist
@@ -37,7 +42,10 @@ type Segment struct {
 	URL      string
 	Duration float64
 	Index    int
+	Sequence int // Media sequence number, the default AES-128 IV
 	Title    string
+	KeyURL   string // AES-128 key for this segment, "" if unencrypted
+	KeyIV    string // Hex IV from the key tag, "" to derive it from Sequence
 }
 
 var (
@@ -53,9 +61,7 @@ var (
 
 // ParseM3U8 parses an m3u8 playlist from a URL
 func ParseM3U8(m3u8URL string) (*M3U8Playlist, error) {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	client := httpclient.New(30 * time.Second)
 
 	req, err := http.NewRequest("GET", m3u8URL, nil)
 	if err != nil {
@@ -84,6 +90,7 @@ func parseM3U8Content(reader io.Reader, baseURL string) (*M3U8Playlist, error) {
 	var currentSegmentDuration float64
 	var currentSegmentTitle string
 	var segmentIndex int
+	var currentKeyURL, currentKeyIV string
 
 	// Parse base URL for resolving relative URLs
 	base, err := url.Parse(baseURL)
@@ -115,20 +122,35 @@ func parseM3U8Content(reader io.Reader, baseURL string) (*M3U8Playlist, error) {
 			continue
 		}
 
-		// Parse encryption key
+		// Parse encryption key; it applies to the segments that follow
 		if strings.HasPrefix(line, "#EXT-X-KEY:") {
+			currentKeyURL, currentKeyIV = "", ""
 			method := extractRegex(keyMethodRegex, line)
 			if method != "NONE" && method != "" {
-				playlist.IsEncrypted = true
 				keyURI := extractRegex(keyURIRegex, line)
 				if keyURI != "" {
-					playlist.KeyURL = resolveURL(base, keyURI)
+					currentKeyURL = resolveURL(base, keyURI)
+				}
+				currentKeyIV = extractRegex(keyIVRegex, line)
+				if !playlist.IsEncrypted || method != "AES-128" {
+					playlist.IsEncrypted = true
+					playlist.KeyMethod = method
+					playlist.KeyURL = currentKeyURL
+					playlist.KeyIV = currentKeyIV
 				}
-				playlist.KeyIV = extractRegex(keyIVRegex, line)
 			}
 			continue
 		}
 
+		if strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:") {
+			playlist.MediaSequence, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+			continue
+		}
+		if line == "#EXT-X-ENDLIST" {
+			playlist.Ended = true
+			continue
+		}
+
 		// Parse segment info
 		if strings.HasPrefix(line, "#EXTINF:") {
 			matches := extinfoRegex.FindStringSubmatch(line)
@@ -152,7 +174,10 @@ func parseM3U8Content(reader io.Reader, baseURL string) (*M3U8Playlist, error) {
 				URL:      resolveURL(base, line),
 				Duration: currentSegmentDuration,
 				Index:    segmentIndex,
+				Sequence: playlist.MediaSequence + segmentIndex,
 				Title:    currentSegmentTitle,
+				KeyURL:   currentKeyURL,
+				KeyIV:    currentKeyIV,
 			}
 			playlist.Segments = append(playlist.Segments, segment)
 			playlist.TotalDuration += currentSegmentDuration
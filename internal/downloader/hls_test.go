@@ -0,0 +1,233 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// encryptAES128 is the inverse of decryptAES128: PKCS7 padding, then CBC
+func encryptAES128(t *testing.T, plain, key, iv []byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	data := append(bytes.Clone(plain), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	return data
+}
+
+// sequenceIV is the IV of a segment without an explicit one
+func sequenceIV(sequence int) []byte {
+	iv := make([]byte, 16)
+	binary.BigEndian.PutUint64(iv[8:], uint64(sequence))
+	return iv
+}
+
+func TestDownloadHLSDecryptsAES128(t *testing.T) {
+	key1 := []byte("0123456789abcdef")
+	key2 := []byte("fedcba9876543210")
+	iv1 := []byte("explicit-iv-0001")
+	const mediaSequence = 7
+
+	plain := [][]byte{
+		bytes.Repeat([]byte("segment zero "), 100),
+		bytes.Repeat([]byte("segment one "), 77),
+		[]byte("segment two is not encrypted"),
+	}
+	files := map[string][]byte{
+		"/key1": key1,
+		"/key2": key2,
+		"/0.ts": encryptAES128(t, plain[0], key1, iv1),
+		"/1.ts": encryptAES128(t, plain[1], key2, sequenceIV(mediaSequence+1)),
+		"/2.ts": plain[2],
+	}
+	files["/index.m3u8"] = []byte(fmt.Sprintf(`#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:%d
+#EXT-X-KEY:METHOD=AES-128,URI="key1",IV=0x%s
+#EXTINF:4.0,
+0.ts
+#EXT-X-KEY:METHOD=AES-128,URI="/key2"
+#EXTINF:4.0,
+1.ts
+#EXT-X-KEY:METHOD=NONE
+#EXTINF:4.0,
+2.ts
+#EXT-X-ENDLIST
+`, mediaSequence, hex.EncodeToString(iv1)))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	output := filepath.Join(t.TempDir(), "video.ts")
+	config := DefaultHLSConfig()
+	config.FragmentDir, config.KeepFragments = "", false
+	state := &downloadState{startTime: time.Now()}
+	if _, err := downloadHLS(context.Background(), srv.URL+"/index.m3u8", output, state, config); err != nil {
+		t.Fatalf("downloadHLS: %v", err)
+	}
+
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := bytes.Join(plain, nil); !bytes.Equal(got, want) {
+		t.Errorf("decrypted output is %d bytes and differs from the %d plain text bytes", len(got), len(want))
+	}
+	if note := state.getNote(); note != "" {
+		t.Errorf("finished playlist got note %q", note)
+	}
+}
+
+func TestDownloadHLSNotesLivePlaylist(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/index.m3u8" {
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-MEDIA-SEQUENCE:7\n#EXTINF:4.0,\n7.ts\n#EXTINF:4.0,\n8.ts\n")
+			return
+		}
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	output := filepath.Join(t.TempDir(), "video.ts")
+	config := DefaultHLSConfig()
+	config.FragmentDir, config.KeepFragments = "", false
+	state := &downloadState{startTime: time.Now()}
+	if _, err := downloadHLS(context.Background(), srv.URL+"/index.m3u8", output, state, config); err != nil {
+		t.Fatalf("downloadHLS: %v", err)
+	}
+	if got, _ := os.ReadFile(output); string(got) != "/7.ts/8.ts" {
+		t.Errorf("output = %q, want the two available segments", got)
+	}
+	if state.getNote() == "" {
+		t.Error("live playlist without #EXT-X-ENDLIST got no note")
+	}
+}
+
+func TestDownloadHLSRejectsBadKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.m3u8":
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"key\"\n#EXTINF:4.0,\n0.ts\n#EXT-X-ENDLIST\n")
+		case "/key":
+			w.Write([]byte("too short"))
+		default:
+			w.Write(make([]byte, 32))
+		}
+	}))
+	defer srv.Close()
+
+	output := filepath.Join(t.TempDir(), "video.ts")
+	state := &downloadState{startTime: time.Now()}
+	if _, err := downloadHLS(context.Background(), srv.URL+"/index.m3u8", output, state, DefaultHLSConfig()); err == nil {
+		t.Fatal("download succeeded with a 9 byte AES-128 key")
+	}
+}
+
+// orderSink records what is written and how many segments had been
+// requested when the first write happened
+type orderSink struct {
+	discardSink
+	data      bytes.Buffer
+	requested *atomic.Int64
+	firstSeen int64
+}
+
+func (s *orderSink) Write(p []byte) (int, error) {
+	if s.data.Len() == 0 {
+		s.firstSeen = s.requested.Load()
+	}
+	return s.data.Write(p)
+}
+
+func TestDownloadSegmentsBoundedLookAhead(t *testing.T) {
+	const numSegments = 200
+	segmentData := func(i int) []byte {
+		return bytes.Repeat([]byte{byte(i)}, 32*1024)
+	}
+
+	var requested atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var i int
+		fmt.Sscanf(r.URL.Path, "/%d.ts", &i)
+		requested.Add(1)
+		// Hold up the first segment so every other worker runs ahead
+		if i == 0 {
+			time.Sleep(300 * time.Millisecond)
+		}
+		w.Write(segmentData(i))
+	}))
+	defer srv.Close()
+
+	segments := make([]Segment, numSegments)
+	for i := range segments {
+		segments[i] = Segment{URL: fmt.Sprintf("%s/%d.ts", srv.URL, i), Index: i}
+	}
+
+	config := DefaultHLSConfig()
+	config.Workers = 4
+	config.FragmentDir = ""
+	sink := &orderSink{requested: &requested}
+	state := &hlsState{totalSegments: numSegments}
+	if err := downloadSegmentsOrdered(context.Background(), segments, sink, nil, state, config); err != nil {
+		t.Fatalf("downloadSegmentsOrdered: %v", err)
+	}
+
+	// Every buffered segment holds one of 2*Workers slots
+	if limit := int64(2 * config.Workers); sink.firstSeen > limit {
+		t.Errorf("%d segments fetched while waiting for the first, want at most %d", sink.firstSeen, limit)
+	}
+	var want bytes.Buffer
+	for i := range numSegments {
+		want.Write(segmentData(i))
+	}
+	if !bytes.Equal(sink.data.Bytes(), want.Bytes()) {
+		t.Error("segments written out of order or incomplete")
+	}
+}
+
+func BenchmarkDownloadSegmentsOrdered(b *testing.B) {
+	segment := bytes.Repeat([]byte{1}, 256*1024)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(segment)
+	}))
+	defer srv.Close()
+
+	segments := make([]Segment, 500)
+	for i := range segments {
+		segments[i] = Segment{URL: fmt.Sprintf("%s/%d.ts", srv.URL, i), Index: i}
+	}
+	config := DefaultHLSConfig()
+	config.FragmentDir = ""
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(segments) * len(segment)))
+	for b.Loop() {
+		state := &hlsState{totalSegments: int64(len(segments))}
+		if err := downloadSegmentsOrdered(context.Background(), segments, discardSink{}, nil, state, config); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
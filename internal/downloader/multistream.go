@@ -2,15 +2,16 @@ package downloader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	tea "github.com/charmbracelet/bubbletea"
+	"github.com/guiyumin/vget/internal/httpclient"
 )
 
 // MultiStreamConfig configures multi-stream downloads
@@ -19,8 +20,24 @@ type MultiStreamConfig struct {
 	ChunkSize  int64 // Size of each chunk in bytes (default 16MB)
 	BufferSize int   // Buffer size per stream (default 1MB)
 	UseHTTP2   bool  // Enable HTTP/2 (default true, better for HTTPS)
+
+	PartRetries int   // Times a chunk with bad content is downloaded again from scratch
+	RateLimit   int64 // Combined download rate cap in bytes per second (0 for no limit)
 }
 
+// partRetries is the PartRetries value of DefaultMultiStreamConfig
+var partRetries = 2
+
+// SetPartRetries sets how often a chunk whose content fails verification is
+// downloaded again, on top of the request retries within a chunk
+func SetPartRetries(n int) {
+	partRetries = n
+}
+
+// errBadChunk marks a response whose content doesn't match the requested
+// range; resuming it would keep the bad bytes, so the chunk starts over
+var errBadChunk = errors.New("chunk content doesn't match the requested range")
+
 // DefaultMultiStreamConfig returns sensible defaults similar to rclone
 func DefaultMultiStreamConfig() MultiStreamConfig {
 	return MultiStreamConfig{
@@ -28,6 +45,9 @@ func DefaultMultiStreamConfig() MultiStreamConfig {
 		ChunkSize:  8 * 1024 * 1024,  // 8MB chunks - smaller for faster recovery on failure
 		BufferSize: 1024 * 1024,      // 1MB buffer per stream
 		UseHTTP2:   true,             // Enable HTTP/2 by default for better multiplexing
+
+		PartRetries: partRetries,
+		RateLimit:   rateLimit,
 	}
 }
 
@@ -38,12 +58,73 @@ type multiStreamState struct {
 	startTime  time.Time
 	mu         sync.RWMutex
 	errors     []error
+	active     map[int]*chunkProgress // chunks currently being downloaded, by index
+
+	retries     int64 // atomic: requests retried within a chunk
+	partRetries int64 // atomic: chunks downloaded again after failing verification
+
+	limiter *rateLimiter // shared by all streams; nil for no limit
+}
+
+// chunkProgress is the byte counter of a single chunk
+type chunkProgress struct {
+	index      int
+	size       int64
+	downloaded int64
 }
 
 func (s *multiStreamState) addBytes(n int64) {
 	atomic.AddInt64(&s.downloaded, n)
 }
 
+// startChunk registers c as active so its progress can be displayed
+func (s *multiStreamState) startChunk(c chunk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil {
+		s.active = make(map[int]*chunkProgress)
+	}
+	s.active[c.index] = &chunkProgress{index: c.index, size: c.end - c.start + 1}
+}
+
+// finishChunk removes a chunk from the active set
+func (s *multiStreamState) finishChunk(index int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.active, index)
+}
+
+// addChunkBytes records n bytes written to the chunk with the given index
+func (s *multiStreamState) addChunkBytes(index int, n int64) {
+	s.addBytes(n)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if cp, ok := s.active[index]; ok {
+		atomic.AddInt64(&cp.downloaded, n)
+	}
+}
+
+// activeChunks returns a snapshot of the active chunks, ordered by index
+func (s *multiStreamState) activeChunks() []chunkProgress {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	chunks := make([]chunkProgress, 0, len(s.active))
+	for _, cp := range s.active {
+		chunks = append(chunks, chunkProgress{
+			index:      cp.index,
+			size:       cp.size,
+			downloaded: atomic.LoadInt64(&cp.downloaded),
+		})
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].index < chunks[j].index })
+	return chunks
+}
+
+// retryStats returns the request and part retry counts
+func (s *multiStreamState) retryStats() (retries, parts int) {
+	return int(atomic.LoadInt64(&s.retries)), int(atomic.LoadInt64(&s.partRetries))
+}
+
 func (s *multiStreamState) getDownloaded() int64 {
 	return atomic.LoadInt64(&s.downloaded)
 }
@@ -69,12 +150,12 @@ type chunk struct {
 
 // probeRangeSupport checks if the server supports Range requests using a small ranged GET
 // This is more reliable than HEAD because many CDNs only advertise Accept-Ranges on GET
-// Returns: totalSize, supportsRange, error
-func probeRangeSupport(ctx context.Context, client *http.Client, url, authHeader string) (int64, bool, error) {
+// Returns: totalSize, supportsRange, ETag, error
+func probeRangeSupport(ctx context.Context, client *http.Client, url, authHeader string) (int64, bool, string, error) {
 	// First try a ranged GET request for just 2 bytes
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return 0, false, err
+		return 0, false, "", err
 	}
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
 	req.Header.Set("Range", "bytes=0-1")
@@ -84,40 +165,60 @@ func probeRangeSupport(ctx context.Context, client *http.Client, url, authHeader
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return 0, false, err
+		return 0, false, "", err
 	}
 	defer resp.Body.Close()
 
 	// Drain the small response body
 	io.Copy(io.Discard, resp.Body)
 
+	// Encoded bodies must be fetched in one piece and decoded
+	if httpclient.IsEncoded(resp.Header) {
+		return -1, false, "", nil
+	}
+
+	etag := resp.Header.Get("ETag")
 	switch resp.StatusCode {
 	case http.StatusPartialContent:
 		// Server supports ranges - parse Content-Range for total size
 		// Format: bytes 0-1/total
-		contentRange := resp.Header.Get("Content-Range")
-		var start, end, total int64
-		if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &total); err == nil {
-			return total, true, nil
+		if total := contentRangeTotal(resp.Header.Get("Content-Range")); total >= 0 {
+			return total, true, etag, nil
 		}
-		// Couldn't parse Content-Range, fall back to HEAD
-		return probeWithHEAD(ctx, client, url, authHeader)
+		// Total not in Content-Range ("bytes 0-1/*"), ask HEAD for the size.
+		// The 206 already shows ranges work, whatever Accept-Ranges says.
+		size, _, err := probeWithHEAD(ctx, client, url, authHeader)
+		if err != nil || size < 0 {
+			return -1, true, etag, nil
+		}
+		return size, true, etag, nil
 
 	case http.StatusOK:
 		// Server returned 200 instead of 206 - doesn't support ranges
 		// But we can get the size from Content-Length
-		return resp.ContentLength, false, nil
+		return resp.ContentLength, false, etag, nil
 
 	case http.StatusRequestedRangeNotSatisfiable:
 		// 416 means server supports ranges but our range was invalid
 		// This shouldn't happen for bytes=0-1, but fall back to HEAD
-		return probeWithHEAD(ctx, client, url, authHeader)
+		size, supportsRange, err := probeWithHEAD(ctx, client, url, authHeader)
+		return size, supportsRange, "", err
 
 	default:
-		return 0, false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return 0, false, "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 }
 
+// contentRangeTotal returns the total size from a Content-Range header
+// ("bytes 0-1/1234"), or -1 if it is missing or unknown ("bytes 0-1/*")
+func contentRangeTotal(contentRange string) int64 {
+	var start, end, total int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		return -1
+	}
+	return total
+}
+
 // probeWithHEAD is a fallback that uses HEAD request to get file size
 func probeWithHEAD(ctx context.Context, client *http.Client, url, authHeader string) (int64, bool, error) {
 	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
@@ -135,117 +236,34 @@ func probeWithHEAD(ctx context.Context, client *http.Client, url, authHeader str
 	}
 	resp.Body.Close()
 
+	if httpclient.IsEncoded(resp.Header) {
+		return -1, false, nil
+	}
+
 	supportsRange := resp.Header.Get("Accept-Ranges") == "bytes"
 	return resp.ContentLength, supportsRange, nil
 }
 
-// MultiStreamDownload downloads a file using multiple parallel HTTP Range requests
-func MultiStreamDownload(ctx context.Context, url, output string, config MultiStreamConfig, state *downloadState) error {
-	// Create HTTP client with optimized transport for high-speed downloads
-	client := &http.Client{
-		Timeout: 0,
-		Transport: &http.Transport{
-			MaxIdleConns:        0,                 // Unlimited idle connections
-			MaxIdleConnsPerHost: config.Streams*2 + 10,
-			MaxConnsPerHost:     0,                 // Unlimited connections per host (like rclone)
-			IdleConnTimeout:     120 * time.Second,
-			DisableCompression:  true,              // Avoid CPU overhead for already compressed media
-			ForceAttemptHTTP2:   config.UseHTTP2,   // Allow HTTP/2 for better multiplexing
-			WriteBufferSize:     128 * 1024,        // 128KB write buffer
-			ReadBufferSize:      128 * 1024,        // 128KB read buffer
-		},
-	}
-
-	// Probe for range support and get file size using a small ranged GET
-	// Many CDNs only advertise Accept-Ranges on GET, not HEAD
-	totalSize, supportsRange, err := probeRangeSupport(ctx, client, url, "")
-	if err != nil {
-		return fmt.Errorf("failed to probe server: %w", err)
-	}
-
-	if totalSize <= 0 {
-		return fmt.Errorf("server did not return Content-Length")
-	}
-
-	// Fall back to single-stream if range not supported
-	if !supportsRange {
-		return downloadWithProgress(client, url, output, state)
-	}
-
-	state.update(0, totalSize)
-
-	// Create the output file
-	file, err := os.Create(output)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer file.Close()
-
-	// Pre-allocate file size for efficiency
-	if err := file.Truncate(totalSize); err != nil {
-		// Non-fatal, continue anyway
-	}
-
-	// Calculate chunks
-	chunks := calculateChunks(totalSize, config.Streams, config.ChunkSize)
-
-	// Create multi-stream state
-	msState := &multiStreamState{
-		total:     totalSize,
-		startTime: state.startTime,
-	}
-
-	// Start progress updater goroutine
-	progressDone := make(chan struct{})
-	go func() {
-		ticker := time.NewTicker(50 * time.Millisecond)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-progressDone:
-				return
-			case <-ticker.C:
-				state.update(msState.getDownloaded(), totalSize)
-			}
-		}
-	}()
-
-	// Download chunks in parallel using a worker pool
-	var wg sync.WaitGroup
-	chunkChan := make(chan chunk, len(chunks))
-
-	// Feed chunks to the channel
-	for _, c := range chunks {
-		chunkChan <- c
-	}
-	close(chunkChan)
-
-	// Start worker goroutines
-	for i := 0; i < config.Streams; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for c := range chunkChan {
-				if err := downloadChunk(ctx, client, url, file, c, config.BufferSize, msState); err != nil {
-					msState.addError(fmt.Errorf("chunk %d failed: %w", c.index, err))
-				}
-			}
-		}()
-	}
-
-	// Wait for all downloads to complete
-	wg.Wait()
-	close(progressDone)
-
-	// Final progress update
-	state.update(msState.getDownloaded(), totalSize)
-
-	// Check for errors
-	if errs := msState.getErrors(); len(errs) > 0 {
-		return fmt.Errorf("download failed with %d errors: %v", len(errs), errs[0])
+// newMultiStreamClient creates an HTTP client with a transport tuned for parallel range downloads
+func newMultiStreamClient(config MultiStreamConfig) *http.Client {
+	transport := httpclient.NewTransport()
+	transport.MaxIdleConns = 0 // Unlimited idle connections
+	transport.MaxIdleConnsPerHost = config.Streams*2 + 10
+	transport.IdleConnTimeout = 120 * time.Second
+	transport.DisableCompression = true           // Avoid CPU overhead for already compressed media
+	transport.ForceAttemptHTTP2 = config.UseHTTP2 // Allow HTTP/2 for better multiplexing
+	transport.WriteBufferSize = 128 * 1024        // 128KB write buffer
+	transport.ReadBufferSize = 128 * 1024         // 128KB read buffer
+
+	return &http.Client{
+		Timeout:   0,
+		Transport: httpclient.Wrap(transport),
 	}
+}
 
-	return nil
+// MultiStreamDownload downloads a file using multiple parallel HTTP Range requests
+func MultiStreamDownload(ctx context.Context, url, output string, config MultiStreamConfig, state *downloadState) error {
+	return MultiStreamDownloadWithAuth(ctx, url, "", output, 0, config, state)
 }
 
 // calculateChunks divides the file into download chunks
@@ -281,15 +299,20 @@ func calculateChunks(totalSize int64, streams int, chunkSize int64) []chunk {
 	return chunks
 }
 
-// downloadChunk downloads a single chunk using HTTP Range request with resumable retry logic
+// downloadChunk downloads a single chunk using HTTP Range request with resumable retry logic,
+// sending authHeader as Authorization if it is set.
 // Instead of restarting from byte 0 on failure, it resumes from the last successfully written byte
-func downloadChunk(ctx context.Context, client *http.Client, url string, file *os.File, c chunk, bufferSize int, state *multiStreamState) error {
+func downloadChunk(ctx context.Context, client *http.Client, url, authHeader string, file Sink, c chunk, bufferSize int, state *multiStreamState) error {
 	const maxRetries = 10 // More retries since we resume, not restart
 	var lastErr error
 	currentStart := c.start // Track where we are in the chunk
 
+	state.startChunk(c)
+	defer state.finishChunk(c.index)
+
 	for attempt := 0; attempt < maxRetries; attempt++ {
 		if attempt > 0 {
+			atomic.AddInt64(&state.retries, 1)
 			// Shorter backoff since we're resuming: 500ms, 1s, 2s, 4s... capped at 8s
 			backoff := time.Duration(1<<uint(attempt-1)) * 500 * time.Millisecond
 			if backoff > 8*time.Second {
@@ -302,24 +325,21 @@ func downloadChunk(ctx context.Context, client *http.Client, url string, file *o
 			}
 		}
 
-		// Create a sub-chunk from current position to end
-		subChunk := chunk{
-			index: c.index,
-			start: currentStart,
-			end:   c.end,
+		bytesWritten, newOffset, err := downloadChunkOnce(ctx, client, url, authHeader, file, c, currentStart, bufferSize, state)
+		if errors.Is(err, errBadChunk) {
+			// Take the chunk's bytes back out of the progress; it starts over
+			state.addBytes(c.start - newOffset)
+			return err
 		}
-
-		bytesWritten, newOffset, err := downloadChunkOnce(ctx, client, url, file, subChunk, bufferSize, state)
 		if err == nil {
 			return nil // Success!
 		}
 
 		lastErr = err
-		// Update currentStart to resume from where we left off
-		// bytesWritten already added to state, so we keep that progress
-		if bytesWritten > 0 {
-			currentStart = newOffset
-		}
+		// Resume from where this attempt left off (which is back at the
+		// chunk start if the server restarted it); the bytes written are
+		// already in state, so we keep that progress
+		currentStart = newOffset
 
 		// Check if context was cancelled
 		if ctx.Err() != nil {
@@ -336,35 +356,57 @@ func downloadChunk(ctx context.Context, client *http.Client, url string, file *o
 	return fmt.Errorf("after %d retries: %w", maxRetries, lastErr)
 }
 
-// downloadChunkOnce performs a single attempt to download a chunk
-// Returns bytes written, final offset position, and any error
-func downloadChunkOnce(ctx context.Context, client *http.Client, url string, file *os.File, c chunk, bufferSize int, state *multiStreamState) (int64, int64, error) {
+// downloadChunkOnce performs a single attempt to download chunk c from
+// offset on. Returns bytes written, final offset position, and any error.
+func downloadChunkOnce(ctx context.Context, client *http.Client, url, authHeader string, file Sink, c chunk, offset int64, bufferSize int, state *multiStreamState) (int64, int64, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return 0, c.start, err
+		return 0, offset, err
 	}
 
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", c.start, c.end))
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, c.end))
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return 0, c.start, err
+		return 0, offset, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
-		return 0, c.start, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return 0, offset, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	if err := checkChunkResponse(resp, c, offset); err != nil {
+		return 0, offset, err
 	}
 
-	buf := make([]byte, bufferSize)
-	offset := c.start
 	expectedEnd := c.end + 1 // end is inclusive
+	body := io.Reader(resp.Body)
+	if resp.StatusCode == http.StatusOK {
+		// The whole file, so the chunk starts over from byte 0 and only
+		// its part of the body is used, whether or not this was a resume
+		if offset > c.start {
+			state.addChunkBytes(c.index, c.start-offset)
+			offset = c.start
+		}
+		body = io.LimitReader(resp.Body, expectedEnd-c.start)
+	}
+
+	buf := make([]byte, bufferSize)
 	var totalWritten int64
 
 	for {
-		n, readErr := resp.Body.Read(buf)
+		n, readErr := body.Read(buf)
+		if offset+int64(n) > expectedEnd {
+			return totalWritten, offset, fmt.Errorf("%w: more than %d bytes", errBadChunk, expectedEnd-c.start)
+		}
 		if n > 0 {
+			if err := state.limiter.wait(ctx, n); err != nil {
+				return totalWritten, offset, err
+			}
 			// Write at specific offset (thread-safe with pwrite)
 			written, writeErr := file.WriteAt(buf[:n], offset)
 			if writeErr != nil {
@@ -372,7 +414,7 @@ func downloadChunkOnce(ctx context.Context, client *http.Client, url string, fil
 			}
 			offset += int64(written)
 			totalWritten += int64(written)
-			state.addBytes(int64(written))
+			state.addChunkBytes(c.index, int64(written))
 		}
 		if readErr == io.EOF {
 			// Verify we got the full chunk
@@ -389,6 +431,36 @@ func downloadChunkOnce(ctx context.Context, client *http.Client, url string, fil
 	return totalWritten, offset, nil
 }
 
+// checkChunkResponse verifies that a response to the request for chunk c
+// from offset on actually carries that range, so it can't be written at
+// the wrong offset
+func checkChunkResponse(resp *http.Response, c chunk, offset int64) error {
+	if resp.StatusCode == http.StatusOK {
+		// Range ignored: only usable if the chunk starts the file
+		if c.start != 0 {
+			return fmt.Errorf("%w: server sent the whole file for bytes=%d-%d", errBadChunk, offset, c.end)
+		}
+		return nil
+	}
+	var start, end int64
+	if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/", &start, &end); err == nil && start != offset {
+		return fmt.Errorf("%w: got bytes %d-%d for %d-%d", errBadChunk, start, end, offset, c.end)
+	}
+	return nil
+}
+
+// withPartRetries runs download, starting the chunk over up to n times when
+// its content fails verification
+func withPartRetries(c chunk, n int, state *multiStreamState, download func() error) error {
+	for part := 0; ; part++ {
+		err := download()
+		if !errors.Is(err, errBadChunk) || part >= n {
+			return err
+		}
+		atomic.AddInt64(&state.partRetries, 1)
+	}
+}
+
 // RunMultiStreamDownloadTUI runs a multi-stream download with TUI progress
 func RunMultiStreamDownloadTUI(url, output, displayID, lang string, config MultiStreamConfig) error {
 	state := &downloadState{
@@ -408,66 +480,51 @@ func RunMultiStreamDownloadTUI(url, output, displayID, lang string, config Multi
 		}
 	}()
 
-	model := newDownloadModel(output, displayID, lang, state)
-
-	p := tea.NewProgram(model)
-	finalModel, err := p.Run()
-	if err != nil {
-		cancel()
-		return err
-	}
-
-	m := finalModel.(downloadModel)
-	_, _, _, _, downloadErr := m.state.get()
-	if downloadErr != nil {
-		return downloadErr
-	}
-
-	return nil
+	return runProgress(output, displayID, lang, state, cancel)
 }
 
-// MultiStreamDownloadWithAuth downloads a file using multiple parallel HTTP Range requests with auth
-func MultiStreamDownloadWithAuth(ctx context.Context, url, authHeader, output string, totalSize int64, config MultiStreamConfig, state *downloadState) error {
+// MultiStreamDownloadWithAuth downloads a file using multiple parallel HTTP Range requests with auth.
+// A totalSize of 0 means unknown; the size is then probed from the server.
+func MultiStreamDownloadWithAuth(ctx context.Context, url, authHeader, output string, totalSize int64, config MultiStreamConfig, state *downloadState) (err error) {
 	// Create HTTP client with optimized transport for high-speed downloads
-	client := &http.Client{
-		Timeout: 0,
-		Transport: &http.Transport{
-			MaxIdleConns:        0,                 // Unlimited idle connections
-			MaxIdleConnsPerHost: config.Streams*2 + 10,
-			MaxConnsPerHost:     0,                 // Unlimited connections per host (like rclone)
-			IdleConnTimeout:     120 * time.Second,
-			DisableCompression:  true,              // Avoid CPU overhead for already compressed media
-			ForceAttemptHTTP2:   config.UseHTTP2,   // Allow HTTP/2 for better multiplexing
-			WriteBufferSize:     128 * 1024,        // 128KB write buffer
-			ReadBufferSize:      128 * 1024,        // 128KB read buffer
-		},
-	}
+	client := newMultiStreamClient(config)
 
 	// Probe for range support using ranged GET (more reliable than HEAD)
-	_, supportsRange, err := probeRangeSupport(ctx, client, url, authHeader)
+	probedSize, supportsRange, etag, err := probeRangeSupport(ctx, client, url, authHeader)
 	if err != nil {
+		if totalSize <= 0 {
+			return fmt.Errorf("failed to probe server: %w", err)
+		}
 		// If probe fails, assume range is supported (we have totalSize from caller)
 		supportsRange = true
 	}
 
+	// The caller may not know the size (e.g. WebDAV without getcontentlength)
+	if totalSize <= 0 && err == nil {
+		totalSize = probedSize
+	}
+	if totalSize < 0 {
+		supportsRange = false
+	}
+
 	state.update(0, totalSize)
 
+	if totalSize == 0 {
+		return ErrEmptyDownload
+	}
+
 	// If no Range support, fall back to single-stream
 	if !supportsRange {
-		return downloadWithAuthSingleStream(ctx, client, url, authHeader, output, totalSize, state)
+		return downloadWithAuthSingleStream(ctx, client, url, authHeader, output, totalSize, sharedRateLimiter(config.RateLimit), state)
 	}
 
-	// Create the output file
-	file, err := os.Create(output)
+	// Create the output, keeping chunks an interrupted run finished
+	resume := loadPartState(output, totalSize, config.ChunkSize, etag)
+	file, err := createResumableSink(ctx, output, totalSize, config.ChunkSize, resume)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
-	defer file.Close()
-
-	// Pre-allocate file size for efficiency
-	if err := file.Truncate(totalSize); err != nil {
-		// Non-fatal, continue anyway
-	}
+	defer func() { err = closeSink(file, err) }()
 
 	// Calculate chunks
 	chunks := calculateChunks(totalSize, config.Streams, config.ChunkSize)
@@ -476,7 +533,10 @@ func MultiStreamDownloadWithAuth(ctx context.Context, url, authHeader, output st
 	msState := &multiStreamState{
 		total:     totalSize,
 		startTime: state.startTime,
+		limiter:   sharedRateLimiter(config.RateLimit),
 	}
+	state.setChunkSource(msState.activeChunks)
+	state.resumed(resume.doneBytes(chunks))
 
 	// Start progress updater goroutine
 	progressDone := make(chan struct{})
@@ -497,8 +557,12 @@ func MultiStreamDownloadWithAuth(ctx context.Context, url, authHeader, output st
 	var wg sync.WaitGroup
 	chunkChan := make(chan chunk, len(chunks))
 
-	// Feed chunks to the channel
+	// Feed chunks to the channel, counting finished ones as downloaded
 	for _, c := range chunks {
+		if resume.isDone(c.index) {
+			msState.addBytes(c.end - c.start + 1)
+			continue
+		}
 		chunkChan <- c
 	}
 	close(chunkChan)
@@ -509,8 +573,13 @@ func MultiStreamDownloadWithAuth(ctx context.Context, url, authHeader, output st
 		go func() {
 			defer wg.Done()
 			for c := range chunkChan {
-				if err := downloadChunkWithAuth(ctx, client, url, authHeader, file, c, config.BufferSize, msState); err != nil {
+				err := withPartRetries(c, config.PartRetries, msState, func() error {
+					return downloadChunk(ctx, client, url, authHeader, file, c, config.BufferSize, msState)
+				})
+				if err != nil {
 					msState.addError(fmt.Errorf("chunk %d failed: %w", c.index, err))
+				} else {
+					resume.markDone(c.index)
 				}
 			}
 		}()
@@ -522,127 +591,22 @@ func MultiStreamDownloadWithAuth(ctx context.Context, url, authHeader, output st
 
 	// Final progress update
 	state.update(msState.getDownloaded(), totalSize)
+	state.setRetryStats(msState.retryStats())
 
 	// Check for errors
 	if errs := msState.getErrors(); len(errs) > 0 {
 		return fmt.Errorf("download failed with %d errors: %v", len(errs), errs[0])
 	}
-
-	return nil
-}
-
-// downloadChunkWithAuth downloads a single chunk using HTTP Range request with auth
-// It includes resumable retry logic - on failure, it resumes from the last written byte
-func downloadChunkWithAuth(ctx context.Context, client *http.Client, url, authHeader string, file *os.File, c chunk, bufferSize int, state *multiStreamState) error {
-	const maxRetries = 10 // More retries since we resume, not restart
-	var lastErr error
-	currentStart := c.start // Track where we are in the chunk
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			// Shorter backoff since we're resuming: 500ms, 1s, 2s, 4s... capped at 8s
-			backoff := time.Duration(1<<uint(attempt-1)) * 500 * time.Millisecond
-			if backoff > 8*time.Second {
-				backoff = 8 * time.Second
-			}
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(backoff):
-			}
-		}
-
-		// Create a sub-chunk from current position to end
-		subChunk := chunk{
-			index: c.index,
-			start: currentStart,
-			end:   c.end,
-		}
-
-		bytesWritten, newOffset, err := downloadChunkWithAuthOnce(ctx, client, url, authHeader, file, subChunk, bufferSize, state)
-		if err == nil {
-			return nil // Success!
-		}
-
-		lastErr = err
-		// Update currentStart to resume from where we left off
-		if bytesWritten > 0 {
-			currentStart = newOffset
-		}
-
-		// Check if context was cancelled
-		if ctx.Err() != nil {
-			return ctx.Err()
-		}
-
-		// Reset attempt counter when we make progress
-		if bytesWritten > 0 {
-			attempt = 0
-		}
-	}
-
-	return fmt.Errorf("after %d retries: %w", maxRetries, lastErr)
-}
-
-// downloadChunkWithAuthOnce performs a single attempt to download a chunk
-// Returns bytes written, final offset, and any error
-func downloadChunkWithAuthOnce(ctx context.Context, client *http.Client, url, authHeader string, file *os.File, c chunk, bufferSize int, state *multiStreamState) (int64, int64, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return 0, c.start, err
-	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", c.start, c.end))
-	if authHeader != "" {
-		req.Header.Set("Authorization", authHeader)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, c.start, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
-		return 0, c.start, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	buf := make([]byte, bufferSize)
-	offset := c.start
-	expectedEnd := c.end + 1 // end is inclusive
-	var totalWritten int64
-
-	for {
-		n, readErr := resp.Body.Read(buf)
-		if n > 0 {
-			// Write at specific offset (thread-safe with pwrite)
-			written, writeErr := file.WriteAt(buf[:n], offset)
-			if writeErr != nil {
-				return totalWritten, offset, fmt.Errorf("write failed: %w", writeErr)
-			}
-			offset += int64(written)
-			totalWritten += int64(written)
-			// Update progress in real-time
-			state.addBytes(int64(written))
-		}
-		if readErr == io.EOF {
-			// Verify we got the full chunk
-			if offset < expectedEnd {
-				return totalWritten, offset, fmt.Errorf("incomplete: got %d/%d bytes", offset-c.start, expectedEnd-c.start)
-			}
-			break
-		}
-		if readErr != nil {
-			return totalWritten, offset, fmt.Errorf("read failed: %w", readErr)
-		}
+	if msState.getDownloaded() == 0 {
+		return ErrEmptyDownload
 	}
 
-	return totalWritten, offset, nil
+	resume.remove()
+	return nil
 }
 
 // downloadWithAuthSingleStream falls back to single-stream download when Range not supported
-func downloadWithAuthSingleStream(ctx context.Context, client *http.Client, url, authHeader, output string, total int64, state *downloadState) error {
+func downloadWithAuthSingleStream(ctx context.Context, client *http.Client, url, authHeader, output string, total int64, limiter *rateLimiter, state *downloadState) (err error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -663,19 +627,29 @@ func downloadWithAuthSingleStream(ctx context.Context, client *http.Client, url,
 		return fmt.Errorf("download failed with status %d", resp.StatusCode)
 	}
 
+	body, encoded, err := httpclient.DecodedBody(resp)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if encoded {
+		total = -1 // Decoded size is unknown
+	}
+
 	// Create output file
-	file, err := os.Create(output)
+	file, err := createSink(ctx, output, total, DefaultMultiStreamConfig().ChunkSize)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
-	defer file.Close()
+	defer func() { err = closeSink(file, err) }()
 
 	// Download with progress tracking
+	reader := limitReader(ctx, body, limiter)
 	buf := make([]byte, 128*1024) // 128KB buffer
 	var current int64
 
 	for {
-		n, err := resp.Body.Read(buf)
+		n, err := reader.Read(buf)
 		if n > 0 {
 			_, writeErr := file.Write(buf[:n])
 			if writeErr != nil {
@@ -692,6 +666,9 @@ func downloadWithAuthSingleStream(ctx context.Context, client *http.Client, url,
 		}
 	}
 
+	if current == 0 {
+		return ErrEmptyDownload
+	}
 	return nil
 }
 
@@ -714,20 +691,5 @@ func RunMultiStreamDownloadWithAuthTUI(url, authHeader, output, displayID, lang
 		}
 	}()
 
-	model := newDownloadModel(output, displayID, lang, state)
-
-	p := tea.NewProgram(model)
-	finalModel, err := p.Run()
-	if err != nil {
-		cancel()
-		return err
-	}
-
-	m := finalModel.(downloadModel)
-	_, _, _, _, downloadErr := m.state.get()
-	if downloadErr != nil {
-		return downloadErr
-	}
-
-	return nil
+	return runProgress(output, displayID, lang, state, cancel)
 }
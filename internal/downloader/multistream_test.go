@@ -0,0 +1,83 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// ignoresRangeOnRetry serves content with ranges, but cuts the first
+// response short and answers every later request with the whole file
+func ignoresRangeOnRetry(content []byte) *httptest.Server {
+	var requests atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 {
+			w.Write(content)
+			return
+		}
+		var start, end int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+		w.Header().Set("Content-Length", fmt.Sprint(end-start+1))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(content[start : start+(end-start+1)/2]) // Connection drops halfway
+	}))
+}
+
+func TestDownloadChunkRestartsOnWholeFileResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 300)
+	srv := ignoresRangeOnRetry(content)
+	defer srv.Close()
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	c := chunk{index: 0, start: 0, end: 999}
+	state := &multiStreamState{}
+	if err := downloadChunk(context.Background(), srv.Client(), srv.URL, "", file, c, 128, state); err != nil {
+		t.Fatalf("downloadChunk: %v", err)
+	}
+
+	got := make([]byte, 1000)
+	if _, err := file.ReadAt(got, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content[:1000]) {
+		t.Error("chunk content differs after the server restarted it")
+	}
+	if n := state.getDownloaded(); n != 1000 {
+		t.Errorf("downloaded = %d, want 1000", n)
+	}
+}
+
+func TestDownloadChunkWholeFileForLaterChunk(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 300)
+	srv := ignoresRangeOnRetry(content)
+	defer srv.Close()
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	c := chunk{index: 1, start: 1000, end: 1999}
+	state := &multiStreamState{}
+	err = downloadChunk(context.Background(), srv.Client(), srv.URL, "", file, c, 128, state)
+	if !errors.Is(err, errBadChunk) {
+		t.Fatalf("err = %v, want errBadChunk so the chunk starts over", err)
+	}
+	if n := state.getDownloaded(); n != 0 {
+		t.Errorf("downloaded = %d after the chunk was discarded, want 0", n)
+	}
+}
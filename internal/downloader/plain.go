@@ -0,0 +1,121 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/guiyumin/vget/internal/i18n"
+	"golang.org/x/term"
+)
+
+// ProgressMode selects how download progress is rendered
+type ProgressMode int
+
+const (
+	// ProgressAuto uses the TUI on a terminal and plain lines otherwise
+	ProgressAuto ProgressMode = iota
+	// ProgressNone prints only start and finish lines
+	ProgressNone
+	// ProgressDetailed adds a small bar per active chunk to the TUI for
+	// multi-stream downloads
+	ProgressDetailed
+)
+
+var progressMode = ProgressAuto
+
+// SetProgressMode sets how progress is rendered for all downloads
+func SetProgressMode(mode ProgressMode) {
+	progressMode = mode
+}
+
+// isTerminal reports whether stdout is an interactive terminal that can
+// run the TUI (not TERM=dumb)
+func isTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("TERM") != "dumb"
+}
+
+// runProgress renders progress for a download running in the background
+// until it finishes, and returns the download error. If the TUI fails,
+// cancel (if not nil) stops the download before the error is returned.
+func runProgress(output, displayID, lang string, state *downloadState, cancel context.CancelFunc) error {
+	if progressMode == ProgressNone || !isTerminal() {
+		return runPlainProgress(output, displayID, lang, state, progressMode != ProgressNone)
+	}
+
+	model := newDownloadModel(output, displayID, lang, state)
+
+	p := tea.NewProgram(model)
+	finalModel, err := p.Run()
+	if err != nil {
+		if cancel != nil {
+			cancel()
+			<-state.finished()
+		}
+		return err
+	}
+
+	m := finalModel.(downloadModel)
+	_, _, _, _, downloadErr := m.state.get()
+	return downloadErr
+}
+
+// runPlainProgress prints start/finish lines and, if showLines is set,
+// periodic "42% (120MB/280MB)" lines suitable for logs
+func runPlainProgress(output, displayID, lang string, state *downloadState, showLines bool) error {
+	t := i18n.T(lang)
+	active, saved := state.labels(t)
+	fmt.Printf("  %s: %s\n", active, displayID)
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	finished := state.finished()
+
+	lastPercent := -1
+	for {
+		select {
+		case <-ticker.C:
+		case <-finished:
+		}
+		current, total, _, done, err := state.get()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  %s: %v\n", t.Download.Failed, err)
+			return err
+		}
+		if done {
+			break
+		}
+		if !showLines {
+			continue
+		}
+
+		if total > 0 {
+			percent := int(current * 100 / total)
+			if percent != lastPercent {
+				fmt.Printf("  %d%% (%s/%s)\n", percent, formatBytes(current), formatBytes(total))
+				lastPercent = percent
+			}
+		} else {
+			fmt.Printf("  %s\n", formatBytes(current))
+		}
+	}
+
+	current, _, _, _, _ := state.get()
+	elapsed, avgSpeed := state.getFinal()
+	fmt.Printf("  %s: %s (%s, %s, %s/s)\n",
+		saved,
+		output,
+		formatBytes(current),
+		formatDuration(elapsed),
+		formatBytes(int64(avgSpeed)),
+	)
+	if retries := state.retrySummary(); retries != "" {
+		fmt.Printf("  (%s)\n", retries)
+	}
+	if note := state.getNote(); note != "" {
+		fmt.Printf("  Note: %s\n", note)
+	}
+	return nil
+}
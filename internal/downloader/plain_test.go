@@ -0,0 +1,42 @@
+package downloader
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPlainProgressReturnsWhenDone(t *testing.T) {
+	for _, wantErr := range []error{nil, errors.New("connection reset")} {
+		state := &downloadState{startTime: time.Now()}
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			state.update(100, 100)
+			if wantErr != nil {
+				state.setError(wantErr)
+			} else {
+				state.setDone()
+			}
+		}()
+
+		start := time.Now()
+		err := runPlainProgress("out.bin", "id", "en", state, true)
+		if err != wantErr {
+			t.Errorf("err = %v, want %v", err, wantErr)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("returned %s after the download finished", elapsed)
+		}
+	}
+}
+
+func TestFinishedAfterDone(t *testing.T) {
+	state := &downloadState{}
+	state.setDone()
+	select {
+	case <-state.finished():
+	default:
+		t.Error("finished() isn't closed for a download that is already done")
+	}
+	state.setError(errors.New("late")) // Must not close the channel twice
+}
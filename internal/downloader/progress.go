@@ -1,10 +1,12 @@
 package downloader
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/guiyumin/vget/internal/httpclient"
 	"github.com/guiyumin/vget/internal/i18n"
 )
 
@@ -29,15 +32,43 @@ type downloadState struct {
 	total       int64
 	speed       float64
 	done        bool
+	doneCh      chan struct{} // closed when done is set; see finished
 	err         error
 	startTime   time.Time
 	endTime     time.Time
 	finalSpeed  float64
+
+	// chunks reports per-chunk progress for multi-stream downloads
+	chunks func() []chunkProgress
+
+	// Request and part (whole chunk) retries of a multi-stream download
+	retries     int
+	partRetries int
+
+	// upload labels the progress as an upload rather than a download
+	upload bool
+
+	// resumedBytes were on disk before the download started
+	resumedBytes int64
+
+	// note is shown with the final summary, e.g. that a live stream was
+	// only saved as far as it went
+	note string
 }
 
 func (s *downloadState) update(current, total int64) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if agg := activeAggregate.Load(); agg != nil {
+		var totalDelta int64
+		if total > 0 {
+			totalDelta = total - max(s.total, 0)
+		}
+		agg.add(current-s.current, totalDelta)
+		if received := current - max(s.current, s.resumedBytes); received > 0 && !s.upload {
+			agg.addTransferred(received)
+		}
+	}
 	s.current = current
 	s.total = total
 	elapsed := time.Since(s.startTime).Seconds()
@@ -46,6 +77,15 @@ func (s *downloadState) update(current, total int64) {
 	}
 }
 
+// resumed records that the first n bytes were already on disk, so they
+// count toward the progress but not as transferred; call it before the
+// first update
+func (s *downloadState) resumed(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resumedBytes = n
+}
+
 func (s *downloadState) setDone() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -54,22 +94,101 @@ func (s *downloadState) setDone() {
 	if elapsed > 0 {
 		s.finalSpeed = float64(s.current) / elapsed
 	}
-	s.done = true
+	s.finish()
 }
 
 func (s *downloadState) setError(err error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.err = err
+	s.finish()
+}
+
+// finish marks the download as done; s.mu must be held
+func (s *downloadState) finish() {
+	if !s.done && s.doneCh != nil {
+		close(s.doneCh)
+	}
 	s.done = true
 }
 
+// finished returns a channel that is closed once the download is done
+func (s *downloadState) finished() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.doneCh == nil {
+		s.doneCh = make(chan struct{})
+		if s.done {
+			close(s.doneCh)
+		}
+	}
+	return s.doneCh
+}
+
 func (s *downloadState) get() (int64, int64, float64, bool, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.current, s.total, s.speed, s.done, s.err
 }
 
+// setChunkSource attaches a per-chunk progress source for the detailed view
+func (s *downloadState) setChunkSource(chunks func() []chunkProgress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chunks = chunks
+}
+
+// setRetryStats records how often a multi-stream download had to retry
+func (s *downloadState) setRetryStats(retries, parts int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries = retries
+	s.partRetries = parts
+}
+
+// retrySummary describes the retries for the final summary, or "" if none
+func (s *downloadState) retrySummary() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.retries == 0 && s.partRetries == 0 {
+		return ""
+	}
+	return fmt.Sprintf("retried %d request(s), %d part(s)", s.retries, s.partRetries)
+}
+
+// setNote sets a remark for the final summary
+func (s *downloadState) setNote(note string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.note = note
+}
+
+// getNote returns the remark set by setNote, or ""
+func (s *downloadState) getNote() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.note
+}
+
+// labels returns the in-progress and finished labels for the transfer
+func (s *downloadState) labels(t *i18n.Translations) (active, saved string) {
+	if s.upload {
+		return t.Download.Uploading, t.Download.Uploaded
+	}
+	return t.Download.Downloading, t.Download.FileSaved
+}
+
+// getChunks returns the active chunks, or nil for single-stream downloads
+func (s *downloadState) getChunks() []chunkProgress {
+	s.mu.RLock()
+	chunks := s.chunks
+	s.mu.RUnlock()
+	if chunks == nil {
+		return nil
+	}
+	return chunks()
+}
+
 func (s *downloadState) getFinal() (elapsed time.Duration, avgSpeed float64) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -188,10 +307,11 @@ func (m downloadModel) View() string {
 
 	if done {
 		elapsed, avgSpeed := m.state.getFinal()
-		return fmt.Sprintf("\n  %s %s\n  %s: %s (%s)\n  %s: %s  |  %s: %s/s\n\n",
+		_, saved := m.state.labels(m.t)
+		summary := fmt.Sprintf("\n  %s %s\n  %s: %s (%s)\n  %s: %s  |  %s: %s/s\n",
 			doneStyle.Render("✓"),
 			m.t.Download.Completed,
-			m.t.Download.FileSaved,
+			saved,
 			m.output,
 			formatBytes(current),
 			m.t.Download.Elapsed,
@@ -199,21 +319,42 @@ func (m downloadModel) View() string {
 			m.t.Download.AvgSpeed,
 			formatBytes(int64(avgSpeed)),
 		)
+		if retries := m.state.retrySummary(); retries != "" {
+			summary += fmt.Sprintf("  %s\n", helpStyle.Render(retries))
+		}
+		if note := m.state.getNote(); note != "" {
+			summary += fmt.Sprintf("  %s\n", infoStyle.Render(note))
+		}
+		return summary + "\n"
 	}
 
 	var s string
 	s += "\n"
 
+	// Overall batch progress
+	if agg := activeAggregate.Load(); agg != nil {
+		s += fmt.Sprintf("  %s\n", helpStyle.Render(agg.Headline()))
+	}
+
 	// Video ID with spinner
+	active, _ := m.state.labels(m.t)
 	s += fmt.Sprintf("  %s %s: %s\n\n",
 		m.spinner.View(),
-		m.t.Download.Downloading,
+		active,
 		infoStyle.Render(m.videoID),
 	)
 
 	// Progress bar
 	s += fmt.Sprintf("  %s\n\n", m.progress.View())
 
+	// Per-chunk bars
+	if progressMode == ProgressDetailed {
+		for _, c := range m.state.getChunks() {
+			s += fmt.Sprintf("  %s\n", chunkBar(c))
+		}
+		s += "\n"
+	}
+
 	// Stats
 	if total > 0 {
 		percent := float64(current) / float64(total) * 100
@@ -243,6 +384,26 @@ func (m downloadModel) View() string {
 	return s
 }
 
+// chunkBar renders a one-line bar for a single chunk, e.g. "#12 ██████░░░░  62%"
+func chunkBar(c chunkProgress) string {
+	const width = 20
+	var ratio float64
+	if c.size > 0 {
+		ratio = float64(c.downloaded) / float64(c.size)
+	}
+	filled := int(ratio * width)
+	if filled > width {
+		filled = width
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+	return fmt.Sprintf("%s %s %3.0f%%  %s",
+		helpStyle.Render(fmt.Sprintf("#%-4d", c.index)),
+		bar,
+		ratio*100,
+		helpStyle.Render(formatBytes(c.downloaded)+"/"+formatBytes(c.size)),
+	)
+}
+
 func calculateETA(remaining int64, speed float64) string {
 	if speed <= 0 {
 		return "??:??"
@@ -253,7 +414,14 @@ func calculateETA(remaining int64, speed float64) string {
 
 // RunDownloadTUI runs the download with a TUI progress display
 func RunDownloadTUI(url, output, videoID, lang string) error {
-	client := &http.Client{Timeout: 0}
+	return runDownloadTUI(url, output, output, videoID, lang, 0, false, false)
+}
+
+// runDownloadTUI downloads url into target, shown as output, appending from
+// offset if target holds the start of the file already. With quiet set only
+// start and finish lines are printed.
+func runDownloadTUI(url, target, output, videoID, lang string, offset int64, mustResume, quiet bool) error {
+	client := httpclient.New(0)
 
 	state := &downloadState{
 		startTime: time.Now(),
@@ -261,7 +429,7 @@ func RunDownloadTUI(url, output, videoID, lang string) error {
 
 	// Start download in background
 	go func() {
-		err := downloadWithProgress(client, url, output, state)
+		err := resumeWithProgress(client, url, target, offset, mustResume, state)
 		if err != nil {
 			state.setError(err)
 		} else {
@@ -269,24 +437,16 @@ func RunDownloadTUI(url, output, videoID, lang string) error {
 		}
 	}()
 
-	model := newDownloadModel(output, videoID, lang, state)
-
-	p := tea.NewProgram(model)
-	finalModel, err := p.Run()
-	if err != nil {
-		return err
+	if quiet {
+		return runPlainProgress(output, videoID, lang, state, false)
 	}
-
-	m := finalModel.(downloadModel)
-	_, _, _, _, downloadErr := m.state.get()
-	if downloadErr != nil {
-		return downloadErr
-	}
-
-	return nil
+	return runProgress(output, videoID, lang, state, nil)
 }
 
-func downloadWithProgress(client *http.Client, url, output string, state *downloadState) error {
+// resumeWithProgress downloads url into output. With offset > 0 the rest of
+// the file is requested and appended; if the server sends the whole file
+// instead, the download starts over unless mustResume is set.
+func resumeWithProgress(client *http.Client, url, output string, offset int64, mustResume bool, state *downloadState) (err error) {
 	// Create HTTP request
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -295,6 +455,10 @@ func downloadWithProgress(client *http.Client, url, output string, state *downlo
 
 	// Set common headers
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("Accept-Encoding", "identity") // Appending needs the raw bytes
+	}
 
 	// Execute request
 	resp, err := client.Do(req)
@@ -303,26 +467,60 @@ func downloadWithProgress(client *http.Client, url, output string, state *downlo
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// The partial file already has every byte
+		state.update(offset, offset)
+		return nil
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 && mustResume {
+			return fmt.Errorf("server can't resume %s; use --force to start over", output)
+		}
+		// Without range support the whole file is sent again
+		offset = 0
+	default:
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	body, encoded, err := httpclient.DecodedBody(resp)
+	if err != nil {
+		return err
 	}
+	defer body.Close()
 
 	total := resp.ContentLength
-	state.update(0, total)
+	if encoded {
+		total = -1 // Content-Length is the encoded size
+	}
+	if total == 0 && offset == 0 {
+		return ErrEmptyDownload
+	}
+	if total > 0 {
+		total += offset
+	}
+	state.resumed(offset)
+	state.update(offset, total)
 
-	// Create output file
-	file, err := os.Create(output)
+	// Create output file, or append to the partial one
+	var file Sink
+	if offset > 0 {
+		file, err = os.OpenFile(output, os.O_WRONLY|os.O_APPEND, 0)
+	} else {
+		file, err = createSink(req.Context(), output, total, DefaultMultiStreamConfig().ChunkSize)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
-	defer file.Close()
+	defer func() { err = closeSink(file, err) }()
 
 	// Download with progress tracking
+	reader := limitReader(req.Context(), body, sharedRateLimiter(rateLimit))
 	buf := make([]byte, 32*1024)
-	var current int64
+	current := offset
 
 	for {
-		n, err := resp.Body.Read(buf)
+		n, err := reader.Read(buf)
 		if n > 0 {
 			_, writeErr := file.Write(buf[:n])
 			if writeErr != nil {
@@ -339,18 +537,25 @@ func downloadWithProgress(client *http.Client, url, output string, state *downlo
 		}
 	}
 
+	if current == 0 {
+		return ErrEmptyDownload
+	}
+	setMtime(output, resp.Header.Get("Last-Modified"))
 	return nil
 }
 
 // RunDownloadFromReaderTUI runs the download from a reader with a TUI progress display
-func RunDownloadFromReaderTUI(reader io.ReadCloser, size int64, output, displayID, lang string) error {
+func RunDownloadFromReaderTUI(ctx context.Context, reader io.ReadCloser, size int64, output, displayID, lang string, reopen ReopenFunc) error {
 	state := &downloadState{
 		startTime: time.Now(),
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Start download in background
 	go func() {
-		err := downloadFromReaderWithProgress(reader, size, output, state)
+		err := downloadFromReaderWithProgress(ctx, reader, size, output, state, reopen)
 		if err != nil {
 			state.setError(err)
 		} else {
@@ -358,41 +563,31 @@ func RunDownloadFromReaderTUI(reader io.ReadCloser, size int64, output, displayI
 		}
 	}()
 
-	model := newDownloadModel(output, displayID, lang, state)
-
-	p := tea.NewProgram(model)
-	finalModel, err := p.Run()
-	if err != nil {
-		return err
-	}
-
-	m := finalModel.(downloadModel)
-	_, _, _, _, downloadErr := m.state.get()
-	if downloadErr != nil {
-		return downloadErr
-	}
-
-	return nil
+	return runProgress(output, displayID, lang, state, cancel)
 }
 
-func downloadFromReaderWithProgress(reader io.ReadCloser, total int64, output string, state *downloadState) error {
-	defer reader.Close()
+func downloadFromReaderWithProgress(ctx context.Context, reader io.ReadCloser, total int64, output string, state *downloadState, reopen ReopenFunc) (err error) {
+	defer func() { reader.Close() }()
 
 	state.update(0, total)
 
 	// Create output file
-	file, err := os.Create(output)
+	file, err := createSink(ctx, output, total, DefaultMultiStreamConfig().ChunkSize)
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
-	defer file.Close()
+	defer func() { err = closeSink(file, err) }()
 
 	// Download with progress tracking
+	const maxRetries = 10
+	limiter := sharedRateLimiter(rateLimit)
+	src := limitReader(ctx, reader, limiter)
 	buf := make([]byte, 32*1024)
 	var current int64
+	attempt := 0
 
 	for {
-		n, err := reader.Read(buf)
+		n, err := src.Read(buf)
 		if n > 0 {
 			_, writeErr := file.Write(buf[:n])
 			if writeErr != nil {
@@ -400,14 +595,73 @@ func downloadFromReaderWithProgress(reader io.ReadCloser, total int64, output st
 			}
 			current += int64(n)
 			state.update(current, total)
+			attempt = 0 // Reset retries when we make progress
 		}
 		if err == io.EOF {
-			break
+			if total <= 0 || current >= total {
+				break
+			}
+			// The connection ended early; resume like after a read error
+			err = io.ErrUnexpectedEOF
 		}
-		if err != nil {
+		if err == nil {
+			continue
+		}
+
+		if reopen == nil || attempt >= maxRetries || ctx.Err() != nil {
 			return fmt.Errorf("download failed: %w", err)
 		}
+		attempt++
+
+		// Backoff: 500ms, 1s, 2s... capped at 8s
+		backoff := time.Duration(1<<uint(attempt-1)) * 500 * time.Millisecond
+		if backoff > 8*time.Second {
+			backoff = 8 * time.Second
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		reader.Close()
+		newReader, resumed, openErr := reopen(current)
+		if openErr != nil {
+			reader = io.NopCloser(&errReader{err: openErr})
+			src = reader
+			continue
+		}
+		reader = newReader
+		src = limitReader(ctx, reader, limiter)
+
+		if !resumed {
+			// Server ignored the range: start the file over
+			f, ok := file.(*os.File)
+			if !ok {
+				return fmt.Errorf("download failed: %w (server can't resume)", err)
+			}
+			if err := f.Truncate(0); err != nil {
+				return fmt.Errorf("failed to restart file: %w", err)
+			}
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return fmt.Errorf("failed to restart file: %w", err)
+			}
+			current = 0
+			state.update(current, total)
+		}
 	}
 
+	if current == 0 {
+		return ErrEmptyDownload
+	}
 	return nil
 }
+
+// errReader returns err on every read, so a failed reopen is retried like a read error
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,123 @@
+package downloader
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// rateLimit is the RateLimit value of DefaultMultiStreamConfig and
+// DefaultHLSConfig, and caps single-stream downloads
+var rateLimit int64
+
+// SetRateLimit caps the combined download rate in bytes per second
+// (0 for no limit)
+func SetRateLimit(bytesPerSec int64) {
+	rateLimit = bytesPerSec
+}
+
+// rateLimiter is a token bucket shared by all downloads with the same cap.
+// A nil *rateLimiter doesn't limit.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	burst  float64 // bucket size
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a limiter for bytesPerSec, or nil for no limit
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	// Allow up to a quarter second of traffic at once so the rate stays
+	// smooth without stalling on every read
+	burst := float64(bytesPerSec) / 4
+	return &rateLimiter{
+		rate:   float64(bytesPerSec),
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = make(map[int64]*rateLimiter)
+)
+
+// sharedRateLimiter returns the process-wide limiter for bytesPerSec, or
+// nil for no limit. Downloads running side by side (image sets, batch
+// workers, the streams of one file) all take from it, so together they
+// stay under the cap instead of each getting the full rate.
+func sharedRateLimiter(bytesPerSec int64) *rateLimiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	l, ok := limiters[bytesPerSec]
+	if !ok {
+		l = newRateLimiter(bytesPerSec)
+		limiters[bytesPerSec] = l
+	}
+	return l
+}
+
+// wait takes n bytes from the bucket, blocking until the cap allows them
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	// Go into debt and sleep it off, so reads larger than the bucket work too
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// limitedReader takes every byte read from r from a rateLimiter
+type limitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+// limitReader returns r capped by l, or r itself when l is nil
+func limitReader(ctx context.Context, r io.Reader, l *rateLimiter) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &limitedReader{ctx: ctx, r: r, limiter: l}
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	if waitErr := lr.limiter.wait(lr.ctx, n); waitErr != nil {
+		return n, waitErr
+	}
+	return n, err
+}
@@ -0,0 +1,58 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimitReader(t *testing.T) {
+	r := bytes.NewReader(nil)
+	if got := limitReader(context.Background(), r, nil); got != io.Reader(r) {
+		t.Error("a nil limiter should leave the reader as is")
+	}
+
+	// The bucket holds a quarter second, so the rest takes another quarter
+	const rate = 128 * 1024
+	data := make([]byte, rate/2)
+	start := time.Now()
+	n, err := io.Copy(io.Discard, limitReader(context.Background(), bytes.NewReader(data), newRateLimiter(rate)))
+	if err != nil || n != int64(len(data)) {
+		t.Fatalf("copied %d bytes: %v", n, err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("read %d bytes at %d B/s in %s", n, rate, elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = io.Copy(io.Discard, limitReader(ctx, bytes.NewReader(data), newRateLimiter(rate)))
+	if err != context.Canceled {
+		t.Errorf("err = %v after cancellation, want context.Canceled", err)
+	}
+}
+
+func TestSharedRateLimiterSpansDownloads(t *testing.T) {
+	if sharedRateLimiter(0) != nil {
+		t.Error("no cap should give a nil limiter")
+	}
+
+	// Two downloads at once share the rate: each gets half of it
+	const rate = 96 * 1024
+	data := make([]byte, rate/4)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			io.Copy(io.Discard, limitReader(t.Context(), bytes.NewReader(data), sharedRateLimiter(rate)))
+		})
+	}
+	wg.Wait()
+	// Half a second of traffic minus the quarter second burst
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("two downloads of %d bytes at a shared %d B/s took %s", len(data), rate, elapsed)
+	}
+}
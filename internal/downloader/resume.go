@@ -0,0 +1,137 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/guiyumin/vget/internal/s3"
+)
+
+// partState is the .vget-part sidecar kept next to a multi-stream download.
+// It records which chunks are complete so an interrupted download can
+// fetch only the missing ones. A nil *partState disables resuming.
+type partState struct {
+	Size      int64        `json:"size"`
+	ChunkSize int64        `json:"chunk_size"`
+	ETag      string       `json:"etag,omitempty"`
+	Done      map[int]bool `json:"done"`
+
+	mu   sync.Mutex
+	path string
+}
+
+// partStatePath returns the sidecar path for output
+func partStatePath(output string) string {
+	return output + ".vget-part"
+}
+
+// hasPartState reports whether output has a sidecar, i.e. is the output of
+// an unfinished multi-stream download
+func hasPartState(output string) bool {
+	_, err := os.Stat(partStatePath(output))
+	return err == nil
+}
+
+// loadPartState returns the resume state for output. A sidecar left by an
+// earlier run is reused only if the output is still there and the remote
+// file looks the same (size, chunk size and ETag); otherwise tracking
+// starts over. S3 outputs can't be resumed and os.DevNull (vget bench)
+// has nothing to resume, so both get nil.
+func loadPartState(output string, size, chunkSize int64, etag string) *partState {
+	if output == os.DevNull || s3.IsS3URL(output) {
+		return nil
+	}
+	p := &partState{
+		Size:      size,
+		ChunkSize: chunkSize,
+		ETag:      etag,
+		Done:      make(map[int]bool),
+		path:      partStatePath(output),
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return p
+	}
+	var prev partState
+	if json.Unmarshal(data, &prev) != nil || prev.Size != size || prev.ChunkSize != chunkSize || prev.ETag != etag {
+		return p
+	}
+	if info, err := os.Stat(output); err != nil || info.Size() != size {
+		return p
+	}
+	if prev.Done != nil {
+		p.Done = prev.Done
+	}
+	return p
+}
+
+// resuming reports whether any chunks are already done
+func (p *partState) resuming() bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.Done) > 0
+}
+
+// isDone reports whether chunk index was completed by an earlier run
+func (p *partState) isDone(index int) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Done[index]
+}
+
+// doneBytes returns the size of the chunks completed by an earlier run
+func (p *partState) doneBytes(chunks []chunk) int64 {
+	var n int64
+	for _, c := range chunks {
+		if p.isDone(c.index) {
+			n += c.end - c.start + 1
+		}
+	}
+	return n
+}
+
+// markDone records chunk index as complete and saves the sidecar
+func (p *partState) markDone(index int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Done[index] = true
+	if data, err := json.Marshal(p); err == nil {
+		os.WriteFile(p.path, data, 0644) // Best-effort
+	}
+}
+
+// remove deletes the sidecar once the download is complete
+func (p *partState) remove() {
+	if p != nil {
+		os.Remove(p.path)
+	}
+}
+
+// createResumableSink opens output for a multi-stream download, keeping
+// the bytes already written when resuming
+func createResumableSink(ctx context.Context, output string, size, chunkSize int64, resume *partState) (Sink, error) {
+	if resume.resuming() {
+		return os.OpenFile(output, os.O_WRONLY, 0)
+	}
+	file, err := createSink(ctx, output, size, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	// Pre-allocate file size for efficiency
+	if f, ok := file.(*os.File); ok {
+		f.Truncate(size) // Non-fatal, continue anyway
+	}
+	return file, nil
+}
@@ -0,0 +1,209 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rangeServer serves content with Range support and records the ranges requested
+func rangeServer(t *testing.T, content []byte) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ranges...)
+	}
+}
+
+func testContent(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 251)
+	}
+	return b
+}
+
+func TestMultiStreamResumeSkipsDoneChunks(t *testing.T) {
+	const chunkSize = 1024
+	content := testContent(4 * chunkSize)
+	srv, requested := rangeServer(t, content)
+
+	// An interrupted run: full-size preallocated output, chunk 0 written
+	output := filepath.Join(t.TempDir(), "file.bin")
+	partial := make([]byte, len(content))
+	copy(partial, content[:chunkSize])
+	if err := os.WriteFile(output, partial, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sidecar, _ := json.Marshal(partState{
+		Size: int64(len(content)), ChunkSize: chunkSize, ETag: `"v1"`,
+		Done: map[int]bool{0: true},
+	})
+	if err := os.WriteFile(partStatePath(output), sidecar, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultMultiStreamConfig()
+	config.Streams = 2
+	config.ChunkSize = chunkSize
+	config.PartRetries = 0
+	config.RateLimit = 0
+	state := &downloadState{startTime: time.Now()}
+	if err := MultiStreamDownload(context.Background(), srv.URL, output, config, state); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatal("resumed output differs from the remote file")
+	}
+	if hasPartState(output) {
+		t.Error("sidecar left behind after a complete download")
+	}
+	done := fmt.Sprintf("bytes=0-%d", chunkSize-1)
+	for _, r := range requested() {
+		if r == done {
+			t.Errorf("chunk 0 was downloaded again although the sidecar marks it done")
+		}
+	}
+}
+
+func TestLoadPartStateRejectsChangedRemote(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "file.bin")
+	if err := os.WriteFile(output, make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sidecar, _ := json.Marshal(partState{Size: 2048, ChunkSize: 1024, ETag: `"v1"`, Done: map[int]bool{0: true}})
+	if err := os.WriteFile(partStatePath(output), sidecar, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		size      int64
+		chunkSize int64
+		etag      string
+		resuming  bool
+	}{
+		{"same remote", 2048, 1024, `"v1"`, true},
+		{"new etag", 2048, 1024, `"v2"`, false},
+		{"new size", 4096, 1024, `"v1"`, false},
+		{"new chunk size", 2048, 512, `"v1"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := loadPartState(output, tt.size, tt.chunkSize, tt.etag)
+			if p.resuming() != tt.resuming {
+				t.Errorf("resuming = %v, want %v", p.resuming(), tt.resuming)
+			}
+		})
+	}
+}
+
+func TestShouldDownloadWithSidecar(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "file.bin")
+	if err := os.WriteFile(output, make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(partStatePath(output), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same size as the remote, but unfinished: must not be skipped
+	d := &Downloader{Existing: ExistingAuto}
+	if !d.ShouldDownload(output, 2048) {
+		t.Error("preallocated output with a sidecar was skipped as complete")
+	}
+	if !hasPartState(output) {
+		t.Error("default mode removed the sidecar it should resume from")
+	}
+
+	d.Existing = ExistingForce
+	if !d.ShouldDownload(output, 2048) {
+		t.Error("--force skipped the download")
+	}
+	if hasPartState(output) {
+		t.Error("--force kept the sidecar, so the download would resume")
+	}
+}
+
+func TestNoPartStateForDevNull(t *testing.T) {
+	if p := loadPartState(os.DevNull, 1000, 100, "v1"); p != nil {
+		t.Fatalf("got part state %+v for %s", p, os.DevNull)
+	}
+}
+
+func TestReaderDownloadResumesShortRead(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	output := filepath.Join(t.TempDir(), "file.bin")
+
+	// The first reader ends cleanly halfway, like a dropped connection
+	first := io.NopCloser(bytes.NewReader(content[:8]))
+	var offsets []int64
+	reopen := func(offset int64) (io.ReadCloser, bool, error) {
+		offsets = append(offsets, offset)
+		return io.NopCloser(bytes.NewReader(content[offset:])), true, nil
+	}
+
+	state := &downloadState{startTime: time.Now()}
+	if err := downloadFromReaderWithProgress(t.Context(), first, int64(len(content)), output, state, reopen); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(output); !bytes.Equal(got, content) {
+		t.Errorf("file = %q, want %q", got, content)
+	}
+	if fmt.Sprint(offsets) != "[8]" {
+		t.Errorf("reopened at %v, want [8]", offsets)
+	}
+}
+
+func TestReaderDownloadStopsWhileWaitingToRetry(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "file.bin")
+	ctx, cancel := context.WithCancel(t.Context())
+
+	failing := io.NopCloser(&errReader{err: errors.New("connection reset")})
+	reopen := func(int64) (io.ReadCloser, bool, error) {
+		return nil, false, errors.New("still down")
+	}
+
+	state := &downloadState{startTime: time.Now()}
+	done := make(chan error, 1)
+	go func() {
+		done <- downloadFromReaderWithProgress(ctx, failing, 100, output, state, reopen)
+	}()
+	time.Sleep(100 * time.Millisecond) // Into the first backoff
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("download kept retrying after cancel")
+	}
+}
@@ -0,0 +1,71 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/guiyumin/vget/internal/s3"
+)
+
+// Sink is the destination a download is written to. Sequential downloads use
+// Write; multi-stream downloads write chunks in parallel with WriteAt.
+// A local *os.File is the default implementation.
+type Sink interface {
+	io.Writer
+	io.WriterAt
+	io.Closer
+}
+
+// ErrEmptyDownload is returned when a download has no content. The empty
+// output file is removed so it doesn't look like a successful download.
+var ErrEmptyDownload = errors.New("download is empty (0 bytes)")
+
+// aborter is implemented by sinks that can discard a partially written output
+type aborter interface {
+	Abort() error
+}
+
+// createSink opens the destination for output. Outputs of the form
+// s3://bucket/key are streamed to S3 via multipart upload, using partSize-sized
+// parts; os.DevNull discards the data, and everything else is treated as a
+// local file path.
+func createSink(ctx context.Context, output string, size, partSize int64) (Sink, error) {
+	if output == os.DevNull {
+		return discardSink{}, nil
+	}
+	if s3.IsS3URL(output) {
+		client, err := s3.NewClientFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return s3.NewMultipartWriter(ctx, client, output, partSize, size)
+	}
+
+	return os.Create(output)
+}
+
+// closeSink finalizes the sink after a download. If the download failed,
+// sinks that support it are aborted instead of being committed.
+func closeSink(sink Sink, downloadErr error) error {
+	if downloadErr != nil {
+		if a, ok := sink.(aborter); ok {
+			a.Abort()
+			return downloadErr
+		}
+		sink.Close()
+		if f, ok := sink.(*os.File); ok && errors.Is(downloadErr, ErrEmptyDownload) {
+			os.Remove(f.Name())
+		}
+		return downloadErr
+	}
+	return sink.Close()
+}
+
+// discardSink drops everything written to it
+type discardSink struct{}
+
+func (discardSink) Write(p []byte) (int, error)            { return len(p), nil }
+func (discardSink) WriteAt(p []byte, _ int64) (int, error) { return len(p), nil }
+func (discardSink) Close() error                           { return nil }
@@ -0,0 +1,72 @@
+package downloader
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// RunUploadTUI runs upload with a progress display. upload must send the
+// data it reads from the reader it is given, which passes r through and
+// counts it against size (-1 if unknown). When r is an io.ReaderAt, so is
+// the reader, for uploads that send parts in parallel. remote is shown as
+// the destination once done. cancel must stop upload; it is called if the
+// progress display fails.
+func RunUploadTUI(r io.Reader, size int64, remote, displayName, lang string, cancel context.CancelFunc, upload func(io.Reader) error) error {
+	state := &downloadState{
+		startTime: time.Now(),
+		upload:    true,
+	}
+	state.update(0, size)
+
+	var pr io.Reader = &progressReader{r: r, total: size, state: state}
+	if _, ok := r.(io.ReaderAt); ok {
+		pr = &progressReaderAt{pr.(*progressReader)}
+	}
+
+	go func() {
+		err := upload(pr)
+		if err != nil {
+			state.setError(err)
+		} else {
+			state.setDone()
+		}
+	}()
+
+	return runProgress(remote, displayName, lang, state, cancel)
+}
+
+// progressReader reports the bytes read through it to state
+type progressReader struct {
+	r     io.Reader
+	read  atomic.Int64
+	total int64
+	state *downloadState
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.add(n)
+	return n, err
+}
+
+func (p *progressReader) add(n int) {
+	read := p.read.Add(int64(n))
+	if p.total > 0 {
+		// Parts sent again after a failure are read twice
+		read = min(read, p.total)
+	}
+	p.state.update(read, p.total)
+}
+
+// progressReaderAt is a progressReader over an io.ReaderAt
+type progressReaderAt struct {
+	*progressReader
+}
+
+func (p *progressReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	n, err := p.r.(io.ReaderAt).ReadAt(b, off)
+	p.add(n)
+	return n, err
+}
@@ -0,0 +1,100 @@
+package downloader
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// parseChecksum splits a checksum of the form "algorithm:hex" and returns
+// a hash for the algorithm (sha256, sha1 or md5)
+func parseChecksum(checksum string) (hash.Hash, string, string, error) {
+	algo, want, ok := strings.Cut(checksum, ":")
+	if !ok {
+		return nil, "", "", fmt.Errorf("invalid checksum %q (use algorithm:hex, e.g. sha256:<hex>)", checksum)
+	}
+
+	var h hash.Hash
+	switch strings.ToLower(algo) {
+	case "sha256":
+		h = sha256.New()
+	case "sha1":
+		h = sha1.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return nil, "", "", fmt.Errorf("unsupported checksum algorithm: %s", algo)
+	}
+
+	if b, err := hex.DecodeString(want); err != nil || len(b) != h.Size() {
+		return nil, "", "", fmt.Errorf("invalid %s checksum %q (expected %d hex digits)", algo, want, 2*h.Size())
+	}
+	return h, algo, want, nil
+}
+
+// ValidateChecksum checks that checksum is usable with VerifyChecksum
+func ValidateChecksum(checksum string) error {
+	_, _, _, err := parseChecksum(checksum)
+	return err
+}
+
+// VerifyChecksum checks a file against a checksum of the form
+// "algorithm:hex" (sha256, sha1 or md5). The file is hashed as it is read.
+func VerifyChecksum(path, checksum string) error {
+	h, algo, want, err := parseChecksum(checksum)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, want) {
+		return fmt.Errorf("%s checksum mismatch for %s: expected %s, got %s", algo, path, want, got)
+	}
+	return nil
+}
+
+// VerifyMediaType sniffs the start of a downloaded media file and returns
+// an error when it is actually text, such as an HTML error page saved
+// under a media extension. Files without a media extension are not checked.
+func VerifyMediaType(path string) error {
+	expected := mime.TypeByExtension(filepath.Ext(path))
+	if !strings.HasPrefix(expected, "image/") && !strings.HasPrefix(expected, "video/") && !strings.HasPrefix(expected, "audio/") {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+
+	if detected := http.DetectContentType(buf[:n]); strings.HasPrefix(detected, "text/") {
+		kind, _, _ := strings.Cut(detected, ";")
+		return fmt.Errorf("%s contains %s, not %s (probably an error page)", path, kind, expected)
+	}
+	return nil
+}
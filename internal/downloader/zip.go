@@ -0,0 +1,226 @@
+package downloader
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/guiyumin/vget/internal/httpclient"
+)
+
+// storedExtensions are already-compressed formats that are stored without deflate
+var storedExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true,
+	".mp4": true, ".mkv": true, ".webm": true, ".mov": true, ".m4v": true, ".ts": true,
+	".mp3": true, ".m4a": true, ".aac": true, ".ogg": true, ".flac": true, ".opus": true,
+	".zip": true, ".gz": true, ".7z": true, ".rar": true, ".xz": true, ".bz2": true,
+}
+
+// ZipArchive streams downloaded files directly into a zip archive
+type ZipArchive struct {
+	path   string
+	file   *os.File
+	zw     *zip.Writer
+	client *http.Client
+	names  map[string]int
+	added  int
+	failed int
+}
+
+// CreateZip creates a new zip archive at the given path
+func CreateZip(output string) (*ZipArchive, error) {
+	file, err := os.Create(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zip file: %w", err)
+	}
+	return &ZipArchive{
+		path:   output,
+		file:   file,
+		zw:     zip.NewWriter(file),
+		client: httpclient.New(0),
+		names:  make(map[string]int),
+	}, nil
+}
+
+// AddURL downloads url and streams it into the archive as name.
+// Errors are reported per entry; the archive stays usable for further entries.
+func (z *ZipArchive) AddURL(url, authHeader, name string) error {
+	return z.report(name, z.addURL(url, authHeader, name))
+}
+
+// AddReader streams the reader returned by open into the archive as name.
+// It is for sources that need more than a header to authenticate.
+func (z *ZipArchive) AddReader(name string, open func() (io.ReadCloser, int64, error)) error {
+	r, size, err := open()
+	if err != nil {
+		return z.report(name, err)
+	}
+	defer r.Close()
+	return z.report(name, z.addReader(r, name, size))
+}
+
+// report counts and prints a failed entry
+func (z *ZipArchive) report(name string, err error) error {
+	if err != nil {
+		z.failed++
+		fmt.Fprintf(os.Stderr, "  %s %s: %v\n", errStyle.Render("✗"), name, err)
+		return err
+	}
+	return nil
+}
+
+func (z *ZipArchive) addURL(url, authHeader, name string) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+
+	resp, err := z.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+
+	return z.addReader(resp.Body, name, resp.ContentLength)
+}
+
+// addReader downloads r to a temp file and, once it's complete, copies it
+// into a new archive entry. Writing the entry header first would leave a
+// truncated entry behind when the download fails halfway.
+func (z *ZipArchive) addReader(r io.Reader, name string, size int64) error {
+	spool, err := os.CreateTemp("", "vget-zip-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	start := time.Now()
+	written, err := z.spool(spool, r, name, size)
+	if err != nil {
+		return fmt.Errorf("download failed after %s: %w", formatBytes(written), err)
+	}
+	if size > 0 && written != size {
+		return fmt.Errorf("incomplete: got %s of %s", formatBytes(written), formatBytes(size))
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	name = z.uniqueName(name)
+	method := zip.Deflate
+	if storedExtensions[strings.ToLower(path.Ext(name))] {
+		method = zip.Store
+	}
+
+	w, err := z.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   method,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create zip entry: %w", err)
+	}
+	if _, err := io.Copy(w, spool); err != nil {
+		return fmt.Errorf("failed to write zip entry: %w", err)
+	}
+
+	z.added++
+	fmt.Printf("  %s %s (%s, %s)\n", doneStyle.Render("✓"), name, formatBytes(written), formatDuration(time.Since(start)))
+	return nil
+}
+
+// spool copies r to w, showing how much of name has been downloaded on
+// a terminal
+func (z *ZipArchive) spool(w io.Writer, r io.Reader, name string, size int64) (int64, error) {
+	if progressMode == ProgressNone || !isTerminal() {
+		return io.Copy(w, r)
+	}
+
+	var written atomic.Int64
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if size > 0 {
+					fmt.Printf("\r\033[K  ↓ %s (%s/%s)", name, formatBytes(written.Load()), formatBytes(size))
+				} else {
+					fmt.Printf("\r\033[K  ↓ %s (%s)", name, formatBytes(written.Load()))
+				}
+			case <-done:
+				fmt.Print("\r\033[K")
+				return
+			}
+		}
+	}()
+
+	n, err := io.Copy(w, &countingReader{r: r, n: &written})
+	close(done)
+	<-finished
+	return n, err
+}
+
+// countingReader adds the bytes read to n
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// uniqueName avoids duplicate entry names by adding a numeric suffix
+func (z *ZipArchive) uniqueName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	count := z.names[name]
+	z.names[name] = count + 1
+	if count == 0 {
+		return name
+	}
+	ext := path.Ext(name)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(name, ext), count+1, ext)
+}
+
+// Close finalizes the archive and prints a summary
+func (z *ZipArchive) Close() error {
+	if err := z.zw.Close(); err != nil {
+		z.file.Close()
+		return fmt.Errorf("failed to finalize zip: %w", err)
+	}
+	if err := z.file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n  Saved %s (%d files", z.path, z.added)
+	if z.failed > 0 {
+		fmt.Printf(", %d failed", z.failed)
+	}
+	fmt.Println(")")
+
+	if z.added == 0 && z.failed > 0 {
+		return fmt.Errorf("all %d entries failed", z.failed)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package downloader
+
+import (
+	"archive/zip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestZipArchiveSkipsFailedEntries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("complete"))
+		case "/short":
+			// The connection ends before the promised length
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte("partial"))
+		default:
+			http.Error(w, "gone", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "out.zip")
+	z, err := CreateZip(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := z.AddURL(srv.URL+"/short", "", "short.jpg"); err == nil {
+		t.Error("short download added without an error")
+	}
+	if err := z.AddURL(srv.URL+"/missing", "", "missing.jpg"); err == nil {
+		t.Error("404 added without an error")
+	}
+	if err := z.AddURL(srv.URL+"/ok", "", "ok.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.OpenReader(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if len(r.File) != 1 || r.File[0].Name != "ok.jpg" {
+		var names []string
+		for _, f := range r.File {
+			names = append(names, f.Name)
+		}
+		t.Fatalf("archive has %v, want only ok.jpg", names)
+	}
+	f, err := r.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if got, _ := io.ReadAll(f); string(got) != "complete" {
+		t.Errorf("ok.jpg = %q", got)
+	}
+}
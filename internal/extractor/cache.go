@@ -0,0 +1,121 @@
+package extractor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CacheTTL is how long an extraction result is reused
+const CacheTTL = 10 * time.Minute
+
+// cachedMedia is the on-disk form of an extracted Media
+type cachedMedia struct {
+	URL   string      `json:"url"`
+	Saved time.Time   `json:"saved"`
+	Video *VideoMedia `json:"video,omitempty"`
+	Audio *AudioMedia `json:"audio,omitempty"`
+	Image *ImageMedia `json:"image,omitempty"`
+}
+
+// CacheDir returns the directory for cached extraction results
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "vget", "extract"), nil
+}
+
+// normalizeURL makes equivalent URLs share a cache entry
+func normalizeURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	u.Fragment = ""
+	return u.String()
+}
+
+// cachePath returns the cache file for a URL
+func cachePath(rawURL string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(normalizeURL(rawURL)))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// LoadCached returns a previously extracted Media for rawURL if it is
+// younger than CacheTTL
+func LoadCached(rawURL string) (Media, bool) {
+	return LoadCachedMaxAge(rawURL, CacheTTL)
+}
+
+// LoadCachedMaxAge is like LoadCached with a custom age limit; 0 accepts
+// entries of any age
+func LoadCachedMaxAge(rawURL string, maxAge time.Duration) (Media, bool) {
+	path, err := cachePath(rawURL)
+	if err != nil {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var c cachedMedia
+	if err := json.Unmarshal(data, &c); err != nil || (maxAge > 0 && time.Since(c.Saved) > maxAge) {
+		return nil, false
+	}
+
+	switch {
+	case c.Video != nil:
+		return c.Video, true
+	case c.Audio != nil:
+		return c.Audio, true
+	case c.Image != nil:
+		return c.Image, true
+	}
+	return nil, false
+}
+
+// SaveCached stores an extracted Media for rawURL
+func SaveCached(rawURL string, m Media) error {
+	c := cachedMedia{URL: normalizeURL(rawURL), Saved: time.Now()}
+	switch v := m.(type) {
+	case *VideoMedia:
+		c.Video = v
+	case *AudioMedia:
+		c.Audio = v
+	case *ImageMedia:
+		c.Image = v
+	default:
+		return fmt.Errorf("unsupported media type")
+	}
+
+	path, err := cachePath(rawURL)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
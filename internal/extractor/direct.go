@@ -1,14 +1,73 @@
 package extractor
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
 	"time"
+
+	"github.com/guiyumin/vget/internal/httpclient"
 )
 
+// ErrNotMedia is returned when a URL without a dedicated extractor points
+// at a web page rather than a downloadable file
+var ErrNotMedia = errors.New("not a downloadable media URL")
+
+// inferExtensions controls whether extension-less URLs get an extension
+// derived from their Content-Type
+var inferExtensions = true
+
+// SetInferExtensions enables or disables extension inference from Content-Type
+func SetInferExtensions(enabled bool) {
+	inferExtensions = enabled
+}
+
+// knownExtensions maps common media types to their usual extension, where
+// mime.ExtensionsByType would be ambiguous or platform dependent
+var knownExtensions = map[string]string{
+	"video/mp4":          "mp4",
+	"video/x-matroska":   "mkv",
+	"video/webm":         "webm",
+	"video/quicktime":    "mov",
+	"video/mp2t":         "ts",
+	"audio/mpeg":         "mp3",
+	"audio/mp4":          "m4a",
+	"audio/x-m4a":        "m4a",
+	"audio/ogg":          "ogg",
+	"audio/flac":         "flac",
+	"image/jpeg":         "jpg",
+	"image/png":          "png",
+	"image/gif":          "gif",
+	"image/webp":         "webp",
+	"application/pdf":    "pdf",
+	"application/zip":    "zip",
+	"application/x-gzip": "gz",
+}
+
+// ExtensionForType returns the file extension (without dot) for a
+// Content-Type, or "" if it is unknown or inference is disabled
+func ExtensionForType(contentType string) string {
+	if !inferExtensions {
+		return ""
+	}
+	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if ct == "" || ct == "application/octet-stream" {
+		return ""
+	}
+	if ext, ok := knownExtensions[ct]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(ct); err == nil && len(exts) > 0 {
+		return strings.TrimPrefix(exts[0], ".")
+	}
+	return ""
+}
+
 // DirectExtractor handles direct file URLs (mp4, mp3, jpg, etc.)
 // This is a fallback extractor that matches any URL not handled by others
 type DirectExtractor struct {
@@ -20,6 +79,11 @@ func (d *DirectExtractor) Name() string {
 	return "direct"
 }
 
+// Example returns a sample URL this extractor handles
+func (d *DirectExtractor) Example() string {
+	return "https://example.com/video.mp4"
+}
+
 // Match always returns true - this is the fallback extractor
 func (d *DirectExtractor) Match(u *url.URL) bool {
 	// Only match http/https URLs
@@ -27,10 +91,11 @@ func (d *DirectExtractor) Match(u *url.URL) bool {
 }
 
 // Extract retrieves media information from a direct URL
-func (d *DirectExtractor) Extract(urlStr string) (Media, error) {
+func (d *DirectExtractor) Extract(ctx context.Context, urlStr string) (Media, error) {
 	if d.client == nil {
 		d.client = &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: httpclient.Wrap(httpclient.NewTransport()),
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				// Follow redirects but limit to 10
 				if len(via) >= 10 {
@@ -42,7 +107,7 @@ func (d *DirectExtractor) Extract(urlStr string) (Media, error) {
 	}
 
 	// HEAD request to get Content-Type and filename
-	req, err := http.NewRequest("HEAD", urlStr, nil)
+	req, err := http.NewRequestWithContext(ctx, "HEAD", urlStr, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -61,11 +126,17 @@ func (d *DirectExtractor) Extract(urlStr string) (Media, error) {
 	contentType := resp.Header.Get("Content-Type")
 	finalURL := resp.Request.URL.String() // URL after redirects
 
+	parsedURL, _ := url.Parse(finalURL)
+
+	// A web page with no extractor can't be downloaded directly
+	if isPageContentType(contentType) && !directDownloadExtensions[strings.ToLower(path.Ext(parsedURL.Path))] {
+		return nil, fmt.Errorf("%w: %s serves %s", ErrNotMedia, finalURL, contentType)
+	}
+
 	// Determine media type and extension
 	mediaType, ext := detectMediaType(contentType, finalURL)
 
 	// Extract filename from URL path
-	parsedURL, _ := url.Parse(finalURL)
 	filename := path.Base(parsedURL.Path)
 	if filename == "" || filename == "/" || filename == "." {
 		filename = "download"
@@ -136,11 +207,7 @@ func detectMediaType(contentType, urlStr string) (MediaType, string) {
 	switch {
 	// Video types
 	case strings.HasPrefix(contentType, "video/"):
-		ext := strings.TrimPrefix(contentType, "video/")
-		if ext == "mp4" || ext == "webm" || ext == "quicktime" {
-			if ext == "quicktime" {
-				ext = "mov"
-			}
+		if ext, ok := knownExtensions[contentType]; ok {
 			return MediaTypeVideo, ext
 		}
 		return MediaTypeVideo, "mp4"
@@ -183,7 +250,13 @@ func detectMediaType(contentType, urlStr string) (MediaType, string) {
 			}
 			return MediaTypeImage, ext
 		case "":
-			// No extension, default to binary download
+			// No extension, infer one from the Content-Type (e.g. .../media/abcd1234)
+			if !inferExtensions {
+				return MediaTypeVideo, ""
+			}
+			if ext := ExtensionForType(contentType); ext != "" {
+				return MediaTypeVideo, ext
+			}
 			return MediaTypeVideo, "bin"
 		default:
 			// Unknown extension, use it as-is
@@ -194,6 +267,12 @@ func detectMediaType(contentType, urlStr string) (MediaType, string) {
 	return MediaTypeVideo, "bin"
 }
 
+// isPageContentType reports whether a Content-Type is an HTML page
+func isPageContentType(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return ct == "text/html" || ct == "application/xhtml+xml"
+}
+
 // generateID creates a short ID from URL
 func generateID(urlStr string) string {
 	parsedURL, err := url.Parse(urlStr)
@@ -1,10 +1,15 @@
 package extractor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // MediaType represents the type of media being downloaded
@@ -16,6 +21,11 @@ const (
 	MediaTypeImage MediaType = "image"
 )
 
+// ErrMediaNotReady is returned when a post has media that isn't playable
+// yet, e.g. a just-posted video that is still processing. Retrying later
+// may succeed.
+var ErrMediaNotReady = errors.New("media is not available yet")
+
 // Media is the interface for all extracted media types
 type Media interface {
 	GetID() string
@@ -33,18 +43,77 @@ type Extractor interface {
 	// The URL is pre-parsed so extractors can reliably check the host/domain
 	Match(u *url.URL) bool
 
-	// Extract retrieves media information from the URL
-	Extract(url string) (Media, error)
+	// Extract retrieves media information from the URL, giving up when
+	// ctx is cancelled
+	Extract(ctx context.Context, url string) (Media, error)
+}
+
+// Describer is implemented by extractors that can show an example of the
+// URLs they handle
+type Describer interface {
+	Example() string
+}
+
+// Lister is implemented by extractors that can list the items of a
+// collection URL (podcast, profile) without extracting each one
+type Lister interface {
+	List(url string) ([]Entry, error)
+}
+
+// Threader is implemented by extractors that can follow a thread: the
+// chain of posts an author made in reply to themselves. Thread returns the
+// posts of the thread url belongs to that have media, oldest first.
+type Threader interface {
+	Thread(ctx context.Context, url string) ([]Entry, error)
+}
+
+// Entry is a single item found by a Lister or Threader
+type Entry struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
 }
 
 // VideoMedia represents video content with multiple format options
 type VideoMedia struct {
-	ID        string
-	Title     string
-	Uploader  string
-	Duration  int // seconds
-	Thumbnail string
-	Formats   []VideoFormat
+	ID          string
+	Title       string
+	Description string // Full post text, when the source has one
+	Uploader    string
+	Duration    int // seconds
+	Thumbnail   string
+	Width       int // original dimensions, 0 if unknown
+	Height      int
+	Formats     []VideoFormat
+	Subtitles   []Subtitle
+
+	// Videos lists each video separately when a post contains more than
+	// one, or holds the one video picked out of such a post; Formats then
+	// holds the formats of the first video
+	Videos []VideoEntry
+
+	// Published is when the post was published, zero if unknown
+	Published time.Time `json:",omitzero"`
+}
+
+// Subtitle is a caption track offered alongside a video
+type Subtitle struct {
+	Lang string // language code, e.g. "en"
+	Name string // human-readable label, if the site gives one
+	URL  string
+	Ext  string // "vtt", "srt"
+}
+
+// VideoEntry is a single video within a multi-video post
+type VideoEntry struct {
+	Duration int // seconds
+	Width    int // original dimensions, 0 if unknown
+	Height   int
+	Formats  []VideoFormat
+
+	// Number is the 1-based position in the post when the video was picked
+	// out of it, 0 otherwise
+	Number int `json:",omitempty"`
 }
 
 func (v *VideoMedia) GetID() string       { return v.ID }
@@ -52,6 +121,19 @@ func (v *VideoMedia) GetTitle() string    { return v.Title }
 func (v *VideoMedia) GetUploader() string { return v.Uploader }
 func (v *VideoMedia) Type() MediaType     { return MediaTypeVideo }
 
+// AspectRatio returns the reduced aspect ratio of the original dimensions,
+// such as "16:9", or "" if they are unknown
+func (v *VideoMedia) AspectRatio() string {
+	if v.Width <= 0 || v.Height <= 0 {
+		return ""
+	}
+	a, b := v.Width, v.Height
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return fmt.Sprintf("%d:%d", v.Width/a, v.Height/a)
+}
+
 // VideoFormat represents a single video quality option
 type VideoFormat struct {
 	URL     string
@@ -60,6 +142,11 @@ type VideoFormat struct {
 	Width   int
 	Height  int
 	Bitrate int
+
+	// AudioURL is set when the site serves audio as a separate stream,
+	// which is downloaded too and merged with the video
+	AudioURL string `json:",omitempty"`
+	AudioExt string `json:",omitempty"` // "m4a", "webm"
 }
 
 // QualityLabel returns a human-readable quality label
@@ -90,10 +177,14 @@ func (a *AudioMedia) Type() MediaType     { return MediaTypeAudio }
 
 // ImageMedia represents one or more images from a single source
 type ImageMedia struct {
-	ID       string
-	Title    string
-	Uploader string
-	Images   []Image
+	ID          string
+	Title       string
+	Description string // Full post text, when the source has one
+	Uploader    string
+	Images      []Image
+
+	// Published is when the post was published, zero if unknown
+	Published time.Time `json:",omitzero"`
 }
 
 func (i *ImageMedia) GetID() string       { return i.ID }
@@ -107,10 +198,71 @@ type Image struct {
 	Ext    string // "jpg", "png", "webp"
 	Width  int
 	Height int
+
+	// AltText is the accessibility description, if the author set one
+	AltText string `json:",omitempty"`
+
+	// Number is the 1-based position in the set when the image was picked
+	// out of it, 0 otherwise
+	Number int `json:",omitempty"`
 }
 
-// SanitizeFilename removes or replaces characters that are invalid in filenames
+// QualityLabel returns a human-readable size label
+func (img *Image) QualityLabel() string {
+	if img.Width > 0 && img.Height > 0 {
+		return fmt.Sprintf("%dx%d", img.Width, img.Height)
+	}
+	return "unknown"
+}
+
+// MediaURLs returns every downloadable URL of m in a stable order, so that
+// the same item can be found again in a later extraction of the same post
+func MediaURLs(m Media) []string {
+	var urls []string
+	switch v := m.(type) {
+	case *VideoMedia:
+		if len(v.Videos) > 0 {
+			for _, entry := range v.Videos {
+				for _, f := range entry.Formats {
+					urls = append(urls, f.URL)
+				}
+			}
+		} else {
+			for _, f := range v.Formats {
+				urls = append(urls, f.URL)
+			}
+		}
+	case *AudioMedia:
+		urls = append(urls, v.URL)
+	case *ImageMedia:
+		for _, img := range v.Images {
+			urls = append(urls, img.URL)
+		}
+	}
+	return urls
+}
+
+// maxFilenameBytes caps sanitized names well below the usual 255-byte
+// limit, leaving room for suffixes such as "_2.mp4.vget.part"
+const maxFilenameBytes = 200
+
+var (
+	filenameURLRegex   = regexp.MustCompile(`https?://[^\s]+`)
+	filenameSpaceRegex = regexp.MustCompile(`\s+`)
+
+	// windowsReservedNames can't be used as a filename on Windows, with or
+	// without an extension
+	windowsReservedNames = regexp.MustCompile(`(?i)^(CON|PRN|AUX|NUL|COM[1-9]|LPT[1-9])(\..*)?$`)
+)
+
+// SanitizeFilename turns text such as a post title into a filename that is
+// valid on Windows, macOS and Linux: path separators and reserved
+// characters are replaced, URLs and control characters removed, and the
+// result is cut to maxFilenameBytes without splitting a character
 func SanitizeFilename(name string) string {
+	// Remove URLs (http:// or https://) before their slashes are replaced
+	result := filenameURLRegex.ReplaceAllString(name, "")
+
 	// Replace characters that are problematic in filenames
 	replacer := strings.NewReplacer(
 		"/", "-",
@@ -122,31 +274,36 @@ func SanitizeFilename(name string) string {
 		"<", "",
 		">", "",
 		"|", "",
-		"\n", " ",
-		"\r", "",
 	)
-	result := replacer.Replace(name)
-
-	// Remove URLs (http:// or https://)
-	urlRegex := regexp.MustCompile(`https?://[^\s]+`)
-	result = urlRegex.ReplaceAllString(result, "")
+	result = replacer.Replace(result)
 
-	// Trim spaces and dots from ends
-	result = strings.TrimSpace(result)
-	result = strings.Trim(result, ".")
+	// Control characters (newlines, tabs, ...) become spaces
+	result = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, result)
 
 	// Collapse multiple spaces
-	spaceRegex := regexp.MustCompile(`\s+`)
-	result = spaceRegex.ReplaceAllString(result, " ")
+	result = filenameSpaceRegex.ReplaceAllString(result, " ")
 
-	// Limit length (use rune count to avoid splitting multi-byte UTF-8 characters)
-	runes := []rune(result)
-	if len(runes) > 200 {
-		result = string(runes[:200])
+	// Limit length in bytes, backing up to the start of a character
+	if len(result) > maxFilenameBytes {
+		cut := maxFilenameBytes
+		for cut > 0 && !utf8.RuneStart(result[cut]) {
+			cut--
+		}
+		result = result[:cut]
 	}
 
-	// If result is empty after sanitization, return empty
-	result = strings.TrimSpace(result)
+	// Trim spaces and dots from ends; Windows drops trailing dots
+	result = strings.Trim(result, " .")
+
+	if windowsReservedNames.MatchString(result) {
+		result = "_" + result
+	}
 
+	// May be empty; callers fall back to the media ID
 	return result
 }
@@ -0,0 +1,27 @@
+package extractor
+
+import "sort"
+
+// sortFormats orders formats best first: by bitrate, then height
+func sortFormats(formats []VideoFormat) {
+	sort.SliceStable(formats, func(i, j int) bool {
+		if formats[i].Bitrate != formats[j].Bitrate {
+			return formats[i].Bitrate > formats[j].Bitrate
+		}
+		return formats[i].Height > formats[j].Height
+	})
+}
+
+// dedupFormats removes formats with a URL already seen, keeping the first
+func dedupFormats(formats []VideoFormat) []VideoFormat {
+	seen := make(map[string]bool, len(formats))
+	result := formats[:0]
+	for _, f := range formats {
+		if seen[f.URL] {
+			continue
+		}
+		seen[f.URL] = true
+		result = append(result, f)
+	}
+	return result
+}
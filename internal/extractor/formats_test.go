@@ -0,0 +1,93 @@
+package extractor
+
+import (
+	"fmt"
+	"testing"
+)
+
+func formatURLs(formats []VideoFormat) string {
+	var urls []string
+	for _, f := range formats {
+		urls = append(urls, f.URL)
+	}
+	return fmt.Sprint(urls)
+}
+
+func TestSortFormats(t *testing.T) {
+	formats := []VideoFormat{
+		{URL: "low", Bitrate: 256000, Height: 320},
+		{URL: "no-bitrate-720", Height: 720},
+		{URL: "high", Bitrate: 2176000, Height: 1080},
+		{URL: "no-bitrate-1080", Height: 1080},
+		{URL: "mid", Bitrate: 832000, Height: 480},
+		{URL: "mid-taller", Bitrate: 832000, Height: 640},
+	}
+	sortFormats(formats)
+
+	want := "[high mid-taller mid low no-bitrate-1080 no-bitrate-720]"
+	if got := formatURLs(formats); got != want {
+		t.Errorf("sorted = %s, want %s", got, want)
+	}
+}
+
+func TestSortFormatsStable(t *testing.T) {
+	formats := []VideoFormat{{URL: "a", Bitrate: 1}, {URL: "b", Bitrate: 1}, {URL: "c", Bitrate: 1}}
+	sortFormats(formats)
+	if got := formatURLs(formats); got != "[a b c]" {
+		t.Errorf("equal formats reordered: %s", got)
+	}
+}
+
+func TestDedupFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		formats []VideoFormat
+		want    string
+	}{
+		{"empty", nil, "[]"},
+		{"no duplicates", []VideoFormat{{URL: "a"}, {URL: "b"}}, "[a b]"},
+		{"keeps the first", []VideoFormat{{URL: "a", Bitrate: 1}, {URL: "b"}, {URL: "a", Bitrate: 2}}, "[a b]"},
+		{"all the same", []VideoFormat{{URL: "a"}, {URL: "a"}, {URL: "a"}}, "[a]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := dedupFormats(tt.formats)
+			if formatURLs(got) != tt.want {
+				t.Errorf("dedupFormats = %s, want %s", formatURLs(got), tt.want)
+			}
+			if tt.name == "keeps the first" && got[0].Bitrate != 1 {
+				t.Errorf("kept the later duplicate")
+			}
+		})
+	}
+}
+
+func TestGraphQLFormatsAreDeduped(t *testing.T) {
+	body := graphQLFixture(`{
+		"type": "video",
+		"video_info": {"duration_millis": 10000, "variants": [
+			{"bitrate": 832000, "content_type": "video/mp4", "url": "https://video.twimg.com/vid/640x360/a.mp4"},
+			{"bitrate": 2176000, "content_type": "video/mp4", "url": "https://video.twimg.com/vid/1280x720/b.mp4"},
+			{"bitrate": 832000, "content_type": "video/mp4", "url": "https://video.twimg.com/vid/640x360/a.mp4"},
+			{"content_type": "application/x-mpegURL", "url": "https://video.twimg.com/pl.m3u8"}
+		]}
+	}`)
+	media, err := (&TwitterExtractor{}).parseGraphQLResponse(body, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	video := media.(*VideoMedia)
+	want := "[https://video.twimg.com/vid/1280x720/b.mp4 https://video.twimg.com/vid/640x360/a.mp4]"
+	if got := formatURLs(video.Formats); got != want {
+		t.Errorf("formats = %s, want %s", got, want)
+	}
+}
+
+func TestImageQualityLabel(t *testing.T) {
+	if got := (&Image{Width: 1920, Height: 1080}).QualityLabel(); got != "1920x1080" {
+		t.Errorf("QualityLabel() = %q, want 1920x1080", got)
+	}
+	if got := (&Image{Height: 1080}).QualityLabel(); got != "unknown" {
+		t.Errorf("QualityLabel() without width = %q, want unknown", got)
+	}
+}
@@ -0,0 +1,206 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/guiyumin/vget/internal/httpclient"
+)
+
+// GenericExtractor handles web pages without a dedicated extractor by
+// looking for a video in Open Graph / Twitter Card meta tags and <video>
+// elements. Direct file URLs are passed through to the DirectExtractor.
+type GenericExtractor struct {
+	direct *DirectExtractor
+}
+
+var (
+	metaTagRegex     = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	attrRegex        = regexp.MustCompile(`(?is)([a-z:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	videoSrcRegex    = regexp.MustCompile(`(?is)<(?:video|source)\s[^>]*\bsrc\s*=\s*(?:"([^"]+)"|'([^']+)')`)
+	trackTagRegex    = regexp.MustCompile(`(?is)<track\s[^>]*>`)
+	htmlTitleRegex   = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	genericVideoTags = []string{"og:video:secure_url", "og:video:url", "og:video", "twitter:player:stream"}
+)
+
+// maxPageSize limits how much of a page is read when scraping
+const maxPageSize = 2 << 20
+
+// Name returns the extractor name
+func (g *GenericExtractor) Name() string {
+	return "generic"
+}
+
+// Example returns a sample URL this extractor handles
+func (g *GenericExtractor) Example() string {
+	return "https://example.com/page-with-video"
+}
+
+// Match accepts any http/https URL, like the DirectExtractor
+func (g *GenericExtractor) Match(u *url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// Extract downloads direct files as-is and scrapes web pages for a video
+func (g *GenericExtractor) Extract(ctx context.Context, urlStr string) (Media, error) {
+	media, err := g.direct.Extract(ctx, urlStr)
+	if !errors.Is(err, ErrNotMedia) {
+		return media, err
+	}
+
+	media, scrapeErr := g.scrape(ctx, urlStr)
+	if scrapeErr != nil {
+		return nil, err // Report the page as not downloadable
+	}
+	return media, nil
+}
+
+// scrape fetches a page and returns the video it embeds
+func (g *GenericExtractor) scrape(ctx context.Context, pageURL string) (Media, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
+
+	resp, err := httpclient.New(30 * time.Second).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, err
+	}
+	page := string(body)
+	base := resp.Request.URL
+
+	meta := parseMetaTags(page)
+
+	var videoURL string
+	if !strings.Contains(meta["og:video:type"], "html") {
+		for _, tag := range genericVideoTags {
+			if v := meta[tag]; v != "" {
+				videoURL = v
+				break
+			}
+		}
+	}
+	if videoURL == "" {
+		if m := videoSrcRegex.FindStringSubmatch(page); m != nil {
+			videoURL = html.UnescapeString(m[1] + m[2])
+		}
+	}
+	if videoURL == "" {
+		return nil, fmt.Errorf("no video found on %s", pageURL)
+	}
+
+	resolved, err := base.Parse(videoURL)
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+		return nil, fmt.Errorf("invalid video URL on %s: %s", pageURL, videoURL)
+	}
+
+	title := meta["og:title"]
+	if title == "" {
+		if m := htmlTitleRegex.FindStringSubmatch(page); m != nil {
+			title = strings.TrimSpace(html.UnescapeString(m[1]))
+		}
+	}
+
+	var thumbnail string
+	if img := meta["og:image"]; img != "" {
+		if u, err := base.Parse(img); err == nil {
+			thumbnail = u.String()
+		}
+	}
+
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(resolved.Path), "."))
+	if ext == "" {
+		ext = "mp4"
+	}
+
+	return &VideoMedia{
+		ID:          generateID(base.String()),
+		Title:       title,
+		Description: meta["og:description"],
+		Thumbnail:   thumbnail,
+		Formats: []VideoFormat{
+			{
+				URL: resolved.String(),
+				Ext: ext,
+			},
+		},
+		Subtitles: parseTrackTags(page, base),
+	}, nil
+}
+
+// parseTrackTags returns the subtitle and caption <track> elements of a page
+func parseTrackTags(page string, base *url.URL) []Subtitle {
+	var subs []Subtitle
+	for _, tag := range trackTagRegex.FindAllString(page, -1) {
+		attrs := make(map[string]string)
+		for _, a := range attrRegex.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(a[1])] = html.UnescapeString(a[2] + a[3])
+		}
+		// kind defaults to subtitles; chapters and metadata aren't captions
+		if kind := strings.ToLower(attrs["kind"]); kind != "" && kind != "subtitles" && kind != "captions" {
+			continue
+		}
+		src, err := base.Parse(attrs["src"])
+		if attrs["src"] == "" || err != nil {
+			continue
+		}
+		lang := attrs["srclang"]
+		if lang == "" {
+			lang = "und"
+		}
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(src.Path), "."))
+		if ext == "" {
+			ext = "vtt" // The only format browsers play
+		}
+		subs = append(subs, Subtitle{Lang: lang, Name: attrs["label"], URL: src.String(), Ext: ext})
+	}
+	return subs
+}
+
+// parseMetaTags returns the content of <meta property/name=... content=...>
+// tags, keeping the first value of each
+func parseMetaTags(page string) map[string]string {
+	meta := make(map[string]string)
+	for _, tag := range metaTagRegex.FindAllString(page, -1) {
+		attrs := make(map[string]string)
+		for _, a := range attrRegex.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(a[1])] = html.UnescapeString(a[2] + a[3])
+		}
+		key := attrs["property"]
+		if key == "" {
+			key = attrs["name"]
+		}
+		key = strings.ToLower(key)
+		if key != "" && attrs["content"] != "" {
+			if _, ok := meta[key]; !ok {
+				meta[key] = attrs["content"]
+			}
+		}
+	}
+	return meta
+}
+
+func init() {
+	// Site-specific extractors still win; this only sees unmatched URLs
+	RegisterGeneric(&GenericExtractor{direct: &DirectExtractor{}})
+}
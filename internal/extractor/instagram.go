@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 )
@@ -12,12 +13,16 @@ func (e *InstagramExtractor) Name() string {
 	return "instagram"
 }
 
+func (e *InstagramExtractor) Example() string {
+	return "https://www.instagram.com/p/<id>/"
+}
+
 func (e *InstagramExtractor) Match(u *url.URL) bool {
 	// Host matching is done by registry
 	return true
 }
 
-func (e *InstagramExtractor) Extract(url string) (Media, error) {
+func (e *InstagramExtractor) Extract(_ context.Context, url string) (Media, error) {
 	return nil, fmt.Errorf("Instagram support coming soon")
 }
 
@@ -1,11 +1,15 @@
 package extractor
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"regexp"
+	"time"
+
+	"github.com/guiyumin/vget/internal/httpclient"
 )
 
 // iTunesExtractor handles Apple Podcasts downloads via iTunes API
@@ -15,6 +19,10 @@ func (e *iTunesExtractor) Name() string {
 	return "itunes"
 }
 
+func (e *iTunesExtractor) Example() string {
+	return "https://podcasts.apple.com/us/podcast/<name>/id<id>"
+}
+
 // Match URLs like:
 // https://podcasts.apple.com/podcast/id173001861
 // https://podcasts.apple.com/us/podcast/dan-carlins-hardcore-history/id173001861
@@ -26,7 +34,7 @@ func (e *iTunesExtractor) Match(u *url.URL) bool {
 	return true
 }
 
-func (e *iTunesExtractor) Extract(rawURL string) (Media, error) {
+func (e *iTunesExtractor) Extract(ctx context.Context, rawURL string) (Media, error) {
 	u, err := url.Parse(rawURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
@@ -42,18 +50,22 @@ func (e *iTunesExtractor) Extract(rawURL string) (Media, error) {
 
 	// If episode ID provided, fetch that specific episode
 	if episodeID != "" {
-		return e.extractEpisode(podcastID, episodeID)
+		return e.extractEpisode(ctx, podcastID, episodeID)
 	}
 
 	// Otherwise list episodes from the podcast
 	return e.listEpisodes()
 }
 
-func (e *iTunesExtractor) extractEpisode(podcastID, episodeID string) (*AudioMedia, error) {
+func (e *iTunesExtractor) extractEpisode(ctx context.Context, podcastID, episodeID string) (*AudioMedia, error) {
 	// Lookup episode by ID
 	url := fmt.Sprintf("https://itunes.apple.com/lookup?id=%s&entity=podcastEpisode", podcastID)
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpclient.New(30 * time.Second).Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -1,11 +1,14 @@
 package extractor
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
 	"time"
+
+	"github.com/guiyumin/vget/internal/httpclient"
 )
 
 // M3U8Extractor handles direct m3u8 playlist URLs
@@ -31,11 +34,9 @@ func (m *M3U8Extractor) Match(u *url.URL) bool {
 }
 
 // Extract retrieves media information from an m3u8 URL
-func (m *M3U8Extractor) Extract(urlStr string) (Media, error) {
+func (m *M3U8Extractor) Extract(_ context.Context, urlStr string) (Media, error) {
 	if m.client == nil {
-		m.client = &http.Client{
-			Timeout: 30 * time.Second,
-		}
+		m.client = httpclient.New(30 * time.Second)
 	}
 
 	// Parse URL to extract filename
@@ -0,0 +1,432 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/guiyumin/vget/internal/httpclient"
+)
+
+// Reddit asks API clients for a descriptive User-Agent and throttles
+// generic browser ones on its .json endpoints
+const redditUserAgent = "vget (+https://github.com/guiyumin/vget)"
+
+var redditPostIDRegex = regexp.MustCompile(`/comments/([a-z0-9]+)`)
+
+// RedditExtractor handles Reddit posts: v.redd.it videos (whose audio is a
+// separate DASH stream), images and galleries
+type RedditExtractor struct{}
+
+// Name returns the extractor name
+func (e *RedditExtractor) Name() string {
+	return "reddit"
+}
+
+// Example returns a sample URL this extractor handles
+func (e *RedditExtractor) Example() string {
+	return "https://www.reddit.com/r/<sub>/comments/<id>/"
+}
+
+// Match accepts post permalinks and redd.it / v.redd.it short links
+func (e *RedditExtractor) Match(u *url.URL) bool {
+	// Host matching is done by registry, check path pattern
+	switch strings.ToLower(u.Hostname()) {
+	case "redd.it", "v.redd.it":
+		return strings.Trim(u.Path, "/") != ""
+	}
+	return strings.Contains(u.Path, "/comments/")
+}
+
+func (e *RedditExtractor) Extract(ctx context.Context, rawURL string) (Media, error) {
+	client := httpclient.New(30 * time.Second)
+
+	postID, err := e.postID(ctx, client, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := e.fetchPost(ctx, client, postID)
+	if err != nil {
+		return nil, err
+	}
+	// A crosspost carries the media of the original post
+	if len(post.CrosspostParentList) > 0 {
+		post = &post.CrosspostParentList[0]
+	}
+
+	title := truncateText(post.Title, 100)
+	if post.IsGallery {
+		return e.galleryMedia(post, title)
+	}
+	if video := post.redditVideo(); video != nil {
+		return e.videoMedia(ctx, client, post, video, title)
+	}
+	if post.PostHint == "image" || strings.Contains(post.URL, "i.redd.it/") {
+		return e.imageMedia(post, title), nil
+	}
+
+	if post.URL != "" && !strings.Contains(post.URL, "/comments/") {
+		return nil, fmt.Errorf("post links to %s, which isn't hosted on Reddit; download that URL instead", post.URL)
+	}
+	return nil, fmt.Errorf("post has no video or images")
+}
+
+// postID returns the ID of the post rawURL points to. v.redd.it links are
+// followed to the post they redirect to.
+func (e *RedditExtractor) postID(ctx context.Context, client *http.Client, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	switch strings.ToLower(u.Hostname()) {
+	case "redd.it":
+		return strings.Trim(u.Path, "/"), nil
+	case "v.redd.it":
+		req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("User-Agent", redditUserAgent)
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", rawURL, err)
+		}
+		resp.Body.Close()
+		u = resp.Request.URL
+	}
+
+	matches := redditPostIDRegex.FindStringSubmatch(u.Path)
+	if len(matches) < 2 {
+		return "", fmt.Errorf("could not extract post ID from URL")
+	}
+	return matches[1], nil
+}
+
+// fetchPost fetches a post from the .json API
+func (e *RedditExtractor) fetchPost(ctx context.Context, client *http.Client, postID string) (*redditPost, error) {
+	// raw_json=1 returns URLs without HTML escaping (&amp;)
+	reqURL := "https://www.reddit.com/comments/" + postID + ".json?raw_json=1"
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", redditUserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("post %s not found", postID)
+	case http.StatusForbidden:
+		return nil, fmt.Errorf("post %s is private or quarantined", postID)
+	default:
+		return nil, fmt.Errorf("reddit API returned status %d", resp.StatusCode)
+	}
+
+	// The response is the post listing followed by the comments listing
+	var listings []struct {
+		Data struct {
+			Children []struct {
+				Data redditPost `json:"data"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listings); err != nil {
+		return nil, fmt.Errorf("failed to parse post JSON: %w", err)
+	}
+	if len(listings) == 0 || len(listings[0].Data.Children) == 0 {
+		return nil, fmt.Errorf("post %s not found", postID)
+	}
+	return &listings[0].Data.Children[0].Data, nil
+}
+
+// videoMedia builds a VideoMedia from a Reddit-hosted video. The formats
+// come from its DASH manifest, with the audio stream set as AudioURL so it
+// is merged in; if the manifest can't be read the fallback URL (video
+// only) is used.
+func (e *RedditExtractor) videoMedia(ctx context.Context, client *http.Client, post *redditPost, video *redditVideo, title string) (Media, error) {
+	var formats []VideoFormat
+	var audioURL string
+	if video.DashURL != "" {
+		formats, audioURL, _ = fetchDASHFormats(ctx, client, video.DashURL)
+	}
+	if len(formats) == 0 {
+		if video.FallbackURL == "" {
+			return nil, fmt.Errorf("no video URL found in post")
+		}
+		formats = []VideoFormat{{
+			URL:     video.FallbackURL,
+			Ext:     "mp4",
+			Width:   video.Width,
+			Height:  video.Height,
+			Bitrate: video.BitrateKbps * 1000,
+		}}
+	}
+
+	// GIF uploads and videos flagged silent have no audio stream
+	hasAudio := !video.IsGIF && (video.HasAudio == nil || *video.HasAudio)
+	if audioURL != "" && hasAudio {
+		for i := range formats {
+			formats[i].AudioURL = audioURL
+			formats[i].AudioExt = "m4a"
+		}
+	}
+	sortFormats(formats)
+
+	return &VideoMedia{
+		ID:          post.ID,
+		Title:       title,
+		Description: post.Selftext,
+		Uploader:    post.Author,
+		Duration:    video.Duration,
+		Width:       video.Width,
+		Height:      video.Height,
+		Formats:     formats,
+	}, nil
+}
+
+// galleryMedia builds an ImageMedia from a gallery post, in gallery order
+func (e *RedditExtractor) galleryMedia(post *redditPost, title string) (Media, error) {
+	var images []Image
+	for _, item := range post.GalleryData.Items {
+		meta, ok := post.MediaMetadata[item.MediaID]
+		if !ok || meta.Status != "valid" {
+			continue
+		}
+
+		img := Image{
+			URL:     meta.Source.URL,
+			Ext:     strings.TrimPrefix(meta.Mime, "image/"),
+			Width:   meta.Source.Width,
+			Height:  meta.Source.Height,
+			AltText: item.Caption,
+		}
+		if meta.Kind == "AnimatedImage" && meta.Source.GIF != "" {
+			img.URL, img.Ext = meta.Source.GIF, "gif"
+		}
+		if img.Ext == "jpeg" {
+			img.Ext = "jpg"
+		}
+		if img.URL != "" {
+			images = append(images, img)
+		}
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("gallery has no images")
+	}
+
+	return &ImageMedia{
+		ID:          post.ID,
+		Title:       title,
+		Description: post.Selftext,
+		Uploader:    post.Author,
+		Images:      images,
+	}, nil
+}
+
+// imageMedia builds an ImageMedia from a single-image post
+func (e *RedditExtractor) imageMedia(post *redditPost, title string) Media {
+	img := Image{
+		URL: post.URL,
+		Ext: getImageExtension(post.URL),
+	}
+	if len(post.Preview.Images) > 0 {
+		img.Width = post.Preview.Images[0].Source.Width
+		img.Height = post.Preview.Images[0].Source.Height
+	}
+
+	return &ImageMedia{
+		ID:          post.ID,
+		Title:       title,
+		Description: post.Selftext,
+		Uploader:    post.Author,
+		Images:      []Image{img},
+	}
+}
+
+// fetchDASHFormats reads a DASH manifest and returns one format per video
+// representation plus the URL of the best audio representation, if any
+func fetchDASHFormats(ctx context.Context, client *http.Client, manifestURL string) ([]VideoFormat, string, error) {
+	base, err := url.Parse(manifestURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", manifestURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("DASH manifest returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	var mpd dashManifest
+	if err := xml.Unmarshal(body, &mpd); err != nil {
+		return nil, "", fmt.Errorf("failed to parse DASH manifest: %w", err)
+	}
+
+	var formats []VideoFormat
+	var audioURL string
+	var audioBandwidth int
+	for _, period := range mpd.Periods {
+		for _, set := range period.AdaptationSets {
+			for _, rep := range set.Representations {
+				ref, err := url.Parse(strings.TrimSpace(rep.BaseURL))
+				if err != nil || rep.BaseURL == "" {
+					continue
+				}
+				repURL := base.ResolveReference(ref).String()
+
+				switch set.kind(rep.MimeType) {
+				case "video":
+					formats = append(formats, VideoFormat{
+						URL:     repURL,
+						Ext:     strings.TrimPrefix(path.Ext(ref.Path), "."),
+						Width:   rep.Width,
+						Height:  rep.Height,
+						Bitrate: rep.Bandwidth,
+					})
+				case "audio":
+					if rep.Bandwidth >= audioBandwidth {
+						audioURL, audioBandwidth = repURL, rep.Bandwidth
+					}
+				}
+			}
+		}
+	}
+	return formats, audioURL, nil
+}
+
+// Reddit API types
+
+type redditPost struct {
+	ID          string       `json:"id"`
+	Title       string       `json:"title"`
+	Selftext    string       `json:"selftext"`
+	Author      string       `json:"author"`
+	URL         string       `json:"url"`
+	PostHint    string       `json:"post_hint"`
+	IsGallery   bool         `json:"is_gallery"`
+	SecureMedia *redditMedia `json:"secure_media"`
+	Media       *redditMedia `json:"media"`
+	Preview     struct {
+		Images []struct {
+			Source struct {
+				Width  int `json:"width"`
+				Height int `json:"height"`
+			} `json:"source"`
+		} `json:"images"`
+	} `json:"preview"`
+	MediaMetadata map[string]redditMediaItem `json:"media_metadata"`
+	GalleryData   struct {
+		Items []struct {
+			MediaID string `json:"media_id"`
+			Caption string `json:"caption"`
+		} `json:"items"`
+	} `json:"gallery_data"`
+	CrosspostParentList []redditPost `json:"crosspost_parent_list"`
+}
+
+// redditVideo returns the post's Reddit-hosted video, if it has one
+func (p *redditPost) redditVideo() *redditVideo {
+	for _, m := range []*redditMedia{p.SecureMedia, p.Media} {
+		if m != nil && m.RedditVideo != nil {
+			return m.RedditVideo
+		}
+	}
+	return nil
+}
+
+type redditMedia struct {
+	RedditVideo *redditVideo `json:"reddit_video"`
+}
+
+type redditVideo struct {
+	FallbackURL string `json:"fallback_url"`
+	DashURL     string `json:"dash_url"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Duration    int    `json:"duration"`
+	BitrateKbps int    `json:"bitrate_kbps"`
+	IsGIF       bool   `json:"is_gif"`
+	HasAudio    *bool  `json:"has_audio"` // missing on older posts
+}
+
+type redditMediaItem struct {
+	Status string `json:"status"`
+	Kind   string `json:"e"` // "Image" or "AnimatedImage"
+	Mime   string `json:"m"` // "image/jpg", "image/png"
+	Source struct {
+		URL    string `json:"u"`
+		GIF    string `json:"gif"`
+		Width  int    `json:"x"`
+		Height int    `json:"y"`
+	} `json:"s"`
+}
+
+// dashManifest is the part of a DASH MPD needed to find the streams
+type dashManifest struct {
+	Periods []struct {
+		AdaptationSets []dashAdaptationSet `xml:"AdaptationSet"`
+	} `xml:"Period"`
+}
+
+type dashAdaptationSet struct {
+	ContentType     string `xml:"contentType,attr"`
+	MimeType        string `xml:"mimeType,attr"`
+	Representations []struct {
+		Bandwidth int    `xml:"bandwidth,attr"`
+		Width     int    `xml:"width,attr"`
+		Height    int    `xml:"height,attr"`
+		MimeType  string `xml:"mimeType,attr"`
+		BaseURL   string `xml:"BaseURL"`
+	} `xml:"Representation"`
+}
+
+// kind returns "video" or "audio" for a representation of the set, going
+// by the set's contentType or else the MIME type
+func (s dashAdaptationSet) kind(repMime string) string {
+	if s.ContentType != "" {
+		return s.ContentType
+	}
+	mime := repMime
+	if mime == "" {
+		mime = s.MimeType
+	}
+	kind, _, _ := strings.Cut(mime, "/")
+	return kind
+}
+
+func init() {
+	Register(&RedditExtractor{},
+		"reddit.com",
+		"old.reddit.com",
+		"redd.it",
+		"v.redd.it",
+	)
+}
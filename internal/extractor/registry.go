@@ -3,13 +3,25 @@ package extractor
 import (
 	"net/url"
 	"path"
+	"slices"
+	"sort"
 	"strings"
 )
 
-// extractorsByHost maps hostnames to their extractors
-var extractorsByHost = map[string]Extractor{}
+// extractorsByHost maps hostnames to their extractors, in registration order
+var extractorsByHost = map[string][]Extractor{}
 
-// fallbackExtractor handles direct file URLs and unknown hosts
+// extractorOrder lists extractor names to try first, highest priority first
+var extractorOrder []string
+
+// disabledExtractors holds the names of extractors Match must skip
+var disabledExtractors = map[string]bool{}
+
+// genericExtractor handles unknown hosts unless it is disabled
+var genericExtractor Extractor
+
+// fallbackExtractor handles direct file URLs, and unknown hosts when there
+// is no generic extractor. It can't be disabled.
 var fallbackExtractor Extractor
 
 // directDownloadExtensions are file extensions that bypass host-based extractors
@@ -33,10 +45,11 @@ var directDownloadExtensions = map[string]bool{
 	".rar": true, ".7z": true, ".dmg": true, ".iso": true,
 }
 
-// Register adds an extractor for the given hostnames
+// Register adds an extractor for the given hostnames. Several extractors may
+// share a host; they are tried in registration order unless SetOrder says otherwise.
 func Register(e Extractor, hosts ...string) {
 	for _, host := range hosts {
-		extractorsByHost[host] = e
+		extractorsByHost[host] = append(extractorsByHost[host], e)
 	}
 }
 
@@ -45,6 +58,25 @@ func RegisterFallback(e Extractor) {
 	fallbackExtractor = e
 }
 
+// RegisterGeneric sets the extractor tried for unknown hosts before the fallback
+func RegisterGeneric(e Extractor) {
+	genericExtractor = e
+}
+
+// SetOrder gives the named extractors priority, in the given order, over
+// others matching the same host
+func SetOrder(names []string) {
+	extractorOrder = names
+}
+
+// Disable makes Match skip the named extractors. The fallback for direct
+// file URLs is always used.
+func Disable(names ...string) {
+	for _, name := range names {
+		disabledExtractors[name] = true
+	}
+}
+
 // Match finds the extractor for a URL using O(1) hostname lookup
 func Match(rawURL string) Extractor {
 	u, err := url.Parse(rawURL)
@@ -61,36 +93,88 @@ func Match(rawURL string) Extractor {
 	// Lookup by hostname
 	host := strings.ToLower(u.Hostname())
 
-	// Try exact match
-	if e, ok := extractorsByHost[host]; ok {
-		// Also check path pattern via Match() (e.g., /status/ for Twitter)
-		if e.Match(u) {
-			return e
-		}
-	}
-
-	// Try without www. prefix
+	// Try exact match, then without www. prefix
+	hosts := []string{host}
 	if strings.HasPrefix(host, "www.") {
-		if e, ok := extractorsByHost[host[4:]]; ok {
-			if e.Match(u) {
+		hosts = append(hosts, host[4:])
+	}
+	for _, h := range hosts {
+		for _, e := range ordered(extractorsByHost[h]) {
+			// Also check path pattern via Match() (e.g., /status/ for Twitter)
+			if !disabledExtractors[e.Name()] && e.Match(u) {
 				return e
 			}
 		}
 	}
 
 	// Fallback for unknown hosts or unmatched paths
+	return fallback()
+}
+
+// fallback returns the extractor for unknown hosts: the generic one unless
+// it is disabled, otherwise the fallback
+func fallback() Extractor {
+	if genericExtractor != nil && !disabledExtractors[genericExtractor.Name()] {
+		return genericExtractor
+	}
 	return fallbackExtractor
 }
 
+// ordered sorts extractors by their position in extractorOrder, keeping
+// registration order for the rest
+func ordered(extractors []Extractor) []Extractor {
+	if len(extractors) < 2 || len(extractorOrder) == 0 {
+		return extractors
+	}
+	rank := func(e Extractor) int {
+		if i := slices.Index(extractorOrder, e.Name()); i >= 0 {
+			return i
+		}
+		return len(extractorOrder)
+	}
+	result := slices.Clone(extractors)
+	sort.SliceStable(result, func(i, j int) bool {
+		return rank(result[i]) < rank(result[j])
+	})
+	return result
+}
+
 // List returns all unique registered extractors
 func List() []Extractor {
 	seen := make(map[string]bool)
 	var result []Extractor
-	for _, e := range extractorsByHost {
-		if !seen[e.Name()] {
-			seen[e.Name()] = true
-			result = append(result, e)
+	for _, extractors := range extractorsByHost {
+		for _, e := range extractors {
+			if !seen[e.Name()] {
+				seen[e.Name()] = true
+				result = append(result, e)
+			}
 		}
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+	if genericExtractor != nil {
+		result = append(result, genericExtractor)
+	}
+	if fallbackExtractor != nil {
+		result = append(result, fallbackExtractor)
+	}
 	return result
 }
+
+// Hosts returns the hostnames e is registered for, sorted. It is empty
+// for the generic and fallback extractors.
+func Hosts(e Extractor) []string {
+	var hosts []string
+	for host, extractors := range extractorsByHost {
+		for _, other := range extractors {
+			if other.Name() == e.Name() {
+				hosts = append(hosts, host)
+				break
+			}
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
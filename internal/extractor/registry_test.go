@@ -0,0 +1,36 @@
+package extractor
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestMatchFallbackCantBeDisabled(t *testing.T) {
+	saved := maps.Clone(disabledExtractors)
+	t.Cleanup(func() { disabledExtractors = saved })
+
+	if e := Match("https://unknown.example/page"); e == nil || e.Name() != "generic" {
+		t.Fatalf("unknown host matched %v, want generic", e)
+	}
+
+	Disable("generic", "direct")
+	tests := []string{
+		"https://unknown.example/page",
+		"https://unknown.example/file.mp4",
+	}
+	for _, rawURL := range tests {
+		if e := Match(rawURL); e == nil || e.Name() != "direct" {
+			t.Errorf("Match(%q) = %v with generic disabled, want direct", rawURL, e)
+		}
+	}
+}
+
+func TestListEndsWithFallbacks(t *testing.T) {
+	list := List()
+	if len(list) < 2 {
+		t.Fatalf("List() returned %d extractors", len(list))
+	}
+	if a, b := list[len(list)-2].Name(), list[len(list)-1].Name(); a != "generic" || b != "direct" {
+		t.Errorf("List() ends with %s, %s; want generic, direct", a, b)
+	}
+}
@@ -0,0 +1,51 @@
+package extractor
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "My Video", "My Video"},
+		{"reserved CON", "CON", "_CON"},
+		{"reserved lowercase nul", "nul", "_nul"},
+		{"reserved COM1 with extension", "COM1.mp4", "_COM1.mp4"},
+		{"reserved LPT9", "LPT9", "_LPT9"},
+		{"not reserved COM0", "COM0", "COM0"},
+		{"reserved name as a prefix", "CONFIG", "CONFIG"},
+		{"trailing dots", "title...", "title"},
+		{"trailing spaces", "title   ", "title"},
+		{"trailing dots and spaces", "title . .", "title"},
+		{"reserved after trimming", "NUL. ", "_NUL"},
+		{"leading dots", "..hidden", "hidden"},
+		{"path separators", "a/b\\c:d", "a-b-c-d"},
+		{"forbidden characters", `what?*"<x>|`, "whatx"},
+		{"control characters", "line1\nline2\ttab", "line1 line2 tab"},
+		{"collapsed spaces", "a    b", "a b"},
+		{"URL removed", "watch https://example.com/x now", "watch now"},
+		{"only dots", "...", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeFilename(tt.in); got != tt.want {
+				t.Errorf("SanitizeFilename(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilenameLength(t *testing.T) {
+	got := SanitizeFilename(strings.Repeat("日本", 100))
+	if len(got) > maxFilenameBytes {
+		t.Errorf("got %d bytes, want at most %d", len(got), maxFilenameBytes)
+	}
+	if !utf8.ValidString(got) {
+		t.Error("truncation split a character")
+	}
+}
@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 )
@@ -12,12 +13,16 @@ func (e *TikTokExtractor) Name() string {
 	return "tiktok"
 }
 
+func (e *TikTokExtractor) Example() string {
+	return "https://www.tiktok.com/@<user>/video/<id>"
+}
+
 func (e *TikTokExtractor) Match(u *url.URL) bool {
 	// Host matching is done by registry
 	return true
 }
 
-func (e *TikTokExtractor) Extract(url string) (Media, error) {
+func (e *TikTokExtractor) Extract(_ context.Context, url string) (Media, error) {
 	return nil, fmt.Errorf("TikTok support coming soon")
 }
 
@@ -1,43 +1,208 @@
 package extractor
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/guiyumin/vget/internal/httpclient"
 )
 
 const (
-	// Public bearer token (same as used by web client)
+	// Public bearer token (same as used by web client), unless replaced
+	// with SetTwitterBearerToken
 	twitterBearerToken = "AAAAAAAAAAAAAAAAAAAAANRILgAAAAAAnNwIzUejRCOuH5E6I8xnZz4puTs=1Zv7ttfk8LF81IUq16cHjhLTvJu4FA33AGWWjCpTnA"
 
 	twitterGuestTokenURL  = "https://api.x.com/1.1/guest/activate.json"
 	twitterGraphQLURL     = "https://x.com/i/api/graphql/NmCeCgkVlsRGS1cAwqtgmw/TweetResultByRestId"
+	twitterTweetDetailURL = "https://x.com/i/api/graphql/nBS-WpgA6ZG0CyNHD517JQ/TweetDetail"
 	twitterSyndicationURL = "https://cdn.syndication.twimg.com/tweet-result"
+
+	// twitterGuestTokenTTL is how long a guest token is reused from disk.
+	// Twitter keeps them valid for a few hours.
+	twitterGuestTokenTTL = 2 * time.Hour
 )
 
+// twitterBearerOverride replaces twitterBearerToken when set
+var twitterBearerOverride string
+
+// SetTwitterBearerToken replaces the built-in bearer token of the Twitter
+// extractor, for when Twitter rotates it. An empty token restores it.
+func SetTwitterBearerToken(token string) {
+	twitterBearerOverride = token
+}
+
+// bearerToken returns the bearer token to authenticate with
+func bearerToken() string {
+	if twitterBearerOverride != "" {
+		return twitterBearerOverride
+	}
+	return twitterBearerToken
+}
+
 var (
 	// Matches twitter.com and x.com URLs with status
 	twitterURLRegex = regexp.MustCompile(`(?:twitter\.com|x\.com)/(?:[^/]+)/status/(\d+)`)
 )
 
-// TwitterExtractor handles Twitter/X media extraction
+// twitterGraphQLFeatures are the feature flags the web client sends with
+// every GraphQL query
+var twitterGraphQLFeatures = map[string]interface{}{
+	"creator_subscriptions_tweet_preview_api_enabled":                         true,
+	"communities_web_enable_tweet_community_results_fetch":                    true,
+	"c9s_tweet_anatomy_moderator_badge_enabled":                               true,
+	"articles_preview_enabled":                                                true,
+	"responsive_web_edit_tweet_api_enabled":                                   true,
+	"graphql_is_translatable_rweb_tweet_is_translatable_enabled":              true,
+	"view_counts_everywhere_api_enabled":                                      true,
+	"longform_notetweets_consumption_enabled":                                 true,
+	"responsive_web_twitter_article_tweet_consumption_enabled":                true,
+	"tweet_awards_web_tipping_enabled":                                        false,
+	"creator_subscriptions_quote_tweet_preview_enabled":                       false,
+	"freedom_of_speech_not_reach_fetch_enabled":                               true,
+	"standardized_nudges_misinfo":                                             true,
+	"tweet_with_visibility_results_prefer_gql_limited_actions_policy_enabled": true,
+	"rweb_video_timestamps_enabled":                                           true,
+	"longform_notetweets_rich_text_read_enabled":                              true,
+	"longform_notetweets_inline_media_enabled":                                true,
+	"rweb_tipjar_consumption_enabled":                                         true,
+	"responsive_web_graphql_exclude_directive_enabled":                        true,
+	"verified_phone_label_enabled":                                            false,
+	"responsive_web_graphql_skip_user_profile_image_extensions_enabled":       false,
+	"responsive_web_graphql_timeline_navigation_enabled":                      true,
+	"responsive_web_enhance_cards_enabled":                                    false,
+}
+
+// TwitterExtractor handles Twitter/X media extraction. The zero value is
+// ready to use and creates a default HTTP client on first use. It is safe
+// for concurrent use.
 type TwitterExtractor struct {
+	callTimeout time.Duration
+
+	mu         sync.Mutex // guards client and guestToken
 	client     *http.Client
 	guestToken string
 }
 
+// TwitterOptions configures a TwitterExtractor
+type TwitterOptions struct {
+	// Client is used for all requests; nil means a default client built
+	// from the global httpclient settings
+	Client *http.Client
+	// CallTimeout bounds each API request, including the guest token;
+	// 0 means the one set with SetTwitterCallTimeout, or
+	// defaultTwitterCallTimeout
+	CallTimeout time.Duration
+}
+
+// defaultTwitterCallTimeout bounds a single Twitter API request so a
+// stalled endpoint fails the extraction instead of hanging it
+const defaultTwitterCallTimeout = 20 * time.Second
+
+// twitterCallTimeout replaces defaultTwitterCallTimeout when set
+var twitterCallTimeout time.Duration
+
+// SetTwitterCallTimeout sets how long a single Twitter API request may take
+// for extractors without their own CallTimeout. 0 restores the default.
+func SetTwitterCallTimeout(d time.Duration) {
+	twitterCallTimeout = d
+}
+
+// NewTwitterExtractor creates a TwitterExtractor with the given options
+func NewTwitterExtractor(opts TwitterOptions) *TwitterExtractor {
+	return &TwitterExtractor{client: opts.Client, callTimeout: opts.CallTimeout}
+}
+
+// httpClient returns the client for API requests, creating the default
+// one on first use
+func (t *TwitterExtractor) httpClient() *http.Client {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client == nil {
+		t.client = httpclient.New(30 * time.Second)
+	}
+	return t.client
+}
+
+// token returns the current guest token, or "" if there is none yet
+func (t *TwitterExtractor) token() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.guestToken
+}
+
+// setToken replaces the guest token
+func (t *TwitterExtractor) setToken(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.guestToken = token
+}
+
+// sessionCSRF returns the CSRF token (ct0 cookie) of the logged-in session
+// the cookie jar holds for endpoint (--cookies-from-browser), or "" when
+// requests go out as a guest
+func (t *TwitterExtractor) sessionCSRF(endpoint string) string {
+	jar := t.httpClient().Jar
+	u, err := url.Parse(endpoint)
+	if jar == nil || err != nil {
+		return ""
+	}
+	for _, c := range jar.Cookies(u) {
+		if c.Name == "ct0" && c.Value != "" {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+// timeout returns the per-request timeout
+func (t *TwitterExtractor) timeout() time.Duration {
+	switch {
+	case t.callTimeout > 0:
+		return t.callTimeout
+	case twitterCallTimeout > 0:
+		return twitterCallTimeout
+	}
+	return defaultTwitterCallTimeout
+}
+
+// callContext derives the context for a single API request from ctx
+func (t *TwitterExtractor) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, t.timeout())
+}
+
+// callError reports err from a request made with callCtx, naming the API
+// when the per-call timeout rather than the caller's ctx expired it
+func (t *TwitterExtractor) callError(ctx, callCtx context.Context, api string, err error) error {
+	if ctx.Err() == nil && callCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s request timed out after %s", api, t.timeout())
+	}
+	return err
+}
+
 // Name returns the extractor name
 func (t *TwitterExtractor) Name() string {
 	return "twitter"
 }
 
+// Example returns a sample URL this extractor handles
+func (t *TwitterExtractor) Example() string {
+	return "https://x.com/<user>/status/<id>"
+}
+
 // Match checks if URL is a Twitter/X status URL
 func (t *TwitterExtractor) Match(u *url.URL) bool {
 	// Host matching is done by registry, check path pattern
@@ -45,14 +210,7 @@ func (t *TwitterExtractor) Match(u *url.URL) bool {
 }
 
 // Extract retrieves media from a Twitter/X URL
-func (t *TwitterExtractor) Extract(urlStr string) (Media, error) {
-	// Initialize HTTP client
-	if t.client == nil {
-		t.client = &http.Client{
-			Timeout: 30 * time.Second,
-		}
-	}
-
+func (t *TwitterExtractor) Extract(ctx context.Context, urlStr string) (Media, error) {
 	// Extract tweet ID from URL
 	matches := twitterURLRegex.FindStringSubmatch(urlStr)
 	if len(matches) < 2 {
@@ -61,17 +219,14 @@ func (t *TwitterExtractor) Extract(urlStr string) (Media, error) {
 	tweetID := matches[1]
 
 	// Try syndication API first (simpler, no auth needed for public tweets)
-	media, err := t.fetchFromSyndication(tweetID)
-	if err == nil {
-		return media, nil
-	}
-
-	// Fallback to GraphQL API
-	if err := t.fetchGuestToken(); err != nil {
-		return nil, fmt.Errorf("failed to get guest token: %w", err)
+	media, err := t.fetchFromSyndicationWithRetry(ctx, tweetID)
+	if err == nil || errors.Is(err, ErrMediaNotReady) {
+		return media, err
 	}
 
-	media, err = t.fetchFromGraphQL(tweetID)
+	// Syndication doesn't serve every tweet (404) or may refuse it (403);
+	// anything else that went wrong is worth a GraphQL attempt as well
+	media, err = t.fetchFromGraphQLWithToken(ctx, tweetID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch tweet: %w", err)
 	}
@@ -79,15 +234,102 @@ func (t *TwitterExtractor) Extract(urlStr string) (Media, error) {
 	return media, nil
 }
 
+// twitterAPIError is a non-200 response from one of the Twitter endpoints
+type twitterAPIError struct {
+	api    string
+	status int
+	body   string
+}
+
+func (e *twitterAPIError) Error() string {
+	return fmt.Sprintf("%s request failed with status %d: %s", e.api, e.status, e.body)
+}
+
+// apiStatus returns the HTTP status of a twitterAPIError, or 0
+func apiStatus(err error) int {
+	var apiErr *twitterAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.status
+	}
+	return 0
+}
+
+// twitterRateLimitRetries is how often a rate-limited syndication request is retried
+const twitterRateLimitRetries = 2
+
+// twitterRateLimitBackoff is the wait before the first syndication retry;
+// each further retry waits one more multiple of it
+var twitterRateLimitBackoff = 2 * time.Second
+
+// fetchFromSyndicationWithRetry calls the syndication API, backing off and
+// retrying when it is rate limited (429)
+func (t *TwitterExtractor) fetchFromSyndicationWithRetry(ctx context.Context, tweetID string) (Media, error) {
+	for attempt := 0; ; attempt++ {
+		media, err := t.fetchFromSyndication(ctx, tweetID)
+		if apiStatus(err) != http.StatusTooManyRequests || attempt == twitterRateLimitRetries {
+			return media, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * twitterRateLimitBackoff):
+		}
+	}
+}
+
+// fetchFromGraphQLWithToken looks the tweet up with the GraphQL API
+func (t *TwitterExtractor) fetchFromGraphQLWithToken(ctx context.Context, tweetID string) (Media, error) {
+	variables := map[string]interface{}{
+		"tweetId":                tweetID,
+		"withCommunity":          false,
+		"includePromotedContent": false,
+		"withVoice":              false,
+	}
+	body, err := t.graphQLWithToken(ctx, twitterGraphQLURL, variables)
+	if err != nil {
+		return nil, err
+	}
+	return t.parseGraphQLResponse(body, tweetID)
+}
+
+// graphQLWithToken calls a GraphQL endpoint with a guest token, getting a
+// fresh token and retrying once if the current one is rejected. A
+// logged-in session authenticates with its cookies instead.
+func (t *TwitterExtractor) graphQLWithToken(ctx context.Context, endpoint string, variables map[string]interface{}) ([]byte, error) {
+	if t.sessionCSRF(endpoint) != "" {
+		return t.fetchGraphQL(ctx, endpoint, variables)
+	}
+	if t.token() == "" {
+		if err := t.fetchGuestToken(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get guest token: %w", err)
+		}
+	}
+
+	body, err := t.fetchGraphQL(ctx, endpoint, variables)
+	switch apiStatus(err) {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		// Guest tokens expire and are rate limited individually
+		removeCachedGuestToken()
+		if err := t.fetchGuestToken(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get guest token: %w", err)
+		}
+		return t.fetchGraphQL(ctx, endpoint, variables)
+	}
+	return body, err
+}
+
 // fetchFromSyndication tries the syndication endpoint (works for public tweets)
-func (t *TwitterExtractor) fetchFromSyndication(tweetID string) (Media, error) {
+func (t *TwitterExtractor) fetchFromSyndication(ctx context.Context, tweetID string) (Media, error) {
 	params := url.Values{}
 	params.Set("id", tweetID)
 	params.Set("token", "x") // Required but value doesn't matter
 
 	reqURL := twitterSyndicationURL + "?" + params.Encode()
 
-	req, err := http.NewRequest("GET", reqURL, nil)
+	callCtx, cancel := t.callContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(callCtx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -95,37 +337,46 @@ func (t *TwitterExtractor) fetchFromSyndication(tweetID string) (Media, error) {
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := t.client.Do(req)
+	resp, err := t.httpClient().Do(req)
 	if err != nil {
-		return nil, err
+		return nil, t.callError(ctx, callCtx, "syndication", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("syndication request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, &twitterAPIError{api: "syndication", status: resp.StatusCode, body: string(body)}
 	}
 
 	var data syndicationResponse
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("failed to parse syndication response: %w", err)
+		return nil, fmt.Errorf("failed to parse syndication response: %w", t.callError(ctx, callCtx, "syndication", err))
 	}
 
 	return t.parseSyndicationResponse(&data, tweetID)
 }
 
-// fetchGuestToken obtains a guest token for API access
-func (t *TwitterExtractor) fetchGuestToken() error {
-	req, err := http.NewRequest("POST", twitterGuestTokenURL, nil)
+// fetchGuestToken obtains a guest token for API access, reusing the one
+// saved on disk while it is younger than twitterGuestTokenTTL
+func (t *TwitterExtractor) fetchGuestToken(ctx context.Context) error {
+	if token := loadCachedGuestToken(); token != "" {
+		t.setToken(token)
+		return nil
+	}
+
+	callCtx, cancel := t.callContext(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(callCtx, "POST", twitterGuestTokenURL, nil)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+twitterBearerToken)
+	req.Header.Set("Authorization", "Bearer "+bearerToken())
 
-	resp, err := t.client.Do(req)
+	resp, err := t.httpClient().Do(req)
 	if err != nil {
-		return err
+		return t.callError(ctx, callCtx, "guest token", err)
 	}
 	defer resp.Body.Close()
 
@@ -138,101 +389,151 @@ func (t *TwitterExtractor) fetchGuestToken() error {
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return err
+		return t.callError(ctx, callCtx, "guest token", err)
 	}
 
-	t.guestToken = result.GuestToken
+	t.setToken(result.GuestToken)
+	saveCachedGuestToken(result.GuestToken) // Best-effort
 	return nil
 }
 
-// fetchFromGraphQL uses the GraphQL API
-func (t *TwitterExtractor) fetchFromGraphQL(tweetID string) (Media, error) {
-	variables := map[string]interface{}{
-		"tweetId":                tweetID,
-		"withCommunity":          false,
-		"includePromotedContent": false,
-		"withVoice":              false,
+// cachedGuestToken is the on-disk form of a guest token. Tokens belong to
+// the bearer token they were activated with, so that is kept as a hash.
+type cachedGuestToken struct {
+	Token  string    `json:"token"`
+	Bearer string    `json:"bearer"`
+	Saved  time.Time `json:"saved"`
+}
+
+// guestTokenPath returns the file the guest token is cached in
+func guestTokenPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "vget", "twitter-guest-token.json"), nil
+}
+
+// bearerHash identifies the current bearer token without storing it
+func bearerHash() string {
+	sum := sha256.Sum256([]byte(bearerToken()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// loadCachedGuestToken returns the cached guest token, or "" if there is
+// none, it is too old or it belongs to another bearer token
+func loadCachedGuestToken() string {
+	path, err := guestTokenPath()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var c cachedGuestToken
+	if err := json.Unmarshal(data, &c); err != nil ||
+		c.Bearer != bearerHash() || time.Since(c.Saved) > twitterGuestTokenTTL {
+		return ""
+	}
+	return c.Token
+}
+
+// saveCachedGuestToken stores token for later runs
+func saveCachedGuestToken(token string) error {
+	path, err := guestTokenPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cachedGuestToken{Token: token, Bearer: bearerHash(), Saved: time.Now()})
+	if err != nil {
+		return err
 	}
+	return os.WriteFile(path, data, 0600)
+}
 
-	features := map[string]interface{}{
-		"creator_subscriptions_tweet_preview_api_enabled":                         true,
-		"communities_web_enable_tweet_community_results_fetch":                    true,
-		"c9s_tweet_anatomy_moderator_badge_enabled":                               true,
-		"articles_preview_enabled":                                                true,
-		"responsive_web_edit_tweet_api_enabled":                                   true,
-		"graphql_is_translatable_rweb_tweet_is_translatable_enabled":              true,
-		"view_counts_everywhere_api_enabled":                                      true,
-		"longform_notetweets_consumption_enabled":                                 true,
-		"responsive_web_twitter_article_tweet_consumption_enabled":                true,
-		"tweet_awards_web_tipping_enabled":                                        false,
-		"creator_subscriptions_quote_tweet_preview_enabled":                       false,
-		"freedom_of_speech_not_reach_fetch_enabled":                               true,
-		"standardized_nudges_misinfo":                                             true,
-		"tweet_with_visibility_results_prefer_gql_limited_actions_policy_enabled": true,
-		"rweb_video_timestamps_enabled":                                           true,
-		"longform_notetweets_rich_text_read_enabled":                              true,
-		"longform_notetweets_inline_media_enabled":                                true,
-		"rweb_tipjar_consumption_enabled":                                         true,
-		"responsive_web_graphql_exclude_directive_enabled":                        true,
-		"verified_phone_label_enabled":                                            false,
-		"responsive_web_graphql_skip_user_profile_image_extensions_enabled":       false,
-		"responsive_web_graphql_timeline_navigation_enabled":                      true,
-		"responsive_web_enhance_cards_enabled":                                    false,
+// removeCachedGuestToken forgets a guest token the API rejected
+func removeCachedGuestToken() {
+	if path, err := guestTokenPath(); err == nil {
+		os.Remove(path)
 	}
+}
 
+// fetchGraphQL makes a single request to a GraphQL endpoint and returns
+// the response body
+func (t *TwitterExtractor) fetchGraphQL(ctx context.Context, endpoint string, variables map[string]interface{}) ([]byte, error) {
 	variablesJSON, _ := json.Marshal(variables)
-	featuresJSON, _ := json.Marshal(features)
+	featuresJSON, _ := json.Marshal(twitterGraphQLFeatures)
 
 	params := url.Values{}
 	params.Set("variables", string(variablesJSON))
 	params.Set("features", string(featuresJSON))
 
-	reqURL := twitterGraphQLURL + "?" + params.Encode()
+	reqURL := endpoint + "?" + params.Encode()
+
+	callCtx, cancel := t.callContext(ctx)
+	defer cancel()
 
-	req, err := http.NewRequest("GET", reqURL, nil)
+	req, err := http.NewRequestWithContext(callCtx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+twitterBearerToken)
-	req.Header.Set("x-guest-token", t.guestToken)
+	req.Header.Set("Authorization", "Bearer "+bearerToken())
 	req.Header.Set("Content-Type", "application/json")
+
+	// Logged-in cookies (--cookies-from-browser), which the client's jar
+	// adds, need the matching CSRF token; otherwise this is a guest
+	if csrf := t.sessionCSRF(endpoint); csrf != "" {
+		req.Header.Set("x-csrf-token", csrf)
+		req.Header.Set("x-twitter-auth-type", "OAuth2Session")
+		req.Header.Set("x-twitter-active-user", "yes")
+	} else {
+		req.Header.Set("x-guest-token", t.token())
+	}
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36")
 
-	resp, err := t.client.Do(req)
+	resp, err := t.httpClient().Do(req)
 	if err != nil {
-		return nil, err
+		return nil, t.callError(ctx, callCtx, "GraphQL", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("GraphQL request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, &twitterAPIError{api: "GraphQL", status: resp.StatusCode, body: string(body)}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, t.callError(ctx, callCtx, "GraphQL", err)
 	}
-
-	return t.parseGraphQLResponse(body, tweetID)
+	return body, nil
 }
 
 // parseSyndicationResponse extracts media from syndication API response
 func (t *TwitterExtractor) parseSyndicationResponse(data *syndicationResponse, tweetID string) (Media, error) {
 	if len(data.MediaDetails) == 0 {
+		if data.Card != nil && strings.HasSuffix(data.Card.Name, ":audiospace") {
+			return nil, fmt.Errorf("tweet shares a Twitter Space; Space recordings can't be downloaded")
+		}
 		return nil, fmt.Errorf("no media found in tweet")
 	}
 
 	title := truncateText(data.Text, 100)
 	uploader := data.User.ScreenName
 
-	var videoFormats []VideoFormat
+	var videos []VideoEntry
 	var images []Image
+	var audio *AudioMedia
 
 	for _, media := range data.MediaDetails {
 		switch media.Type {
 		case "video", "animated_gif":
+			var videoFormats []VideoFormat
 			for _, variant := range media.VideoInfo.Variants {
 				if variant.ContentType != "video/mp4" {
 					continue
@@ -250,18 +551,35 @@ func (t *TwitterExtractor) parseSyndicationResponse(data *syndicationResponse, t
 					format.Quality = fmt.Sprintf("%dp", h)
 				} else if variant.Bitrate > 0 {
 					format.Quality = estimateQualityFromBitrate(variant.Bitrate)
+					format.Width, format.Height = estimateResolution(variant.Bitrate, media.VideoInfo.AspectRatio)
 				}
 
 				videoFormats = append(videoFormats, format)
 			}
+			// Audio posts come as video media with only audio encodings
+			if len(videoFormats) == 0 {
+				if v := bestAudioVariant(media.VideoInfo.Variants); v != nil {
+					if audio == nil {
+						audio = newTwitterAudioMedia(tweetID, title, uploader, v, media.VideoInfo.DurationMillis)
+					}
+					continue
+				}
+			}
+			videos = append(videos, VideoEntry{
+				Duration: videoDuration(media.Type, media.VideoInfo.DurationMillis),
+				Width:    media.OriginalWidth,
+				Height:   media.OriginalHeight,
+				Formats:  videoFormats,
+			})
 
 		case "photo":
 			imageURL := getHighQualityImageURL(media.MediaURLHTTPS)
 			ext := getImageExtension(media.MediaURLHTTPS)
 
 			img := Image{
-				URL: imageURL,
-				Ext: ext,
+				URL:     imageURL,
+				Ext:     ext,
+				AltText: media.AltText,
 			}
 
 			if media.OriginalWidth > 0 {
@@ -273,25 +591,16 @@ func (t *TwitterExtractor) parseSyndicationResponse(data *syndicationResponse, t
 		}
 	}
 
-	// Also check video field directly
+	// Also check video field directly (it describes the first video)
 	if data.Video.Variants != nil {
+		if len(videos) == 0 {
+			videos = append(videos, VideoEntry{})
+		}
 		for _, variant := range data.Video.Variants {
 			if variant.Type != "video/mp4" {
 				continue
 			}
 
-			// Check if this URL already exists
-			exists := false
-			for _, f := range videoFormats {
-				if f.URL == variant.Src {
-					exists = true
-					break
-				}
-			}
-			if exists {
-				continue
-			}
-
 			format := VideoFormat{
 				URL: variant.Src,
 				Ext: "mp4",
@@ -303,37 +612,35 @@ func (t *TwitterExtractor) parseSyndicationResponse(data *syndicationResponse, t
 				format.Quality = fmt.Sprintf("%dp", h)
 			}
 
-			videoFormats = append(videoFormats, format)
+			videos[0].Formats = append(videos[0].Formats, format)
 		}
 	}
 
 	// Return appropriate media type
-	if len(videoFormats) > 0 {
-		// Sort by bitrate/height (highest first)
-		sort.Slice(videoFormats, func(i, j int) bool {
-			if videoFormats[i].Bitrate != videoFormats[j].Bitrate {
-				return videoFormats[i].Bitrate > videoFormats[j].Bitrate
-			}
-			return videoFormats[i].Height > videoFormats[j].Height
-		})
-
-		return &VideoMedia{
-			ID:       tweetID,
-			Title:    title,
-			Uploader: uploader,
-			Formats:  videoFormats,
-		}, nil
+	if video := newTwitterVideoMedia(tweetID, title, data.Text, uploader, parseTweetTime(data.CreatedAt), videos); video != nil {
+		return video, nil
+	}
+
+	if audio != nil {
+		return audio, nil
 	}
 
 	if len(images) > 0 {
 		return &ImageMedia{
-			ID:       tweetID,
-			Title:    title,
-			Uploader: uploader,
-			Images:   images,
+			ID:          tweetID,
+			Title:       title,
+			Description: data.Text,
+			Uploader:    uploader,
+			Images:      images,
+			Published:   parseTweetTime(data.CreatedAt),
 		}, nil
 	}
 
+	// A video entity without variants hasn't finished processing
+	if len(videos) > 0 {
+		return nil, fmt.Errorf("%w: video in tweet %s is still processing", ErrMediaNotReady, tweetID)
+	}
+
 	return nil, fmt.Errorf("no media found in tweet")
 }
 
@@ -369,15 +676,14 @@ func (t *TwitterExtractor) parseGraphQLResponse(body []byte, tweetID string) (Me
 		return nil, fmt.Errorf("no media found in tweet")
 	}
 
-	var videoFormats []VideoFormat
+	var videos []VideoEntry
 	var images []Image
-	var duration int
+	var audio *AudioMedia
 
 	for _, media := range legacy.ExtendedEntities.Media {
 		switch media.Type {
 		case "video", "animated_gif":
-			duration = media.VideoInfo.DurationMillis / 1000
-
+			var videoFormats []VideoFormat
 			for _, variant := range media.VideoInfo.Variants {
 				if variant.ContentType != "video/mp4" {
 					continue
@@ -395,18 +701,35 @@ func (t *TwitterExtractor) parseGraphQLResponse(body []byte, tweetID string) (Me
 					format.Quality = fmt.Sprintf("%dp", h)
 				} else if variant.Bitrate > 0 {
 					format.Quality = estimateQualityFromBitrate(variant.Bitrate)
+					format.Width, format.Height = estimateResolution(variant.Bitrate, media.VideoInfo.AspectRatio)
 				}
 
 				videoFormats = append(videoFormats, format)
 			}
+			// Audio posts come as video media with only audio encodings
+			if len(videoFormats) == 0 {
+				if v := bestAudioVariant(media.VideoInfo.Variants); v != nil {
+					if audio == nil {
+						audio = newTwitterAudioMedia(tweetID, title, uploader, v, media.VideoInfo.DurationMillis)
+					}
+					continue
+				}
+			}
+			videos = append(videos, VideoEntry{
+				Duration: videoDuration(media.Type, media.VideoInfo.DurationMillis),
+				Width:    media.OriginalInfo.Width,
+				Height:   media.OriginalInfo.Height,
+				Formats:  videoFormats,
+			})
 
 		case "photo":
 			imageURL := getHighQualityImageURL(media.MediaURLHTTPS)
 			ext := getImageExtension(media.MediaURLHTTPS)
 
 			img := Image{
-				URL: imageURL,
-				Ext: ext,
+				URL:     imageURL,
+				Ext:     ext,
+				AltText: media.AltText,
 			}
 
 			if media.OriginalInfo.Width > 0 {
@@ -419,29 +742,30 @@ func (t *TwitterExtractor) parseGraphQLResponse(body []byte, tweetID string) (Me
 	}
 
 	// Return appropriate media type
-	if len(videoFormats) > 0 {
-		sort.Slice(videoFormats, func(i, j int) bool {
-			return videoFormats[i].Bitrate > videoFormats[j].Bitrate
-		})
-
-		return &VideoMedia{
-			ID:       tweetID,
-			Title:    title,
-			Uploader: uploader,
-			Duration: duration,
-			Formats:  videoFormats,
-		}, nil
+	if video := newTwitterVideoMedia(tweetID, title, legacy.FullText, uploader, parseTweetTime(legacy.CreatedAt), videos); video != nil {
+		return video, nil
+	}
+
+	if audio != nil {
+		return audio, nil
 	}
 
 	if len(images) > 0 {
 		return &ImageMedia{
-			ID:       tweetID,
-			Title:    title,
-			Uploader: uploader,
-			Images:   images,
+			ID:          tweetID,
+			Title:       title,
+			Description: legacy.FullText,
+			Uploader:    uploader,
+			Images:      images,
+			Published:   parseTweetTime(legacy.CreatedAt),
 		}, nil
 	}
 
+	// A video entity without variants hasn't finished processing
+	if len(videos) > 0 {
+		return nil, fmt.Errorf("%w: video in tweet %s is still processing", ErrMediaNotReady, tweetID)
+	}
+
 	return nil, fmt.Errorf("no media found in tweet")
 }
 
@@ -455,14 +779,13 @@ type syndicationResponse struct {
 	MediaDetails []struct {
 		Type           string `json:"type"`
 		MediaURLHTTPS  string `json:"media_url_https"`
+		AltText        string `json:"ext_alt_text"`
 		OriginalWidth  int    `json:"original_info_width"`
 		OriginalHeight int    `json:"original_info_height"`
 		VideoInfo      struct {
-			Variants []struct {
-				Bitrate     int    `json:"bitrate"`
-				ContentType string `json:"content_type"`
-				URL         string `json:"url"`
-			} `json:"variants"`
+			AspectRatio    []int            `json:"aspect_ratio"`
+			DurationMillis int              `json:"duration_millis"`
+			Variants       []twitterVariant `json:"variants"`
 		} `json:"video_info"`
 	} `json:"mediaDetails"`
 	Video struct {
@@ -471,6 +794,17 @@ type syndicationResponse struct {
 			Src  string `json:"src"`
 		} `json:"variants"`
 	} `json:"video"`
+	Card *struct {
+		Name string `json:"name"` // e.g. "3691233323:audiospace"
+	} `json:"card"`
+	CreatedAt string `json:"created_at"`
+}
+
+// twitterVariant is a single encoding of a tweet's video (or audio)
+type twitterVariant struct {
+	Bitrate     int    `json:"bitrate"`
+	ContentType string `json:"content_type"`
+	URL         string `json:"url"`
 }
 
 // GraphQL API response structures
@@ -484,6 +818,7 @@ type graphQLResponse struct {
 
 type graphQLTweetResult struct {
 	TypeName string              `json:"__typename"`
+	RestID   string              `json:"rest_id"`
 	Legacy   *graphQLLegacy      `json:"legacy"`
 	Core     *graphQLCore        `json:"core"`
 	Tweet    *graphQLTweetResult `json:"tweet"` // For TweetWithVisibilityResults
@@ -500,22 +835,23 @@ type graphQLCore struct {
 }
 
 type graphQLLegacy struct {
-	FullText         string `json:"full_text"`
-	ExtendedEntities *struct {
+	FullText          string `json:"full_text"`
+	CreatedAt         string `json:"created_at"`
+	UserID            string `json:"user_id_str"`
+	InReplyToStatusID string `json:"in_reply_to_status_id_str"`
+	ExtendedEntities  *struct {
 		Media []struct {
 			Type          string `json:"type"`
 			MediaURLHTTPS string `json:"media_url_https"`
+			AltText       string `json:"ext_alt_text"`
 			OriginalInfo  struct {
 				Width  int `json:"width"`
 				Height int `json:"height"`
 			} `json:"original_info"`
 			VideoInfo struct {
-				DurationMillis int `json:"duration_millis"`
-				Variants       []struct {
-					Bitrate     int    `json:"bitrate"`
-					ContentType string `json:"content_type"`
-					URL         string `json:"url"`
-				} `json:"variants"`
+				AspectRatio    []int            `json:"aspect_ratio"`
+				DurationMillis int              `json:"duration_millis"`
+				Variants       []twitterVariant `json:"variants"`
 			} `json:"video_info"`
 		} `json:"media"`
 	} `json:"extended_entities"`
@@ -523,6 +859,87 @@ type graphQLLegacy struct {
 
 // Helper functions
 
+// newTwitterVideoMedia builds a VideoMedia from the per-video format lists,
+// or returns nil if the tweet has no playable video
+func newTwitterVideoMedia(tweetID, title, text, uploader string, published time.Time, videos []VideoEntry) *VideoMedia {
+	var entries []VideoEntry
+	for _, v := range videos {
+		// Sort by bitrate/height (highest first)
+		v.Formats = dedupFormats(v.Formats)
+		sortFormats(v.Formats)
+		if len(v.Formats) > 0 {
+			entries = append(entries, v)
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	media := &VideoMedia{
+		ID:          tweetID,
+		Title:       title,
+		Description: text,
+		Uploader:    uploader,
+		Duration:    entries[0].Duration,
+		Width:       entries[0].Width,
+		Height:      entries[0].Height,
+		Formats:     entries[0].Formats,
+		Published:   published,
+	}
+	if len(entries) > 1 {
+		media.Videos = entries
+	}
+	return media
+}
+
+// parseTweetTime parses a tweet's created_at, which GraphQL gives as
+// "Wed Oct 10 20:19:24 +0000 2018" and the syndication API as RFC 3339.
+// It returns the zero time if the date is missing or malformed.
+func parseTweetTime(s string) time.Time {
+	for _, layout := range []string{time.RubyDate, time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// bestAudioVariant returns the highest-bitrate audio-only variant, if any
+func bestAudioVariant(variants []twitterVariant) *twitterVariant {
+	var best *twitterVariant
+	for i, v := range variants {
+		if strings.HasPrefix(v.ContentType, "audio/") && (best == nil || v.Bitrate > best.Bitrate) {
+			best = &variants[i]
+		}
+	}
+	return best
+}
+
+// newTwitterAudioMedia builds an AudioMedia from an audio-only variant
+func newTwitterAudioMedia(tweetID, title, uploader string, v *twitterVariant, durationMillis int) *AudioMedia {
+	ext := "m4a"
+	if v.ContentType == "audio/mpeg" {
+		ext = "mp3"
+	}
+	return &AudioMedia{
+		ID:       tweetID,
+		Title:    title,
+		Uploader: uploader,
+		Duration: durationMillis / 1000,
+		URL:      v.URL,
+		Ext:      ext,
+	}
+}
+
+// videoDuration returns the duration in seconds of a tweet media item.
+// GIFs loop and have no meaningful duration, so they report zero.
+func videoDuration(mediaType string, durationMillis int) int {
+	if mediaType == "animated_gif" {
+		return 0
+	}
+	return durationMillis / 1000
+}
+
 func truncateText(s string, maxLen int) string {
 	s = strings.ReplaceAll(s, "\n", " ")
 	runes := []rune(s)
@@ -545,16 +962,35 @@ func extractResolutionFromURL(url string) (width, height int) {
 }
 
 func estimateQualityFromBitrate(bitrate int) string {
+	return fmt.Sprintf("%dp", estimateLinesFromBitrate(bitrate))
+}
+
+// estimateLinesFromBitrate guesses the short side of a video from its bitrate
+func estimateLinesFromBitrate(bitrate int) int {
 	switch {
 	case bitrate >= 2000000:
-		return "1080p"
+		return 1080
 	case bitrate >= 1000000:
-		return "720p"
+		return 720
 	case bitrate >= 500000:
-		return "480p"
+		return 480
 	default:
-		return "360p"
+		return 360
+	}
+}
+
+// estimateResolution guesses a variant's dimensions from its bitrate and the
+// video's aspect ratio ([width, height]) when the URL doesn't carry them
+func estimateResolution(bitrate int, aspect []int) (width, height int) {
+	if bitrate <= 0 || len(aspect) != 2 || aspect[0] <= 0 || aspect[1] <= 0 {
+		return 0, 0
+	}
+	short := estimateLinesFromBitrate(bitrate)
+	// Round the long side to an even number, as encoders require
+	if aspect[0] >= aspect[1] {
+		return (short*aspect[0]/aspect[1] + 1) &^ 1, short
 	}
+	return short, (short*aspect[1]/aspect[0] + 1) &^ 1
 }
 
 // getHighQualityImageURL converts a Twitter image URL to highest quality version
@@ -585,7 +1021,7 @@ func getImageExtension(imageURL string) string {
 }
 
 func init() {
-	Register(&TwitterExtractor{},
+	Register(NewTwitterExtractor(TwitterOptions{}),
 		"twitter.com",
 		"x.com",
 		"mobile.twitter.com",
@@ -0,0 +1,482 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// graphQLFixture wraps media entities (JSON objects) in a TweetResultByRestId
+// response for a tweet by @user
+func graphQLFixture(media ...string) []byte {
+	return []byte(fmt.Sprintf(`{"data": {"tweetResult": {"result": {
+		"__typename": "Tweet",
+		"rest_id": "1",
+		"core": {"user_results": {"result": {"legacy": {"screen_name": "user"}}}},
+		"legacy": {
+			"full_text": "a tweet",
+			"created_at": "Wed Oct 10 20:19:24 +0000 2018",
+			"extended_entities": {"media": [%s]}
+		}
+	}}}}`, strings.Join(media, ",")))
+}
+
+// videoEntity is a media entity with one mp4 variant at url
+func videoEntity(mediaType, url string, durationMillis int) string {
+	return fmt.Sprintf(`{
+		"type": %q,
+		"video_info": {"duration_millis": %d, "variants": [
+			{"bitrate": 832000, "content_type": "video/mp4", "url": %q}
+		]}
+	}`, mediaType, durationMillis, url)
+}
+
+func TestTwitterDurations(t *testing.T) {
+	tests := []struct {
+		name      string
+		media     []string
+		duration  int   // VideoMedia.Duration
+		durations []int // per entry of VideoMedia.Videos, nil for a single video
+	}{
+		{
+			name:     "single video",
+			media:    []string{videoEntity("video", "https://video.twimg.com/a.mp4", 42500)},
+			duration: 42,
+		},
+		{
+			name: "multiple videos",
+			media: []string{
+				videoEntity("video", "https://video.twimg.com/a.mp4", 12000),
+				videoEntity("video", "https://video.twimg.com/b.mp4", 95000),
+			},
+			duration:  12,
+			durations: []int{12, 95},
+		},
+		{
+			name:     "gif",
+			media:    []string{videoEntity("animated_gif", "https://video.twimg.com/tweet_video/a.mp4", 0)},
+			duration: 0,
+		},
+		{
+			name: "gif next to a video",
+			media: []string{
+				videoEntity("animated_gif", "https://video.twimg.com/tweet_video/a.mp4", 3000),
+				videoEntity("video", "https://video.twimg.com/b.mp4", 30000),
+			},
+			duration:  0,
+			durations: []int{0, 30},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			media, err := (&TwitterExtractor{}).parseGraphQLResponse(graphQLFixture(tt.media...), "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			video := media.(*VideoMedia)
+			if video.Duration != tt.duration {
+				t.Errorf("Duration = %d, want %d", video.Duration, tt.duration)
+			}
+			var durations []int
+			for _, v := range video.Videos {
+				durations = append(durations, v.Duration)
+			}
+			if fmt.Sprint(durations) != fmt.Sprint(tt.durations) {
+				t.Errorf("per-video durations = %v, want %v", durations, tt.durations)
+			}
+		})
+	}
+}
+
+func TestTwitterPublished(t *testing.T) {
+	media, err := (&TwitterExtractor{}).parseGraphQLResponse(graphQLFixture(videoEntity("video", "https://video.twimg.com/a.mp4", 1000)), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2018, 10, 10, 20, 19, 24, 0, time.UTC)
+	if got := media.(*VideoMedia).Published; !got.Equal(want) {
+		t.Errorf("Published = %v, want %v", got, want)
+	}
+
+	if got := parseTweetTime("2018-10-10T20:19:24.000Z"); !got.Equal(want) {
+		t.Errorf("syndication date parsed as %v, want %v", got, want)
+	}
+}
+
+func TestEstimateResolution(t *testing.T) {
+	tests := []struct {
+		name          string
+		bitrate       int
+		aspect        []int
+		width, height int
+	}{
+		{"landscape 1080p", 2176000, []int{16, 9}, 1920, 1080},
+		{"landscape 360p", 256000, []int{4, 3}, 480, 360},
+		{"portrait 720p", 1280000, []int{9, 16}, 720, 1280},
+		{"square", 832000, []int{1, 1}, 480, 480},
+		{"odd long side rounded up", 256000, []int{11, 8}, 496, 360},
+		{"no bitrate", 0, []int{16, 9}, 0, 0},
+		{"no aspect ratio", 832000, nil, 0, 0},
+		{"zero aspect ratio", 832000, []int{16, 0}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := estimateResolution(tt.bitrate, tt.aspect)
+			if w != tt.width || h != tt.height {
+				t.Errorf("estimateResolution(%d, %v) = %dx%d, want %dx%d", tt.bitrate, tt.aspect, w, h, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestGraphQLEstimatesDimensions(t *testing.T) {
+	body := graphQLFixture(`{
+		"type": "video",
+		"original_info": {"width": 1280, "height": 720},
+		"video_info": {"duration_millis": 10000, "aspect_ratio": [16, 9], "variants": [
+			{"bitrate": 2176000, "content_type": "video/mp4", "url": "https://video.twimg.com/amplify_video/1/vid/avc1/a.mp4"},
+			{"bitrate": 832000, "content_type": "video/mp4", "url": "https://video.twimg.com/amplify_video/1/vid/640x360/b.mp4"}
+		]}
+	}`)
+	media, err := (&TwitterExtractor{}).parseGraphQLResponse(body, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	video := media.(*VideoMedia)
+	if video.Width != 1280 || video.Height != 720 {
+		t.Errorf("video is %dx%d, want the original_info 1280x720", video.Width, video.Height)
+	}
+
+	want := map[string][2]int{
+		"https://video.twimg.com/amplify_video/1/vid/avc1/a.mp4":    {1920, 1080}, // estimated
+		"https://video.twimg.com/amplify_video/1/vid/640x360/b.mp4": {640, 360},   // from the URL
+	}
+	for _, f := range video.Formats {
+		if got := [2]int{f.Width, f.Height}; got != want[f.URL] {
+			t.Errorf("%s is %dx%d, want %dx%d", f.URL, f.Width, f.Height, want[f.URL][0], want[f.URL][1])
+		}
+	}
+	if len(video.Formats) != len(want) {
+		t.Errorf("got %d formats, want %d", len(video.Formats), len(want))
+	}
+}
+
+// twitterAPI stands in for the Twitter endpoints: it routes requests by
+// URL without the query to a handler and counts the calls per endpoint
+type twitterAPI struct {
+	mu       sync.Mutex
+	handlers map[string]http.HandlerFunc
+	calls    map[string]int
+}
+
+func (a *twitterAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	a.mu.Lock()
+	a.calls[endpoint]++
+	h := a.handlers[endpoint]
+	a.mu.Unlock()
+	if h == nil {
+		h = http.NotFound
+	}
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec.Result(), nil
+}
+
+func (a *twitterAPI) count(endpoint string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.calls[endpoint]
+}
+
+// newTwitterTest returns an extractor talking to api, with the guest token
+// cached in a temp dir and no wait between rate-limit retries
+func newTwitterTest(t *testing.T, handlers map[string]http.HandlerFunc) (*TwitterExtractor, *twitterAPI) {
+	t.Helper()
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("HOME", cache)
+	saved := twitterRateLimitBackoff
+	t.Cleanup(func() { twitterRateLimitBackoff = saved })
+	twitterRateLimitBackoff = time.Millisecond
+
+	api := &twitterAPI{handlers: handlers, calls: map[string]int{}}
+	return NewTwitterExtractor(TwitterOptions{Client: &http.Client{Transport: api}}), api
+}
+
+// respond writes status and body
+func respond(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}
+}
+
+const syndicationFixture = `{
+	"text": "a tweet",
+	"user": {"screen_name": "user"},
+	"mediaDetails": [{"type": "video", "video_info": {"duration_millis": 1000, "variants": [
+		{"bitrate": 832000, "content_type": "video/mp4", "url": "https://video.twimg.com/vid/640x360/syndication.mp4"}
+	]}}]
+}`
+
+// graphQLVideo is what the GraphQL handlers of these tests return
+var graphQLVideo = string(graphQLFixture(videoEntity("video", "https://video.twimg.com/vid/640x360/graphql.mp4", 1000)))
+
+// videoURL returns the URL of the first format of a video
+func videoURL(t *testing.T, media Media) string {
+	t.Helper()
+	video, ok := media.(*VideoMedia)
+	if !ok || len(video.Formats) == 0 {
+		t.Fatalf("got %#v, want a video", media)
+	}
+	return video.Formats[0].URL
+}
+
+func TestTwitterFallsBackToGraphQL(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusForbidden} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var guestToken string
+			ext, api := newTwitterTest(t, map[string]http.HandlerFunc{
+				twitterSyndicationURL: respond(status, "{}"),
+				twitterGuestTokenURL:  respond(http.StatusOK, `{"guest_token": "g1"}`),
+				twitterGraphQLURL: func(w http.ResponseWriter, r *http.Request) {
+					guestToken = r.Header.Get("x-guest-token")
+					fmt.Fprint(w, graphQLVideo)
+				},
+			})
+			media, err := ext.Extract(t.Context(), "https://x.com/user/status/1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := videoURL(t, media); !strings.HasSuffix(got, "graphql.mp4") {
+				t.Errorf("got %s, want the GraphQL video", got)
+			}
+			if guestToken != "g1" {
+				t.Errorf("GraphQL request had guest token %q, want g1", guestToken)
+			}
+			if n := api.count(twitterSyndicationURL); n != 1 {
+				t.Errorf("syndication called %d times, want 1", n)
+			}
+		})
+	}
+}
+
+func TestTwitterRetriesRateLimitedSyndication(t *testing.T) {
+	var attempts int
+	ext, api := newTwitterTest(t, map[string]http.HandlerFunc{
+		twitterSyndicationURL: func(w http.ResponseWriter, r *http.Request) {
+			if attempts++; attempts <= twitterRateLimitRetries {
+				respond(http.StatusTooManyRequests, "{}")(w, r)
+				return
+			}
+			fmt.Fprint(w, syndicationFixture)
+		},
+	})
+	media, err := ext.Extract(t.Context(), "https://x.com/user/status/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := videoURL(t, media); !strings.HasSuffix(got, "syndication.mp4") {
+		t.Errorf("got %s, want the syndication video", got)
+	}
+	if attempts != twitterRateLimitRetries+1 {
+		t.Errorf("syndication called %d times, want %d", attempts, twitterRateLimitRetries+1)
+	}
+	if n := api.count(twitterGraphQLURL); n != 0 {
+		t.Errorf("GraphQL called %d times after syndication succeeded", n)
+	}
+}
+
+func TestTwitterRateLimitedSyndicationFallsBack(t *testing.T) {
+	ext, api := newTwitterTest(t, map[string]http.HandlerFunc{
+		twitterSyndicationURL: respond(http.StatusTooManyRequests, "{}"),
+		twitterGuestTokenURL:  respond(http.StatusOK, `{"guest_token": "g1"}`),
+		twitterGraphQLURL:     respond(http.StatusOK, graphQLVideo),
+	})
+	if _, err := ext.Extract(t.Context(), "https://x.com/user/status/1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := api.count(twitterSyndicationURL); n != twitterRateLimitRetries+1 {
+		t.Errorf("syndication called %d times, want %d", n, twitterRateLimitRetries+1)
+	}
+	if n := api.count(twitterGraphQLURL); n != 1 {
+		t.Errorf("GraphQL called %d times, want 1", n)
+	}
+}
+
+func TestTwitterRefreshesRejectedGuestToken(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			ext, api := newTwitterTest(t, map[string]http.HandlerFunc{
+				twitterSyndicationURL: respond(http.StatusNotFound, "{}"),
+				twitterGuestTokenURL:  respond(http.StatusOK, `{"guest_token": "fresh"}`),
+				twitterGraphQLURL: func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get("x-guest-token") != "fresh" {
+						respond(status, "{}")(w, r)
+						return
+					}
+					fmt.Fprint(w, graphQLVideo)
+				},
+			})
+			// A token from an earlier run that the API no longer accepts
+			if err := saveCachedGuestToken("stale"); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := ext.Extract(t.Context(), "https://x.com/user/status/1"); err != nil {
+				t.Fatal(err)
+			}
+			if n := api.count(twitterGuestTokenURL); n != 1 {
+				t.Errorf("guest token requested %d times, want 1", n)
+			}
+			if n := api.count(twitterGraphQLURL); n != 2 {
+				t.Errorf("GraphQL called %d times, want 2", n)
+			}
+			if token := loadCachedGuestToken(); token != "fresh" {
+				t.Errorf("cached guest token = %q, want the fresh one", token)
+			}
+		})
+	}
+}
+
+func TestTwitterCallTimeout(t *testing.T) {
+	t.Cleanup(func() { SetTwitterCallTimeout(0) })
+
+	if got := (&TwitterExtractor{}).timeout(); got != defaultTwitterCallTimeout {
+		t.Errorf("default timeout = %s", got)
+	}
+	SetTwitterCallTimeout(45 * time.Second)
+	if got := (&TwitterExtractor{}).timeout(); got != 45*time.Second {
+		t.Errorf("configured timeout = %s, want 45s", got)
+	}
+	ext := NewTwitterExtractor(TwitterOptions{CallTimeout: 5 * time.Second})
+	if got := ext.timeout(); got != 5*time.Second {
+		t.Errorf("CallTimeout option = %s, want 5s over the configured one", got)
+	}
+}
+
+// threadAPI serves the TweetDetail fixtures of testdata/twitter_thread.json
+// by focal tweet, recording the headers of each request
+func threadAPI(t *testing.T, headers *[]http.Header) http.HandlerFunc {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "twitter_thread.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixtures map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	return func(w http.ResponseWriter, r *http.Request) {
+		var variables struct {
+			FocalTweetID string `json:"focalTweetId"`
+		}
+		json.Unmarshal([]byte(r.URL.Query().Get("variables")), &variables)
+		mu.Lock()
+		*headers = append(*headers, r.Header.Clone())
+		mu.Unlock()
+		fixture, ok := fixtures[variables.FocalTweetID]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(fixture)
+	}
+}
+
+func TestTwitterThread(t *testing.T) {
+	var headers []http.Header
+	ext, api := newTwitterTest(t, map[string]http.HandlerFunc{
+		twitterGuestTokenURL:  respond(http.StatusOK, `{"guest_token": "g1"}`),
+		twitterTweetDetailURL: threadAPI(t, &headers),
+	})
+
+	entries, err := ext.Thread(t.Context(), "https://x.com/author/status/200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 200 has no media and 250 is someone else's reply
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.URL)
+	}
+	want := []string{
+		"https://x.com/author/status/100",
+		"https://x.com/author/status/300",
+		"https://x.com/author/status/400",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("thread = %v, want %v", got, want)
+	}
+	if entries[0].Title != "1/ the start" {
+		t.Errorf("first title = %q", entries[0].Title)
+	}
+	// 200 includes 300 but not its reply, 300 includes 400, and 400 shows
+	// there is nothing after it
+	if n := api.count(twitterTweetDetailURL); n != 3 {
+		t.Errorf("TweetDetail called %d times, want 3", n)
+	}
+	for _, h := range headers {
+		if h.Get("x-guest-token") != "g1" || h.Get("x-csrf-token") != "" {
+			t.Errorf("guest request sent x-guest-token %q, x-csrf-token %q", h.Get("x-guest-token"), h.Get("x-csrf-token"))
+		}
+	}
+}
+
+func TestTwitterThreadWithSessionCookies(t *testing.T) {
+	var headers []http.Header
+	ext, api := newTwitterTest(t, map[string]http.HandlerFunc{
+		twitterGuestTokenURL:  respond(http.StatusOK, `{"guest_token": "g1"}`),
+		twitterTweetDetailURL: threadAPI(t, &headers),
+	})
+	jar, _ := cookiejar.New(nil)
+	jar.SetCookies(&url.URL{Scheme: "https", Host: "x.com"}, []*http.Cookie{
+		{Name: "auth_token", Value: "secret", Path: "/"},
+		{Name: "ct0", Value: "csrf123", Path: "/"},
+	})
+	ext.client.Jar = jar
+
+	if _, err := ext.Thread(t.Context(), "https://x.com/author/status/400"); err != nil {
+		t.Fatal(err)
+	}
+	if n := api.count(twitterGuestTokenURL); n != 0 {
+		t.Errorf("guest token requested %d times with a logged-in session", n)
+	}
+	for _, h := range headers {
+		if h.Get("x-csrf-token") != "csrf123" || h.Get("x-guest-token") != "" {
+			t.Errorf("session request sent x-csrf-token %q, x-guest-token %q", h.Get("x-csrf-token"), h.Get("x-guest-token"))
+		}
+		if !strings.Contains(h.Get("Cookie"), "auth_token=secret") {
+			t.Errorf("session request sent Cookie %q", h.Get("Cookie"))
+		}
+	}
+}
+
+func TestTwitterConcurrentExtract(t *testing.T) {
+	ext, _ := newTwitterTest(t, map[string]http.HandlerFunc{
+		twitterSyndicationURL: respond(http.StatusNotFound, "{}"),
+		twitterGuestTokenURL:  respond(http.StatusOK, `{"guest_token": "g1"}`),
+		twitterGraphQLURL:     respond(http.StatusOK, graphQLVideo),
+	})
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			ext.Extract(t.Context(), "https://x.com/user/status/1")
+		})
+	}
+	wg.Wait()
+	if ext.token() == "" {
+		t.Error("no guest token after extracting")
+	}
+}
@@ -0,0 +1,192 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// twitterMaxThreadLength bounds how many tweets Thread follows
+const twitterMaxThreadLength = 200
+
+// threadTweet is a tweet of a conversation, as far as following a thread
+// needs it
+type threadTweet struct {
+	id         string
+	userID     string
+	replyTo    string
+	screenName string
+	text       string
+	hasMedia   bool
+}
+
+// Thread returns the tweets with media in the thread the tweet at urlStr
+// belongs to: its author's replies to themselves before and after it
+func (t *TwitterExtractor) Thread(ctx context.Context, urlStr string) ([]Entry, error) {
+	matches := twitterURLRegex.FindStringSubmatch(urlStr)
+	if len(matches) < 2 {
+		return nil, fmt.Errorf("could not extract tweet ID from URL")
+	}
+	focalID := matches[1]
+
+	tweets := make(map[string]*threadTweet)
+	fetched := map[string]bool{focalID: true}
+	if err := t.fetchConversation(ctx, focalID, tweets); err != nil {
+		return nil, err
+	}
+	focal := tweets[focalID]
+	if focal == nil {
+		return nil, fmt.Errorf("tweet not found or not accessible")
+	}
+
+	// Earlier tweets come with the conversation; walk up to the first one
+	var chain []*threadTweet
+	for tw := focal; tw != nil && tw.userID == focal.userID; tw = tweets[tw.replyTo] {
+		chain = append(chain, tw)
+	}
+	slices.Reverse(chain)
+
+	// Later ones may need the conversation of the last tweet found so far
+	for len(chain) < twitterMaxThreadLength {
+		last := chain[len(chain)-1]
+		next := selfReply(tweets, last)
+		if next == nil && !fetched[last.id] {
+			fetched[last.id] = true
+			if err := t.fetchConversation(ctx, last.id, tweets); err != nil {
+				return nil, err
+			}
+			next = selfReply(tweets, last)
+		}
+		if next == nil {
+			break
+		}
+		chain = append(chain, next)
+	}
+
+	var entries []Entry
+	for _, tw := range chain {
+		if !tw.hasMedia {
+			continue
+		}
+		screenName := tw.screenName
+		if screenName == "" {
+			screenName = "i"
+		}
+		entries = append(entries, Entry{
+			ID:    tw.id,
+			Title: truncateText(tw.text, 100),
+			URL:   fmt.Sprintf("https://x.com/%s/status/%s", screenName, tw.id),
+		})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no media found in thread")
+	}
+	return entries, nil
+}
+
+// selfReply returns the earliest reply to tw by its author, or nil
+func selfReply(tweets map[string]*threadTweet, tw *threadTweet) *threadTweet {
+	var next *threadTweet
+	for _, reply := range tweets {
+		if reply.replyTo != tw.id || reply.userID != tw.userID {
+			continue
+		}
+		// IDs grow over time; compare numerically
+		if next == nil || len(reply.id) < len(next.id) ||
+			(len(reply.id) == len(next.id) && reply.id < next.id) {
+			next = reply
+		}
+	}
+	return next
+}
+
+// fetchConversation adds the tweets of the conversation around focalID, as
+// returned by the TweetDetail query, to tweets
+func (t *TwitterExtractor) fetchConversation(ctx context.Context, focalID string, tweets map[string]*threadTweet) error {
+	variables := map[string]interface{}{
+		"focalTweetId":                           focalID,
+		"with_rux_injections":                    false,
+		"includePromotedContent":                 false,
+		"withCommunity":                          true,
+		"withQuickPromoteEligibilityTweetFields": false,
+		"withBirdwatchNotes":                     false,
+		"withVoice":                              true,
+		"withV2Timeline":                         true,
+	}
+	body, err := t.graphQLWithToken(ctx, twitterTweetDetailURL, variables)
+	if err != nil {
+		return fmt.Errorf("failed to fetch thread: %w", err)
+	}
+
+	var resp tweetDetailResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("failed to parse TweetDetail response: %w", err)
+	}
+
+	for _, instruction := range resp.Data.Conversation.Instructions {
+		for _, entry := range instruction.Entries {
+			// Single tweets carry itemContent, reply threads a list of items
+			if entry.Content.ItemContent != nil {
+				addThreadTweet(tweets, entry.Content.ItemContent.TweetResults.Result)
+			}
+			for _, item := range entry.Content.Items {
+				if item.Item.ItemContent != nil {
+					addThreadTweet(tweets, item.Item.ItemContent.TweetResults.Result)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// addThreadTweet records result in tweets, skipping anything that isn't a
+// readable tweet (cursors, tombstones)
+func addThreadTweet(tweets map[string]*threadTweet, result *graphQLTweetResult) {
+	if result != nil && result.Legacy == nil && result.Tweet != nil {
+		result = result.Tweet
+	}
+	if result == nil || result.Legacy == nil || result.RestID == "" {
+		return
+	}
+
+	tw := &threadTweet{
+		id:       result.RestID,
+		userID:   result.Legacy.UserID,
+		replyTo:  result.Legacy.InReplyToStatusID,
+		text:     result.Legacy.FullText,
+		hasMedia: result.Legacy.ExtendedEntities != nil && len(result.Legacy.ExtendedEntities.Media) > 0,
+	}
+	if result.Core != nil && result.Core.UserResults.Result != nil {
+		tw.screenName = result.Core.UserResults.Result.Legacy.ScreenName
+	}
+	tweets[tw.id] = tw
+}
+
+// TweetDetail response structures
+type tweetDetailResponse struct {
+	Data struct {
+		Conversation struct {
+			Instructions []struct {
+				Entries []tweetDetailEntry `json:"entries"`
+			} `json:"instructions"`
+		} `json:"threaded_conversation_with_injections_v2"`
+	} `json:"data"`
+}
+
+type tweetDetailEntry struct {
+	Content struct {
+		ItemContent *tweetDetailItem `json:"itemContent"`
+		Items       []struct {
+			Item struct {
+				ItemContent *tweetDetailItem `json:"itemContent"`
+			} `json:"item"`
+		} `json:"items"`
+	} `json:"content"`
+}
+
+type tweetDetailItem struct {
+	TweetResults struct {
+		Result *graphQLTweetResult `json:"result"`
+	} `json:"tweet_results"`
+}
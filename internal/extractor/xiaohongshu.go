@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -24,6 +25,10 @@ func (e *XiaohongshuExtractor) Name() string {
 	return "xiaohongshu"
 }
 
+func (e *XiaohongshuExtractor) Example() string {
+	return "https://www.xiaohongshu.com/explore/<id>"
+}
+
 func (e *XiaohongshuExtractor) Match(u *url.URL) bool {
 	return true
 }
@@ -56,7 +61,7 @@ type xhsNoteDetail struct {
 	} `json:"note"`
 }
 
-func (e *XiaohongshuExtractor) Extract(rawURL string) (Media, error) {
+func (e *XiaohongshuExtractor) Extract(ctx context.Context, rawURL string) (Media, error) {
 	// Resolve short URL if needed
 	finalURL := rawURL
 	if strings.Contains(rawURL, "xhslink.com") {
@@ -74,7 +79,7 @@ func (e *XiaohongshuExtractor) Extract(rawURL string) (Media, error) {
 	}
 
 	// Launch browser and extract data
-	return e.extractWithBrowser(finalURL, noteID)
+	return e.extractWithBrowser(ctx, finalURL, noteID)
 }
 
 func (e *XiaohongshuExtractor) extractNoteID(rawURL string) string {
@@ -112,7 +117,7 @@ func (e *XiaohongshuExtractor) resolveShortURL(shortURL string) (string, error)
 	return page.MustInfo().URL, nil
 }
 
-func (e *XiaohongshuExtractor) extractWithBrowser(targetURL, noteID string) (Media, error) {
+func (e *XiaohongshuExtractor) extractWithBrowser(ctx context.Context, targetURL, noteID string) (Media, error) {
 	// Launch browser (non-headless for now, to handle login if needed)
 	l := e.createLauncher(false)
 	defer l.Cleanup()
@@ -186,7 +191,12 @@ func (e *XiaohongshuExtractor) extractWithBrowser(targetURL, noteID string) (Med
 		remaining := maxWait - elapsed
 		fmt.Printf("\rWaiting for login... %d seconds remaining", int(remaining.Seconds()))
 
-		time.Sleep(checkInterval)
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return nil, ctx.Err()
+		case <-time.After(checkInterval):
+		}
 
 		// Refresh the page state after waiting
 		page.MustWaitDOMStable()
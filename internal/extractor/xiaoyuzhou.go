@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,6 +9,9 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/guiyumin/vget/internal/httpclient"
 )
 
 // XiaoyuzhouExtractor handles xiaoyuzhoufm.com podcast downloads
@@ -17,14 +21,18 @@ func (e *XiaoyuzhouExtractor) Name() string {
 	return "xiaoyuzhou"
 }
 
+func (e *XiaoyuzhouExtractor) Example() string {
+	return "https://www.xiaoyuzhoufm.com/episode/<id>"
+}
+
 func (e *XiaoyuzhouExtractor) Match(u *url.URL) bool {
 	// Host matching is done by registry, check path pattern
 	return strings.HasPrefix(u.Path, "/episode/") || strings.HasPrefix(u.Path, "/podcast/")
 }
 
-func (e *XiaoyuzhouExtractor) Extract(url string) (Media, error) {
+func (e *XiaoyuzhouExtractor) Extract(ctx context.Context, url string) (Media, error) {
 	if strings.Contains(url, "/episode/") {
-		return e.extractEpisode(url)
+		return e.extractEpisode(ctx, url)
 	}
 	if strings.Contains(url, "/podcast/") {
 		return e.extractPodcast(url)
@@ -33,7 +41,7 @@ func (e *XiaoyuzhouExtractor) Extract(url string) (Media, error) {
 }
 
 // extractEpisode extracts a single episode
-func (e *XiaoyuzhouExtractor) extractEpisode(url string) (*AudioMedia, error) {
+func (e *XiaoyuzhouExtractor) extractEpisode(ctx context.Context, url string) (*AudioMedia, error) {
 	// Extract episode ID from URL
 	re := regexp.MustCompile(`/episode/([a-zA-Z0-9]+)`)
 	matches := re.FindStringSubmatch(url)
@@ -42,35 +50,11 @@ func (e *XiaoyuzhouExtractor) extractEpisode(url string) (*AudioMedia, error) {
 	}
 	episodeID := matches[1]
 
-	// Fetch the episode page to get JSON data
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	jsonData, err := fetchNextData(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse the embedded JSON data from the page
-	// Look for the script tag with __NEXT_DATA__
-	content := string(body)
-	jsonStart := strings.Index(content, `<script id="__NEXT_DATA__" type="application/json">`)
-	if jsonStart == -1 {
-		return nil, fmt.Errorf("could not find episode data in page")
-	}
-
-	jsonStart = strings.Index(content[jsonStart:], ">") + jsonStart + 1
-	jsonEnd := strings.Index(content[jsonStart:], "</script>") + jsonStart
-
-	if jsonEnd <= jsonStart {
-		return nil, fmt.Errorf("could not parse episode data")
-	}
-
-	jsonData := content[jsonStart:jsonEnd]
-
 	// Parse the JSON
 	var pageData struct {
 		Props struct {
@@ -90,7 +74,7 @@ func (e *XiaoyuzhouExtractor) extractEpisode(url string) (*AudioMedia, error) {
 		} `json:"props"`
 	}
 
-	if err := json.Unmarshal([]byte(jsonData), &pageData); err != nil {
+	if err := json.Unmarshal(jsonData, &pageData); err != nil {
 		return nil, fmt.Errorf("failed to parse episode JSON: %v", err)
 	}
 
@@ -122,7 +106,79 @@ func (e *XiaoyuzhouExtractor) extractEpisode(url string) (*AudioMedia, error) {
 func (e *XiaoyuzhouExtractor) extractPodcast(_ string) (*AudioMedia, error) {
 	// For now, return an error suggesting to use search
 	// Full podcast download can be implemented later
-	return nil, fmt.Errorf("podcast download not yet implemented. Use 'vget search --podcast <name>' to find specific episodes")
+	return nil, fmt.Errorf("podcast download not yet implemented. Use 'vget <url> --flat' to list episodes, or 'vget search --podcast <name>' to find specific ones")
+}
+
+// List returns the episodes shown on a podcast page
+func (e *XiaoyuzhouExtractor) List(url string) ([]Entry, error) {
+	if !strings.Contains(url, "/podcast/") {
+		return nil, fmt.Errorf("not a podcast URL: %s", url)
+	}
+
+	jsonData, err := fetchNextData(context.Background(), url)
+	if err != nil {
+		return nil, err
+	}
+
+	var pageData struct {
+		Props struct {
+			PageProps struct {
+				Podcast struct {
+					Episodes []struct {
+						Eid   string `json:"eid"`
+						Title string `json:"title"`
+					} `json:"episodes"`
+				} `json:"podcast"`
+			} `json:"pageProps"`
+		} `json:"props"`
+	}
+	if err := json.Unmarshal(jsonData, &pageData); err != nil {
+		return nil, fmt.Errorf("failed to parse podcast JSON: %v", err)
+	}
+
+	var entries []Entry
+	for _, ep := range pageData.Props.PageProps.Podcast.Episodes {
+		entries = append(entries, Entry{
+			ID:    ep.Eid,
+			Title: ep.Title,
+			URL:   "https://www.xiaoyuzhoufm.com/episode/" + ep.Eid,
+		})
+	}
+	return entries, nil
+}
+
+// fetchNextData fetches a page and returns its embedded __NEXT_DATA__ JSON
+func fetchNextData(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpclient.New(30 * time.Second).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look for the script tag with __NEXT_DATA__
+	content := string(body)
+	jsonStart := strings.Index(content, `<script id="__NEXT_DATA__" type="application/json">`)
+	if jsonStart == -1 {
+		return nil, fmt.Errorf("could not find page data")
+	}
+
+	jsonStart = strings.Index(content[jsonStart:], ">") + jsonStart + 1
+	jsonEnd := strings.Index(content[jsonStart:], "</script>") + jsonStart
+
+	if jsonEnd <= jsonStart {
+		return nil, fmt.Errorf("could not parse page data")
+	}
+
+	return []byte(content[jsonStart:jsonEnd]), nil
 }
 
 
@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 )
@@ -12,12 +13,16 @@ func (e *YouTubeExtractor) Name() string {
 	return "youtube"
 }
 
+func (e *YouTubeExtractor) Example() string {
+	return "https://www.youtube.com/watch?v=<id>"
+}
+
 func (e *YouTubeExtractor) Match(u *url.URL) bool {
 	// Host matching is done by registry
 	return true
 }
 
-func (e *YouTubeExtractor) Extract(url string) (Media, error) {
+func (e *YouTubeExtractor) Extract(_ context.Context, url string) (Media, error) {
 	return nil, fmt.Errorf("YouTube support coming soon")
 }
 
@@ -0,0 +1,125 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+	"weak"
+)
+
+// hostSlots caps the connections open to each host across every transport
+// in the process. Each caller builds its own transport, so the transport's
+// own MaxConnsPerHost alone would allow one full set of connections per
+// client. Slots are taken when dialing and given back when the connection
+// is closed.
+var hostSlots = &connLimiter{sems: make(map[slotKey]chan struct{})}
+
+// slotKey identifies a host's semaphore; the limit is part of the key so
+// a reconfigured limit gets fresh semaphores
+type slotKey struct {
+	addr  string
+	limit int
+}
+
+type connLimiter struct {
+	mu         sync.Mutex
+	sems       map[slotKey]chan struct{}
+	transports []weak.Pointer[http.Transport]
+}
+
+// track registers a transport whose idle connections may be closed to
+// free slots for other transports
+func (l *connLimiter) track(t *http.Transport) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.transports = append(l.transports, weak.Make(t))
+}
+
+// closeIdle closes the idle keep-alive connections of all live transports,
+// which otherwise hold their slots until they time out
+func (l *connLimiter) closeIdle() {
+	l.mu.Lock()
+	var live []*http.Transport
+	kept := l.transports[:0]
+	for _, p := range l.transports {
+		if t := p.Value(); t != nil {
+			live = append(live, t)
+			kept = append(kept, p)
+		}
+	}
+	l.transports = kept
+	l.mu.Unlock()
+
+	for _, t := range live {
+		t.CloseIdleConnections()
+	}
+}
+
+func (l *connLimiter) sem(addr string, limit int) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := slotKey{addr, limit}
+	sem, ok := l.sems[key]
+	if !ok {
+		sem = make(chan struct{}, limit)
+		l.sems[key] = sem
+	}
+	return sem
+}
+
+// acquire waits for a free slot for addr
+func (l *connLimiter) acquire(ctx context.Context, addr string, limit int) (chan struct{}, error) {
+	sem := l.sem(addr, limit)
+	select {
+	case sem <- struct{}{}:
+		return sem, nil
+	default:
+	}
+
+	// Connections that went idle while waiting keep their slots too, so
+	// keep freeing them until a slot opens up
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		l.closeIdle()
+		select {
+		case sem <- struct{}{}:
+			return sem, nil
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// limitDial wraps dial so that at most limit connections to each address
+// are open at once. Through a proxy, the address is the proxy's.
+func limitDial(dial dialFunc, limit int) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		sem, err := hostSlots.acquire(ctx, addr, limit)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			<-sem
+			return nil, err
+		}
+		return &slotConn{Conn: conn, sem: sem}, nil
+	}
+}
+
+// slotConn gives its slot back when closed
+type slotConn struct {
+	net.Conn
+	sem  chan struct{}
+	once sync.Once
+}
+
+func (c *slotConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { <-c.sem })
+	return err
+}
@@ -0,0 +1,124 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// dialFunc matches http.Transport.DialContext
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// defaultFallbackDelay is how long IPv6 gets to connect before IPv4 is
+// tried alongside it, as recommended by RFC 8305
+const defaultFallbackDelay = 300 * time.Millisecond
+
+// happyEyeballs returns a dial function that races IPv6 and IPv4
+// connections (RFC 8305) and keeps whichever connects first. IPv6 gets a
+// head start of dialer.FallbackDelay; IPv4 starts early if IPv6 fails.
+// With verbose set, the winning address family is logged to stderr.
+func happyEyeballs(dialer *net.Dialer, verbose bool) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil || network != "tcp" {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var v4, v6 []net.IP
+		for _, ip := range ips {
+			if ip.IP.To4() != nil {
+				v4 = append(v4, ip.IP)
+			} else {
+				v6 = append(v6, ip.IP)
+			}
+		}
+
+		// Nothing to race with a single family
+		if len(v4) == 0 || len(v6) == 0 {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		type result struct {
+			conn   net.Conn
+			family string
+			err    error
+		}
+
+		raceCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		delay := dialer.FallbackDelay
+		if delay <= 0 {
+			delay = defaultFallbackDelay
+		}
+
+		start := time.Now()
+		results := make(chan result, 2)
+		v6Done := make(chan struct{})
+		attempt := func(family, network string, ips []net.IP) {
+			var lastErr error
+			for _, ip := range ips {
+				conn, err := dialer.DialContext(raceCtx, network, net.JoinHostPort(ip.String(), port))
+				if err == nil {
+					results <- result{conn: conn, family: family}
+					return
+				}
+				lastErr = err
+				if raceCtx.Err() != nil {
+					break
+				}
+			}
+			results <- result{family: family, err: lastErr}
+		}
+		go func() {
+			attempt("IPv6", "tcp6", v6)
+			close(v6Done)
+		}()
+		go func() {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-v6Done:
+			case <-raceCtx.Done():
+				results <- result{family: "IPv4", err: raceCtx.Err()}
+				return
+			}
+			attempt("IPv4", "tcp4", v4)
+		}()
+
+		var errs []error
+		for i := 0; i < 2; i++ {
+			r := <-results
+			if r.err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", r.family, r.err))
+				continue
+			}
+
+			cancel()
+			if i == 0 {
+				// Close the losing connection if it still completes
+				go func() {
+					if late := <-results; late.conn != nil {
+						late.conn.Close()
+					}
+				}()
+			}
+			if verbose {
+				fmt.Fprintf(os.Stderr, "[net] %s: connected via %s (%s) in %s\n",
+					host, r.family, r.conn.RemoteAddr(), time.Since(start).Round(time.Millisecond))
+			}
+			return r.conn, nil
+		}
+
+		return nil, errors.Join(errs...)
+	}
+}
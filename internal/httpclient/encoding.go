@@ -0,0 +1,49 @@
+package httpclient
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// IsEncoded reports whether the server applied a Content-Encoding to the body.
+// Range offsets and Content-Length then refer to the encoded stream, so such
+// responses can't be split into chunks or resumed.
+func IsEncoded(h http.Header) bool {
+	enc := strings.TrimSpace(strings.ToLower(h.Get("Content-Encoding")))
+	return enc != "" && enc != "identity"
+}
+
+// gzipReadCloser closes both the gzip reader and the underlying body
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (g *gzipReadCloser) Close() error {
+	g.Reader.Close()
+	return g.body.Close()
+}
+
+// DecodedBody returns a reader over the decoded response body and whether
+// the body was encoded (in which case Content-Length is not the media size).
+// Every download path reads bodies through it, so a server that compresses
+// without being asked never leaves encoded bytes on disk.
+func DecodedBody(resp *http.Response) (io.ReadCloser, bool, error) {
+	if !IsEncoded(resp.Header) {
+		return resp.Body, false, nil
+	}
+
+	switch enc := strings.ToLower(resp.Header.Get("Content-Encoding")); enc {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to decode gzip body: %w", err)
+		}
+		return &gzipReadCloser{Reader: zr, body: resp.Body}, true, nil
+	default:
+		return nil, true, fmt.Errorf("unsupported Content-Encoding %q", enc)
+	}
+}
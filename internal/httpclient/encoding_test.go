@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestDecodedBody(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("hello"))
+	zw.Close()
+
+	tests := []struct {
+		encoding string
+		body     []byte
+		want     string
+		encoded  bool
+		wantErr  bool
+	}{
+		{"", []byte("plain"), "plain", false, false},
+		{"identity", []byte("plain"), "plain", false, false},
+		{"gzip", gz.Bytes(), "hello", true, false},
+		{"x-gzip", gz.Bytes(), "hello", true, false},
+		{"br", []byte("??"), "", true, true},
+		{"gzip", []byte("not gzip"), "", true, true},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(tt.body))}
+		if tt.encoding != "" {
+			resp.Header.Set("Content-Encoding", tt.encoding)
+		}
+		body, encoded, err := DecodedBody(resp)
+		if encoded != tt.encoded || (err != nil) != tt.wantErr {
+			t.Errorf("%q: encoded = %v, err = %v", tt.encoding, encoded, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		got, _ := io.ReadAll(body)
+		body.Close()
+		if string(got) != tt.want {
+			t.Errorf("%q: body = %q, want %q", tt.encoding, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package httpclient
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// countryCIDRs maps ISO 3166-1 alpha-2 codes to a large address block
+// allocated to that country. Used for --geo-bypass-country.
+var countryCIDRs = map[string]string{
+	"AU": "1.128.0.0/11",
+	"BR": "179.128.0.0/10",
+	"CA": "99.224.0.0/11",
+	"CN": "36.128.0.0/10",
+	"DE": "53.0.0.0/8",
+	"ES": "88.0.0.0/11",
+	"FR": "90.0.0.0/9",
+	"GB": "25.0.0.0/8",
+	"HK": "113.252.0.0/14",
+	"IN": "117.192.0.0/10",
+	"IT": "79.0.0.0/10",
+	"JP": "133.0.0.0/8",
+	"KR": "175.192.0.0/10",
+	"MX": "187.192.0.0/11",
+	"NL": "145.96.0.0/11",
+	"RU": "5.136.0.0/13",
+	"SE": "78.64.0.0/12",
+	"SG": "101.100.128.0/17",
+	"TW": "120.96.0.0/11",
+	"US": "6.0.0.0/8",
+}
+
+// GeoBypassCountries returns the supported country codes
+func GeoBypassCountries() []string {
+	codes := make([]string, 0, len(countryCIDRs))
+	for code := range countryCIDRs {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// randomCountryIP picks a random IPv4 address from the country's block
+func randomCountryIP(country string) (string, error) {
+	cidr, ok := countryCIDRs[strings.ToUpper(country)]
+	if !ok {
+		return "", fmt.Errorf("unsupported geo bypass country %q (supported: %s)",
+			country, strings.Join(GeoBypassCountries(), ", "))
+	}
+
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+
+	base := binary.BigEndian.Uint32(ipNet.IP.To4())
+	mask := binary.BigEndian.Uint32(net.IP(ipNet.Mask).To4())
+	addr := base | (rand.Uint32() &^ mask)
+
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, addr)
+	return ip.String(), nil
+}
+
+// headerTransport adds fixed headers to every request
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		if req.Header.Get(k) == "" {
+			req.Header.Set(k, v)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
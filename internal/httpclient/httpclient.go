@@ -0,0 +1,265 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options holds network settings shared by every HTTP client vget creates
+// (extractors, downloader, WebDAV). They are set once from CLI flags.
+type Options struct {
+	// SourceAddress is the local IP outgoing connections are bound to
+	SourceAddress string
+
+	// GeoBypassCountry is a country code whose address space is used for a
+	// fake X-Forwarded-For header. This is best-effort: it only helps with
+	// sites that trust the header and won't defeat real geofencing.
+	GeoBypassCountry string
+
+	// PrintTraffic dumps requests and responses to stderr for debugging
+	PrintTraffic bool
+
+	// HTTPVersion forces "1.1" or "2"; empty means negotiate as usual.
+	// With "2", plain http:// URLs still use HTTP/1.1.
+	HTTPVersion string
+
+	// Verbose logs connection details (e.g. which IP family was used)
+	Verbose bool
+
+	// MaxConnsPerHost caps the connections open to a single host, summed
+	// over all clients; 0 means no limit
+	MaxConnsPerHost int
+
+	// InsecureSkipVerify disables TLS certificate verification, for
+	// networks behind a TLS-intercepting proxy
+	InsecureSkipVerify bool
+
+	// Proxy is the URL of an HTTP(S) or SOCKS5 proxy all requests go
+	// through; empty means use the environment's proxy settings
+	Proxy string
+}
+
+// DefaultMaxConnsPerHost is the default limit on connections to a single host
+const DefaultMaxConnsPerHost = 16
+
+var (
+	mu      sync.RWMutex
+	options Options
+	geoIP   string // Picked once per run from GeoBypassCountry
+	jar     http.CookieJar
+)
+
+// Configure sets the shared network options
+func Configure(o Options) error {
+	if o.SourceAddress != "" {
+		if err := ValidateSourceAddress(o.SourceAddress); err != nil {
+			return err
+		}
+	}
+
+	switch o.HTTPVersion {
+	case "", "auto", "1.1", "2":
+	default:
+		return fmt.Errorf("invalid HTTP version %q (use 1.1 or 2)", o.HTTPVersion)
+	}
+
+	if o.MaxConnsPerHost < 0 {
+		return fmt.Errorf("invalid per-host connection limit: %d", o.MaxConnsPerHost)
+	}
+
+	if o.Proxy != "" {
+		if _, err := parseProxy(o.Proxy); err != nil {
+			return err
+		}
+	}
+
+	var ip string
+	if o.GeoBypassCountry != "" {
+		var err error
+		if ip, err = randomCountryIP(o.GeoBypassCountry); err != nil {
+			return err
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	options = o
+	geoIP = ip
+	return nil
+}
+
+// GeoBypassIP returns the X-Forwarded-For address in use, if any
+func GeoBypassIP() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return geoIP
+}
+
+// SetCookieJar sets the cookie jar used by clients created with New,
+// including the ones created before the call
+func SetCookieJar(j http.CookieJar) {
+	mu.Lock()
+	defer mu.Unlock()
+	jar = j
+}
+
+// cookieJar returns the shared cookie jar, if any
+func cookieJar() http.CookieJar {
+	mu.RLock()
+	defer mu.RUnlock()
+	return jar
+}
+
+// sharedJar is the http.CookieJar of clients created with New. It looks
+// the shared jar up on every request, so extractors built at startup get
+// cookies loaded later; without a shared jar it keeps no cookies.
+type sharedJar struct{}
+
+func (sharedJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if j := cookieJar(); j != nil {
+		j.SetCookies(u, cookies)
+	}
+}
+
+func (sharedJar) Cookies(u *url.URL) []*http.Cookie {
+	if j := cookieJar(); j != nil {
+		return j.Cookies(u)
+	}
+	return nil
+}
+
+// current returns a copy of the shared options
+func current() Options {
+	mu.RLock()
+	defer mu.RUnlock()
+	return options
+}
+
+// ValidateSourceAddress checks that addr is an IP assigned to a local interface
+func ValidateSourceAddress(addr string) error {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return fmt.Errorf("invalid source address: %s", addr)
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return fmt.Errorf("failed to list local interfaces: %w", err)
+	}
+	for _, a := range addrs {
+		if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("source address %s is not assigned to any local interface", addr)
+}
+
+// parseProxy parses a proxy URL, accepting the schemes http.Transport
+// supports. Scheme-less values (host:port) are assumed to be http.
+func parseProxy(raw string) (*url.URL, error) {
+	addr := raw
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q (use http://, https:// or socks5://)", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", raw)
+	}
+	return u, nil
+}
+
+// newDialer creates the dialer used for all outgoing connections
+func newDialer(o Options) *net.Dialer {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if o.SourceAddress != "" {
+		dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(o.SourceAddress)}
+	}
+	return dialer
+}
+
+// NewTransport returns a transport with the shared options applied.
+// Callers may tune connection pooling fields on the result.
+func NewTransport() *http.Transport {
+	o := current()
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := newDialer(o)
+	if o.SourceAddress != "" {
+		// A bound source address fixes the IP family
+		transport.DialContext = dialer.DialContext
+	} else {
+		transport.DialContext = happyEyeballs(dialer, o.Verbose)
+	}
+	// The transport reuses its idle connections before opening new ones,
+	// so unlike counting dials this can't stall on idle keep-alives. It is
+	// keyed by target host, also when going through a proxy.
+	transport.MaxConnsPerHost = o.MaxConnsPerHost
+	if o.MaxConnsPerHost > 0 {
+		// The limit above is per transport; this one spans all of them
+		transport.DialContext = limitDial(transport.DialContext, o.MaxConnsPerHost)
+		hostSlots.track(transport)
+	}
+	if o.Proxy != "" {
+		// Already validated by Configure
+		if u, err := parseProxy(o.Proxy); err == nil {
+			transport.Proxy = http.ProxyURL(u)
+		}
+	}
+	if o.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	// An explicit protocol set takes precedence over ForceAttemptHTTP2,
+	// so callers tuning the transport can't undo the override
+	switch o.HTTPVersion {
+	case "1.1":
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP1(true)
+	case "2":
+		// Without UnencryptedHTTP2 in the set, http:// URLs still use
+		// HTTP/1.1: servers rarely speak HTTP/2 in the clear (h2c)
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP2(true)
+	}
+	return transport
+}
+
+// Wrap adds the shared request middleware (geo bypass header, traffic
+// dump) to a transport. Use it when building a client around NewTransport.
+func Wrap(transport http.RoundTripper) http.RoundTripper {
+	if ip := GeoBypassIP(); ip != "" {
+		transport = &headerTransport{
+			base:    transport,
+			headers: map[string]string{"X-Forwarded-For": ip},
+		}
+	}
+	if current().PrintTraffic {
+		transport = &trafficTransport{base: transport}
+	}
+	return transport
+}
+
+// New returns an HTTP client using the shared transport settings
+func New(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: Wrap(NewTransport()),
+		Jar:       sharedJar{},
+	}
+}
@@ -0,0 +1,157 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// trafficBodyLimit is how much of each response body --print-traffic shows
+const trafficBodyLimit = 1024
+
+// redactedHeaders are never printed in full
+var redactedHeaders = map[string]bool{
+	"Authorization":        true,
+	"Proxy-Authorization":  true,
+	"Cookie":               true,
+	"Set-Cookie":           true,
+	"X-Csrf-Token":         true,
+	"X-Amz-Security-Token": true,
+}
+
+// redactedParams are query parameters whose values are never printed:
+// signatures and tokens of signed CDN and S3 URLs, API keys and the like
+var redactedParams = map[string]bool{
+	"sig":                  true,
+	"signature":            true,
+	"token":                true,
+	"access_token":         true,
+	"refresh_token":        true,
+	"auth":                 true,
+	"key":                  true,
+	"api_key":              true,
+	"apikey":               true,
+	"password":             true,
+	"secret":               true,
+	"policy":               true,
+	"key-pair-id":          true,
+	"x-amz-signature":      true,
+	"x-amz-credential":     true,
+	"x-amz-security-token": true,
+	"x-goog-signature":     true,
+	"x-goog-credential":    true,
+}
+
+// trafficMu keeps concurrent dumps from interleaving
+var trafficMu sync.Mutex
+
+// trafficTransport logs every request and response to stderr
+type trafficTransport struct {
+	base http.RoundTripper
+}
+
+func (t *trafficTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "> %s %s\n", req.Method, redactURL(req.URL))
+	writeHeaders(&b, "> ", req.Header)
+
+	if err != nil {
+		fmt.Fprintf(&b, "< error: %v\n", err)
+		printTraffic(b.String())
+		return nil, err
+	}
+
+	fmt.Fprintf(&b, "< %s %s\n", resp.Proto, resp.Status)
+	writeHeaders(&b, "< ", resp.Header)
+
+	// Peek at the start of the body without consuming it
+	head := make([]byte, trafficBodyLimit)
+	n, _ := io.ReadFull(resp.Body, head)
+	head = head[:n]
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+
+	if n > 0 {
+		if isTextContent(resp.Header.Get("Content-Type")) {
+			fmt.Fprintf(&b, "<\n%s\n", head)
+			if n == trafficBodyLimit {
+				b.WriteString("< (truncated)\n")
+			}
+		} else {
+			fmt.Fprintf(&b, "< (%d+ bytes of binary body)\n", n)
+		}
+	}
+
+	printTraffic(b.String())
+	return resp, nil
+}
+
+// writeHeaders writes headers in sorted order with secrets redacted
+func writeHeaders(b *strings.Builder, prefix string, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		value := strings.Join(h[k], ", ")
+		switch {
+		case redactedHeaders[http.CanonicalHeaderKey(k)]:
+			value = "<redacted>"
+		case http.CanonicalHeaderKey(k) == "Location":
+			// Redirects often point at signed URLs
+			if u, err := url.Parse(value); err == nil {
+				value = redactURL(u)
+			}
+		}
+		fmt.Fprintf(b, "%s%s: %s\n", prefix, k, value)
+	}
+}
+
+// redactURL returns u with its password and the values of sensitive
+// query parameters masked, keeping the parameters in their order
+func redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	c := *u
+	params := strings.Split(u.RawQuery, "&")
+	for i, p := range params {
+		name, _, hasValue := strings.Cut(p, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if hasValue && redactedParams[strings.ToLower(name)] {
+			params[i] = p[:strings.Index(p, "=")+1] + "<redacted>"
+		}
+	}
+	c.RawQuery = strings.Join(params, "&")
+	return c.Redacted()
+}
+
+// isTextContent reports whether a Content-Type is worth printing
+func isTextContent(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	return strings.HasPrefix(ct, "text/") ||
+		strings.Contains(ct, "json") ||
+		strings.Contains(ct, "xml") ||
+		strings.Contains(ct, "javascript") ||
+		strings.Contains(ct, "mpegurl")
+}
+
+func printTraffic(s string) {
+	trafficMu.Lock()
+	defer trafficMu.Unlock()
+	fmt.Fprintln(os.Stderr, s)
+}
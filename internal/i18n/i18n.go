@@ -69,6 +69,8 @@ type DownloadTranslations struct {
 	Elapsed          string `yaml:"elapsed"`
 	AvgSpeed         string `yaml:"avg_speed"`
 	FileSaved        string `yaml:"file_saved"`
+	Uploading        string `yaml:"uploading"`
+	Uploaded         string `yaml:"uploaded"`
 	NoFormats        string `yaml:"no_formats"`
 	SelectFormat     string `yaml:"select_format"`
 	FormatsAvailable string `yaml:"formats_available"`
@@ -0,0 +1,31 @@
+package muxer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MergeFormats are the containers Merge can write. mkv accepts nearly any
+// codec; mp4 is more widely playable but rejects some (e.g. Vorbis audio).
+var MergeFormats = []string{"mkv", "mp4"}
+
+// ValidateMergeFormat checks that format is one of MergeFormats
+func ValidateMergeFormat(format string) error {
+	for _, f := range MergeFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported merge format %q (use %s)", format, strings.Join(MergeFormats, " or "))
+}
+
+// Merge combines a video-only and an audio-only file into output without
+// re-encoding. The container is taken from output's extension.
+func Merge(video, audio, output string) error {
+	if err := run("-i", video, "-i", audio, "-map", "0:v:0", "-map", "1:a:0", "-c", "copy", output); err != nil {
+		os.Remove(output)
+		return fmt.Errorf("can't merge into %s (codecs may not be supported by the container): %w", output, err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package muxer
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AudioFormats are the formats supported by ExtractAudio
+var AudioFormats = []string{"mp3", "m4a"}
+
+// ValidateAudioFormat checks that format is one of AudioFormats
+func ValidateAudioFormat(format string) error {
+	for _, f := range AudioFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported audio format %q (use %s)", format, strings.Join(AudioFormats, " or "))
+}
+
+var bitrateRegex = regexp.MustCompile(`^\d+k$`)
+
+// ValidateAudioQuality checks that quality is a VBR level (0 best, 9 worst)
+// or a bitrate such as 192k. An empty quality selects the defaults.
+func ValidateAudioQuality(quality string) error {
+	if quality == "" || bitrateRegex.MatchString(quality) {
+		return nil
+	}
+	if n, err := strconv.Atoi(quality); err == nil && n >= 0 && n <= 9 {
+		return nil
+	}
+	return fmt.Errorf("invalid audio quality %q (use a VBR level 0-9 or a bitrate like 192k)", quality)
+}
+
+// audioQualityArgs returns the ffmpeg rate arguments for quality. AAC has
+// no comparable VBR scale, so levels map onto bitrates from 256k down.
+func audioQualityArgs(quality, format string) []string {
+	if quality == "" {
+		if format == "m4a" {
+			return []string{"-b:a", "192k"}
+		}
+		return []string{"-q:a", "2"}
+	}
+	if bitrateRegex.MatchString(quality) {
+		return []string{"-b:a", quality}
+	}
+	if format == "m4a" {
+		level, _ := strconv.Atoi(quality)
+		return []string{"-b:a", fmt.Sprintf("%dk", 256-level*20)}
+	}
+	return []string{"-q:a", quality}
+}
+
+// ffmpegPath returns the ffmpeg binary to use
+func ffmpegPath() (string, error) {
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg is required for this operation: %w", err)
+	}
+	return path, nil
+}
+
+// Available reports whether ffmpeg is installed
+func Available() bool {
+	_, err := ffmpegPath()
+	return err == nil
+}
+
+// run invokes ffmpeg with args, returning the end of its log on failure
+func run(args ...string) error {
+	ffmpeg, err := ffmpegPath()
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(ffmpeg, append([]string{"-hide_banner", "-loglevel", "error", "-y"}, args...)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if i := strings.LastIndex(msg, "\n"); i >= 0 {
+			msg = msg[i+1:]
+		}
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, msg)
+	}
+	return nil
+}
+
+// ExtractAudio writes the audio track of input to output in the given
+// format and quality (see ValidateAudioQuality). Without an explicit
+// quality, m4a copies AAC audio as-is when possible; mp3 is transcoded.
+func ExtractAudio(input, output, format, quality string) error {
+	if err := ValidateAudioFormat(format); err != nil {
+		return err
+	}
+	if err := ValidateAudioQuality(quality); err != nil {
+		return err
+	}
+
+	rate := audioQualityArgs(quality, format)
+	switch format {
+	case "m4a":
+		if quality == "" {
+			if err := run("-i", input, "-vn", "-c:a", "copy", output); err == nil {
+				return nil
+			}
+		}
+		// Not AAC or a specific quality was asked for, transcode
+		return run(append([]string{"-i", input, "-vn", "-c:a", "aac"}, append(rate, output)...)...)
+	default:
+		return run(append([]string{"-i", input, "-vn", "-c:a", "libmp3lame"}, append(rate, output)...)...)
+	}
+}
@@ -0,0 +1,45 @@
+package muxer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Containers are the formats supported by Remux
+var Containers = []string{"mp4", "mkv", "webm"}
+
+// ValidateContainer checks that container is one of Containers
+func ValidateContainer(container string) error {
+	for _, c := range Containers {
+		if container == c {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported container %q (use %s)", container, strings.Join(Containers, ", "))
+}
+
+// Remux rewrites file into another container without re-encoding and
+// removes the original. It returns the new filename. It fails if the
+// streams' codecs aren't allowed in the target container (e.g. H.264 in webm).
+func Remux(file, container string) (string, error) {
+	if err := ValidateContainer(container); err != nil {
+		return "", err
+	}
+
+	ext := filepath.Ext(file)
+	if strings.EqualFold(strings.TrimPrefix(ext, "."), container) {
+		return file, nil
+	}
+	out := strings.TrimSuffix(file, ext) + "." + container
+
+	if err := run("-i", file, "-c", "copy", "-map", "0", out); err != nil {
+		os.Remove(out)
+		return "", fmt.Errorf("can't remux %s to %s (codecs may not be supported by the container): %w", file, container, err)
+	}
+	if err := os.Remove(file); err != nil {
+		return out, err
+	}
+	return out, nil
+}
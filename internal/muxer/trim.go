@@ -0,0 +1,75 @@
+package muxer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseTimeRange parses "START-END" where each side is seconds, MM:SS or
+// HH:MM:SS (fractions allowed, e.g. "00:01:00-00:02:30.5"). END may be
+// empty to mean the end of the media.
+func ParseTimeRange(s string) (start, end time.Duration, err error) {
+	startStr, endStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid time range %q (use START-END, e.g. 00:01:00-00:02:30)", s)
+	}
+
+	if start, err = parseTimestamp(startStr); err != nil {
+		return 0, 0, err
+	}
+	if endStr != "" {
+		if end, err = parseTimestamp(endStr); err != nil {
+			return 0, 0, err
+		}
+		if end <= start {
+			return 0, 0, fmt.Errorf("invalid time range %q: end must be after start", s)
+		}
+	}
+	return start, end, nil
+}
+
+// parseTimestamp parses seconds, MM:SS or HH:MM:SS
+func parseTimestamp(s string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 || parts[0] == "" {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	var total float64
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || v < 0 || (i > 0 && v >= 60) {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		total = total*60 + v
+	}
+	return time.Duration(total * float64(time.Second)), nil
+}
+
+// formatTimestamp formats d for ffmpeg (seconds with millisecond precision)
+func formatTimestamp(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// Trim cuts file in place to start..end (zero end keeps the rest) without
+// re-encoding. Cuts snap to the nearest preceding keyframe.
+func Trim(file string, start, end time.Duration) error {
+	ext := filepath.Ext(file)
+	tmp := strings.TrimSuffix(file, ext) + ".trim" + ext
+
+	args := []string{"-ss", formatTimestamp(start)}
+	if end > 0 {
+		args = append(args, "-to", formatTimestamp(end))
+	}
+	args = append(args, "-i", file, "-c", "copy", "-map", "0", tmp)
+
+	if err := run(args...); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, file)
+}
@@ -0,0 +1,304 @@
+package s3
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// MinPartSize is the smallest part size S3 accepts (except for the last part)
+const MinPartSize = 5 * 1024 * 1024
+
+// MaxUploads is the number of parts uploaded at the same time. Writers block
+// while that many are in flight, so memory use stays bounded.
+const MaxUploads = 4
+
+// MultipartWriter streams data into an S3 object using a multipart upload.
+// It implements io.Writer for sequential writes and io.WriterAt for parallel
+// ranged downloads: each part is buffered until all of its bytes have arrived
+// and is then uploaded. Objects that fit in a single part, including empty
+// ones, are sent with a plain PutObject on Close instead.
+type MultipartWriter struct {
+	ctx      context.Context
+	client   *Client
+	bucket   string
+	key      string
+	partSize int64
+	size     int64 // expected total size, or -1 if unknown
+
+	uploads   chan struct{} // one slot per part being uploaded
+	startOnce sync.Once
+
+	mu       sync.Mutex
+	uploadID string // set once the first part is uploaded
+	parts    map[int]*pendingPart
+	sent     map[int]bool // parts handed off for upload
+	done     []CompletedPart
+	offset   int64 // next offset for sequential Write
+	err      error
+	uploadWg sync.WaitGroup
+}
+
+type pendingPart struct {
+	data    []byte
+	written []byteRange // sorted, non-overlapping
+}
+
+// byteRange is the half-open range [start, end) of a part
+type byteRange struct {
+	start, end int64
+}
+
+// mark records that [start, end) of the part has been written. Ranges are
+// merged, so rewriting bytes (a retried chunk) doesn't count them twice.
+func (p *pendingPart) mark(start, end int64) {
+	merged := []byteRange{{start, end}}
+	for _, r := range p.written {
+		if r.end < start || r.start > end {
+			merged = append(merged, r)
+			continue
+		}
+		merged[0].start = min(merged[0].start, r.start)
+		merged[0].end = max(merged[0].end, r.end)
+	}
+	slices.SortFunc(merged, func(a, b byteRange) int {
+		return cmp.Compare(a.start, b.start)
+	})
+	p.written = merged
+}
+
+// filled returns how many bytes from the start of the part have been written
+// without a gap
+func (p *pendingPart) filled() int64 {
+	if len(p.written) == 0 || p.written[0].start != 0 {
+		return 0
+	}
+	return p.written[0].end
+}
+
+// NewMultipartWriter prepares an upload to s3://bucket/key.
+// size is the expected object size (-1 if unknown).
+func NewMultipartWriter(ctx context.Context, client *Client, s3URL string, partSize, size int64) (*MultipartWriter, error) {
+	bucket, key, err := ParseURL(s3URL)
+	if err != nil {
+		return nil, err
+	}
+	if partSize < MinPartSize {
+		partSize = MinPartSize
+	}
+
+	return &MultipartWriter{
+		ctx:      ctx,
+		client:   client,
+		bucket:   bucket,
+		key:      key,
+		partSize: partSize,
+		size:     size,
+		uploads:  make(chan struct{}, MaxUploads),
+		parts:    make(map[int]*pendingPart),
+		sent:     make(map[int]bool),
+	}, nil
+}
+
+// Write appends p sequentially
+func (w *MultipartWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	off := w.offset
+	w.offset += int64(len(p))
+	w.mu.Unlock()
+	return w.WriteAt(p, off)
+}
+
+// WriteAt buffers p at offset off, uploading any part that becomes complete
+func (w *MultipartWriter) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	if w.err != nil {
+		w.mu.Unlock()
+		return 0, w.err
+	}
+	if w.size > 0 && off+int64(len(p)) > w.size {
+		w.mu.Unlock()
+		return 0, fmt.Errorf("write past the end of the %d byte object", w.size)
+	}
+
+	type fullPart struct {
+		index int
+		data  []byte
+	}
+	var full []fullPart
+	written := 0
+	for written < len(p) {
+		pos := off + int64(written)
+		index := int(pos / w.partSize)
+		partOff := pos - int64(index)*w.partSize
+
+		if w.sent[index] {
+			w.mu.Unlock()
+			return written, fmt.Errorf("part %d was already uploaded", index+1)
+		}
+		part, ok := w.parts[index]
+		if !ok {
+			part = &pendingPart{data: make([]byte, w.partLength(index))}
+			w.parts[index] = part
+		}
+
+		n := copy(part.data[partOff:], p[written:])
+		part.mark(partOff, partOff+int64(n))
+		written += n
+
+		// A part that is the whole object waits for Close to use PutObject
+		if part.filled() == int64(len(part.data)) && !w.singlePart() {
+			delete(w.parts, index)
+			w.sent[index] = true
+			full = append(full, fullPart{index, part.data})
+		}
+	}
+	w.mu.Unlock()
+
+	for _, part := range full {
+		if err := w.upload(part.index, part.data); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// partLength returns the expected length of the given part
+func (w *MultipartWriter) partLength(index int) int64 {
+	if w.size <= 0 {
+		return w.partSize
+	}
+	start := int64(index) * w.partSize
+	if remaining := w.size - start; remaining < w.partSize {
+		return remaining
+	}
+	return w.partSize
+}
+
+// singlePart reports whether the whole object is known to fit in one part
+func (w *MultipartWriter) singlePart() bool {
+	return w.size > 0 && w.size <= w.partSize
+}
+
+// start creates the multipart upload the first time it is needed
+func (w *MultipartWriter) start() error {
+	w.startOnce.Do(func() {
+		uploadID, err := w.client.CreateMultipartUpload(w.ctx, w.bucket, w.key)
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if err != nil {
+			if w.err == nil {
+				w.err = fmt.Errorf("failed to start multipart upload: %w", err)
+			}
+			return
+		}
+		w.uploadID = uploadID
+	})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// upload sends a finished part in the background, waiting first while
+// MaxUploads parts are already in flight
+func (w *MultipartWriter) upload(index int, data []byte) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	w.uploads <- struct{}{}
+	w.uploadWg.Add(1)
+	go func() {
+		defer func() {
+			<-w.uploads
+			w.uploadWg.Done()
+		}()
+		etag, err := w.client.UploadPart(w.ctx, w.bucket, w.key, w.uploadID, index+1, data)
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if err != nil {
+			if w.err == nil {
+				w.err = fmt.Errorf("failed to upload part %d: %w", index+1, err)
+			}
+			return
+		}
+		w.done = append(w.done, CompletedPart{PartNumber: index + 1, ETag: etag})
+	}()
+	return nil
+}
+
+// Close uploads any remaining data and completes the upload
+func (w *MultipartWriter) Close() error {
+	w.mu.Lock()
+	indexes := make([]int, 0, len(w.parts))
+	for index := range w.parts {
+		indexes = append(indexes, index)
+	}
+	slices.Sort(indexes)
+
+	remaining := make([][]byte, len(indexes))
+	for i, index := range indexes {
+		part := w.parts[index]
+		n := part.filled()
+		if len(part.written) != 1 || (w.size > 0 && n < int64(len(part.data))) {
+			w.mu.Unlock()
+			w.Abort()
+			return fmt.Errorf("part %d is incomplete", index+1)
+		}
+		remaining[i] = part.data[:n]
+	}
+	multipart := len(w.sent) > 0 || len(indexes) > 1 || (len(indexes) == 1 && indexes[0] != 0)
+	w.mu.Unlock()
+
+	if !multipart {
+		var data []byte
+		if len(remaining) == 1 {
+			data = remaining[0]
+		}
+		if err := w.client.PutObject(w.ctx, w.bucket, w.key, data); err != nil {
+			return fmt.Errorf("failed to upload object: %w", err)
+		}
+		return nil
+	}
+
+	for i, index := range indexes {
+		if err := w.upload(index, remaining[i]); err != nil {
+			w.Abort()
+			return err
+		}
+	}
+	w.uploadWg.Wait()
+
+	w.mu.Lock()
+	err := w.err
+	parts := w.done
+	w.mu.Unlock()
+
+	if err != nil {
+		w.Abort()
+		return err
+	}
+	if err := w.client.CompleteMultipartUpload(w.ctx, w.bucket, w.key, w.uploadID, parts); err != nil {
+		w.Abort()
+		return err
+	}
+	return nil
+}
+
+// Abort cancels the multipart upload, discarding any uploaded parts
+func (w *MultipartWriter) Abort() error {
+	w.uploadWg.Wait()
+
+	w.mu.Lock()
+	uploadID := w.uploadID
+	w.mu.Unlock()
+	if uploadID == "" {
+		return nil
+	}
+	return w.client.AbortMultipartUpload(context.Background(), w.bucket, w.key, uploadID)
+}
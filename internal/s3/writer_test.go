@@ -0,0 +1,211 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 stores objects and multipart uploads in memory
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	parts    map[int][]byte
+	requests []string // "PUT object", "POST uploads", ...
+	inFlight int
+	maxParts int // most UploadPart requests seen at once
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *Client) {
+	t.Helper()
+	f := &fakeS3{objects: make(map[string][]byte), parts: make(map[int][]byte)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	client := &Client{
+		endpoint:   srv.URL,
+		region:     "us-east-1",
+		accessKey:  "key",
+		secretKey:  "secret",
+		httpClient: srv.Client(),
+	}
+	return f, client
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	query := r.URL.Query()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == "POST" && query.Has("uploads"):
+		f.requests = append(f.requests, "create")
+		fmt.Fprint(w, "<InitiateMultipartUploadResult><UploadId>up1</UploadId></InitiateMultipartUploadResult>")
+	case r.Method == "PUT" && query.Has("partNumber"):
+		f.requests = append(f.requests, "part")
+		f.inFlight++
+		f.maxParts = max(f.maxParts, f.inFlight)
+		f.mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		f.mu.Lock()
+		f.inFlight--
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		f.parts[n] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag%d"`, n))
+	case r.Method == "PUT":
+		f.requests = append(f.requests, "put")
+		f.objects[r.URL.Path] = body
+	case r.Method == "POST" && query.Has("uploadId"):
+		f.requests = append(f.requests, "complete")
+		var complete struct {
+			Parts []CompletedPart `xml:"Part"`
+		}
+		xml.Unmarshal(body, &complete)
+		if len(complete.Parts) == 0 {
+			http.Error(w, "<Error>MalformedXML</Error>", http.StatusBadRequest)
+			return
+		}
+		var object []byte
+		for _, p := range complete.Parts {
+			object = append(object, f.parts[p.PartNumber]...)
+		}
+		f.objects[r.URL.Path] = object
+		fmt.Fprint(w, "<CompleteMultipartUploadResult/>")
+	case r.Method == "DELETE":
+		f.requests = append(f.requests, "abort")
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func testData(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 253)
+	}
+	return b
+}
+
+func TestMultipartWriterSmallObjects(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		size int64
+		data []byte
+	}{
+		{"empty, unknown size", -1, nil},
+		{"empty, zero size", 0, nil},
+		{"small, unknown size", -1, testData(1000)},
+		{"small, known size", 1000, testData(1000)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, client := newFakeS3(t)
+			w, err := NewMultipartWriter(context.Background(), client, "s3://bucket/key", MinPartSize, tt.size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(tt.data); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			if fmt.Sprint(f.requests) != "[put]" {
+				t.Errorf("requests = %v, want a single PutObject", f.requests)
+			}
+			if got := f.objects["/bucket/key"]; !bytes.Equal(got, tt.data) {
+				t.Errorf("object has %d bytes, want %d", len(got), len(tt.data))
+			}
+		})
+	}
+}
+
+func TestMultipartWriterRewrittenChunks(t *testing.T) {
+	f, client := newFakeS3(t)
+	data := testData(3*MinPartSize + 100)
+	w, err := NewMultipartWriter(context.Background(), client, "s3://bucket/key", MinPartSize, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Parts arrive out of order, and the start of part 2 is written twice
+	// (a retried chunk). Counting bytes would upload it before its tail.
+	half := int64(MinPartSize / 2)
+	writes := []struct{ start, end int64 }{
+		{3 * MinPartSize, int64(len(data))},
+		{MinPartSize, MinPartSize + half},
+		{MinPartSize, MinPartSize + half},
+		{0, MinPartSize},
+		{2 * MinPartSize, 3 * MinPartSize},
+		{MinPartSize + half, 2 * MinPartSize},
+	}
+	for _, wr := range writes {
+		if _, err := w.WriteAt(data[wr.start:wr.end], wr.start); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := f.objects["/bucket/key"]; !bytes.Equal(got, data) {
+		t.Fatal("assembled object differs from the written data")
+	}
+	numbers := make([]int, 0, len(f.parts))
+	for n := range f.parts {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	if fmt.Sprint(numbers) != "[1 2 3 4]" {
+		t.Errorf("uploaded parts = %v, want [1 2 3 4]", numbers)
+	}
+}
+
+func TestMultipartWriterLimitsConcurrentUploads(t *testing.T) {
+	f, client := newFakeS3(t)
+	data := testData(3 * MaxUploads * MinPartSize)
+	w, err := NewMultipartWriter(context.Background(), client, "s3://bucket/key", MinPartSize, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if f.maxParts > MaxUploads {
+		t.Errorf("%d parts uploaded at once, want at most %d", f.maxParts, MaxUploads)
+	}
+	if got := f.objects["/bucket/key"]; !bytes.Equal(got, data) {
+		t.Fatal("assembled object differs from the written data")
+	}
+}
+
+func TestMultipartWriterIncompleteObject(t *testing.T) {
+	f, client := newFakeS3(t)
+	data := testData(2 * MinPartSize)
+	w, err := NewMultipartWriter(context.Background(), client, "s3://bucket/key", MinPartSize, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteAt(data[:MinPartSize+10], 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err == nil {
+		t.Fatal("Close succeeded with part 2 missing its tail")
+	}
+	if _, ok := f.objects["/bucket/key"]; ok {
+		t.Error("incomplete object was committed")
+	}
+}
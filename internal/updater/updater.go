@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"strings"
 
 	"github.com/creativeprojects/go-selfupdate"
 	"github.com/guiyumin/vget/internal/version"
@@ -14,16 +15,52 @@ const (
 	repoName  = "vget"
 )
 
-// CheckUpdate checks if a new version is available
-func CheckUpdate() (*selfupdate.Release, bool, error) {
+// Update channels
+const (
+	ChannelStable  = "stable"
+	ChannelNightly = "nightly"
+)
+
+// Options controls which release Update installs
+type Options struct {
+	// Channel is "stable" (default) or "nightly" (includes pre-releases)
+	Channel string
+
+	// VersionTag pins a specific release (e.g. "v0.5.0"), which may be a downgrade
+	VersionTag string
+}
+
+// ValidateChannel checks that channel is a known update channel
+func ValidateChannel(channel string) error {
+	switch channel {
+	case "", ChannelStable, ChannelNightly:
+		return nil
+	default:
+		return fmt.Errorf("invalid update channel %q (use %s or %s)", channel, ChannelStable, ChannelNightly)
+	}
+}
+
+// newUpdater creates an updater for the given channel
+func newUpdater(channel string) (*selfupdate.Updater, error) {
 	source, err := selfupdate.NewGitHubSource(selfupdate.GitHubConfig{})
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
-	updater, err := selfupdate.NewUpdater(selfupdate.Config{
-		Source: source,
+	return selfupdate.NewUpdater(selfupdate.Config{
+		Source:     source,
+		Prerelease: channel == ChannelNightly,
 	})
+}
+
+// currentVersion returns version.Version without the 'v' prefix
+func currentVersion() string {
+	return strings.TrimPrefix(version.Version, "v")
+}
+
+// CheckUpdate checks if a new version is available
+func CheckUpdate() (*selfupdate.Release, bool, error) {
+	updater, err := newUpdater(ChannelStable)
 	if err != nil {
 		return nil, false, err
 	}
@@ -37,13 +74,7 @@ func CheckUpdate() (*selfupdate.Release, bool, error) {
 		return nil, false, nil
 	}
 
-	currentVersion := version.Version
-	// Remove 'v' prefix if present for comparison
-	if len(currentVersion) > 0 && currentVersion[0] == 'v' {
-		currentVersion = currentVersion[1:]
-	}
-
-	if latest.LessOrEqual(currentVersion) {
+	if latest.LessOrEqual(currentVersion()) {
 		return latest, false, nil
 	}
 
@@ -51,51 +82,68 @@ func CheckUpdate() (*selfupdate.Release, bool, error) {
 }
 
 // Update performs the self-update
-func Update() error {
-	source, err := selfupdate.NewGitHubSource(selfupdate.GitHubConfig{})
-	if err != nil {
+func Update(opts Options) error {
+	if err := ValidateChannel(opts.Channel); err != nil {
 		return err
 	}
 
-	updater, err := selfupdate.NewUpdater(selfupdate.Config{
-		Source: source,
-	})
+	// A pinned tag may be a pre-release, so search those too
+	channel := opts.Channel
+	if opts.VersionTag != "" {
+		channel = ChannelNightly
+	}
+
+	updater, err := newUpdater(channel)
 	if err != nil {
 		return err
 	}
 
-	latest, found, err := updater.DetectLatest(context.Background(), selfupdate.NewRepositorySlug(repoOwner, repoName))
+	ctx := context.Background()
+	repo := selfupdate.NewRepositorySlug(repoOwner, repoName)
+
+	var release *selfupdate.Release
+	var found bool
+	if opts.VersionTag != "" {
+		release, found, err = updater.DetectVersion(ctx, repo, opts.VersionTag)
+	} else {
+		release, found, err = updater.DetectLatest(ctx, repo)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to check for updates: %w", err)
 	}
 
 	if !found {
+		if opts.VersionTag != "" {
+			return fmt.Errorf("release %s not found for %s/%s", opts.VersionTag, repoOwner, repoName)
+		}
 		return fmt.Errorf("no releases found for %s/%s", repoOwner, repoName)
 	}
 
-	currentVersion := version.Version
-	if len(currentVersion) > 0 && currentVersion[0] == 'v' {
-		currentVersion = currentVersion[1:]
-	}
-
-	if latest.LessOrEqual(currentVersion) {
-		fmt.Printf("Already up to date (v%s)\n", currentVersion)
+	current := currentVersion()
+	if opts.VersionTag != "" {
+		// Pinned: install exactly that version, upgrading or downgrading
+		if release.Equal(current) {
+			fmt.Printf("Already at v%s\n", current)
+			return nil
+		}
+	} else if release.LessOrEqual(current) {
+		fmt.Printf("Already up to date (v%s)\n", current)
 		return nil
 	}
 
-	fmt.Printf("Updating from v%s to %s...\n", currentVersion, latest.Version())
+	fmt.Printf("Updating from v%s to %s...\n", current, release.Version())
 
 	exe, err := selfupdate.ExecutablePath()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
 
-	err = updater.UpdateTo(context.Background(), latest, exe)
+	err = updater.UpdateTo(ctx, release, exe)
 	if err != nil {
 		return fmt.Errorf("failed to update: %w", err)
 	}
 
-	fmt.Printf("Successfully updated to %s\n", latest.Version())
+	fmt.Printf("Successfully updated to %s\n", release.Version())
 	return nil
 }
 
@@ -0,0 +1,37 @@
+package webdav
+
+import (
+	"context"
+	"strings"
+)
+
+// checksumAlgorithms lists supported algorithms, strongest first
+var checksumAlgorithms = []string{"sha256", "sha1", "md5"}
+
+// Checksum returns a checksum the server advertises for a file as
+// "algorithm:hex" (e.g. "sha1:2fd4e1c6..."), or "" if it provides none
+func (c *Client) Checksum(ctx context.Context, filePath string) (string, error) {
+	p, err := c.props(ctx, filePath)
+	if err != nil {
+		return "", err
+	}
+	return p.Checksum, nil
+}
+
+// pickChecksum selects the strongest supported checksum from a property
+// value like "SHA1:abc MD5:def ADLER32:123"
+func pickChecksum(value string) string {
+	sums := make(map[string]string)
+	for _, field := range strings.Fields(value) {
+		algo, sum, ok := strings.Cut(field, ":")
+		if ok && sum != "" {
+			sums[strings.ToLower(algo)] = strings.ToLower(sum)
+		}
+	}
+	for _, algo := range checksumAlgorithms {
+		if sum, ok := sums[algo]; ok {
+			return algo + ":" + sum
+		}
+	}
+	return ""
+}
@@ -9,9 +9,11 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/emersion/go-webdav"
 	"github.com/guiyumin/vget/internal/config"
+	"github.com/guiyumin/vget/internal/httpclient"
 )
 
 // Client wraps go-webdav client with convenience methods
@@ -20,6 +22,14 @@ type Client struct {
 	baseURL  string
 	username string
 	password string
+	token    string
+
+	// http is the authenticated client used for requests go-webdav doesn't make
+	http webdav.HTTPClient
+
+	// digest is set when credentials are sent with Digest auth, which
+	// can't be expressed as a static Authorization header
+	digest bool
 }
 
 // FileInfo contains information about a remote file
@@ -28,6 +38,18 @@ type FileInfo struct {
 	Path string
 	Size int64
 	IsDir bool
+
+	// MIMEType is the Content-Type reported by the server, if any
+	MIMEType string
+
+	// Checksum is "algorithm:hex" when the server provides one (oc:checksums)
+	Checksum string
+
+	// DisplayName is the server's DAV:displayname, if set
+	DisplayName string
+
+	// ModTime is the server's DAV:getlastmodified, zero if unknown
+	ModTime time.Time
 }
 
 // NewClient creates a new WebDAV client
@@ -50,11 +72,11 @@ func NewClient(rawURL string) (*Client, error) {
 	baseURL := fmt.Sprintf("%s://%s", scheme, parsed.Host)
 
 	// Extract credentials and create HTTP client
-	var httpClient webdav.HTTPClient
+	var httpClient webdav.HTTPClient = httpclient.New(0)
 	if parsed.User != nil {
 		username := parsed.User.Username()
 		password, _ := parsed.User.Password()
-		httpClient = webdav.HTTPClientWithBasicAuth(nil, username, password)
+		httpClient = webdav.HTTPClientWithBasicAuth(httpClient, username, password)
 	}
 
 	client, err := webdav.NewClient(httpClient, baseURL)
@@ -73,6 +95,7 @@ func NewClient(rawURL string) (*Client, error) {
 		baseURL:  baseURL,
 		username: username,
 		password: password,
+		http:     httpClient,
 	}, nil
 }
 
@@ -92,12 +115,22 @@ func (c *Client) Stat(ctx context.Context, filePath string) (*FileInfo, error) {
 		return nil, fmt.Errorf("failed to stat %s: %w", filePath, err)
 	}
 
-	return &FileInfo{
-		Name:  path.Base(info.Path),
-		Path:  info.Path,
-		Size:  info.Size,
-		IsDir: info.IsDir,
-	}, nil
+	fi := &FileInfo{
+		Name:     path.Base(info.Path),
+		Path:     info.Path,
+		Size:     info.Size,
+		IsDir:    info.IsDir,
+		MIMEType: info.MIMEType,
+		ModTime:  info.ModTime,
+	}
+	if !info.IsDir {
+		// Servers without these properties just don't return them
+		if p, err := c.props(ctx, filePath); err == nil {
+			fi.Checksum = p.Checksum
+			fi.DisplayName = p.DisplayName
+		}
+	}
+	return fi, nil
 }
 
 // List returns the contents of a directory
@@ -128,10 +161,11 @@ func (c *Client) List(ctx context.Context, dirPath string) ([]FileInfo, error) {
 		}
 
 		result = append(result, FileInfo{
-			Name:  name,
-			Path:  info.Path,
-			Size:  info.Size,
-			IsDir: info.IsDir,
+			Name:    name,
+			Path:    info.Path,
+			Size:    info.Size,
+			IsDir:   info.IsDir,
+			ModTime: info.ModTime,
 		})
 	}
 	return result, nil
@@ -157,6 +191,52 @@ func (c *Client) Open(ctx context.Context, filePath string) (io.ReadCloser, int6
 	return reader, info.Size, nil
 }
 
+// OpenAt opens a file for reading starting at offset using a Range request.
+// resumed is false when the server ignored the range and the reader starts
+// at the beginning of the file.
+func (c *Client) OpenAt(ctx context.Context, filePath string, offset int64) (reader io.ReadCloser, resumed bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.GetFileURL(filePath), nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+	if auth := c.GetAuthHeader(); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("Accept-Encoding", "identity") // Appending needs the raw bytes
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open %s: %w", filePath, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if httpclient.IsEncoded(resp.Header) {
+			// The range is of the encoded stream and can't be appended
+			resp.Body.Close()
+			return nil, false, fmt.Errorf("failed to open %s: server encoded a range response", filePath)
+		}
+		resumed = true
+	case http.StatusOK:
+		resumed = offset == 0
+	default:
+		resp.Body.Close()
+		return nil, false, fmt.Errorf("failed to open %s: status %d", filePath, resp.StatusCode)
+	}
+
+	body, _, err := httpclient.DecodedBody(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, false, fmt.Errorf("failed to open %s: %w", filePath, err)
+	}
+	return body, resumed, nil
+}
+
 // IsWebDAVURL checks if a URL is a WebDAV URL or a remote path (remote:path)
 func IsWebDAVURL(rawURL string) bool {
 	return strings.HasPrefix(rawURL, "webdav://") ||
@@ -164,8 +244,12 @@ func IsWebDAVURL(rawURL string) bool {
 		IsRemotePath(rawURL)
 }
 
-// IsRemotePath checks if the URL is a remote path format (e.g., "pikpak:/path/to/file")
+// IsRemotePath checks if the URL is a remote path format (e.g., "pikpak:/path/to/file").
+// ":/path" refers to the default remote.
 func IsRemotePath(rawURL string) bool {
+	if strings.HasPrefix(rawURL, ":/") {
+		return true
+	}
 	// Check for remote:path format (not a URL scheme like http://)
 	if idx := strings.Index(rawURL, ":"); idx > 0 {
 		prefix := rawURL[:idx]
@@ -187,10 +271,11 @@ func IsRemotePath(rawURL string) bool {
 	return false
 }
 
-// ParseRemotePath parses a remote path like "pikpak:/path/to/file" into remote name and path
+// ParseRemotePath parses a remote path like "pikpak:/path/to/file" into remote name and path.
+// The remote name is empty for ":/path/to/file" (the default remote).
 func ParseRemotePath(remotePath string) (remoteName, filePath string, err error) {
 	idx := strings.Index(remotePath, ":")
-	if idx <= 0 {
+	if idx < 0 || (idx == 0 && !strings.HasPrefix(remotePath, ":/")) {
 		return "", "", fmt.Errorf("invalid remote path format: %s", remotePath)
 	}
 	remoteName = remotePath[:idx]
@@ -204,11 +289,36 @@ func ParseRemotePath(remotePath string) (remoteName, filePath string, err error)
 	return remoteName, filePath, nil
 }
 
-// NewClientFromConfig creates a WebDAV client from a configured server
+// bearerAuthClient adds a bearer token to every request
+type bearerAuthClient struct {
+	c     webdav.HTTPClient
+	token string
+}
+
+func (c *bearerAuthClient) Do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	return c.c.Do(req)
+}
+
+// NewClientFromConfig creates a WebDAV client from a configured server.
+// A token takes precedence over username/password.
 func NewClientFromConfig(server *config.WebDAVServer) (*Client, error) {
-	var httpClient webdav.HTTPClient
-	if server.Username != "" {
-		httpClient = webdav.HTTPClientWithBasicAuth(nil, server.Username, server.Password)
+	var httpClient webdav.HTTPClient = httpclient.New(0)
+	digest := false
+	switch strings.ToLower(server.AuthType) {
+	case "", "basic", "bearer", "digest":
+	default:
+		return nil, fmt.Errorf("unknown auth type %q (want basic, digest or bearer)", server.AuthType)
+	}
+	if server.Token != "" {
+		httpClient = &bearerAuthClient{c: httpClient, token: server.Token}
+	} else if strings.EqualFold(server.AuthType, "bearer") {
+		return nil, fmt.Errorf("auth type bearer needs a token")
+	} else if server.Username != "" && strings.EqualFold(server.AuthType, "digest") {
+		httpClient = &digestAuthClient{c: httpClient, username: server.Username, password: server.Password}
+		digest = true
+	} else if server.Username != "" {
+		httpClient = webdav.HTTPClientWithBasicAuth(httpClient, server.Username, server.Password)
 	}
 
 	client, err := webdav.NewClient(httpClient, server.URL)
@@ -221,12 +331,41 @@ func NewClientFromConfig(server *config.WebDAVServer) (*Client, error) {
 		baseURL:  server.URL,
 		username: server.Username,
 		password: server.Password,
+		token:    server.Token,
+		http:     httpClient,
+		digest:   digest,
 	}, nil
 }
 
-// ExtractFilename extracts the filename from a WebDAV path
-func ExtractFilename(filePath string) string {
-	return path.Base(filePath)
+// ExtractFilename returns a local filename for a WebDAV file, preferring the
+// server's display name over the (possibly opaque) path base. Every WebDAV
+// download is named here, so percent-encoding is decoded here too.
+func ExtractFilename(filePath, displayName string) string {
+	base := localName(path.Base(filePath))
+	name := localName(strings.TrimSpace(displayName))
+	if name == "" || name == "." || name == ".." {
+		return base
+	}
+	// Keep the path's extension when the display name has none
+	if path.Ext(name) == "" {
+		name += path.Ext(base)
+	}
+	return name
+}
+
+// localName decodes a name that is still percent-encoded (as remote paths
+// typed from a browser and some servers' display names are) and replaces
+// the characters that can't be part of a local filename
+func localName(name string) string {
+	if decoded, err := url.PathUnescape(name); err == nil {
+		name = decoded
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 0x20 {
+			return '-'
+		}
+		return r
+	}, name)
 }
 
 // GetFileURL returns the full HTTP URL for a file path
@@ -238,15 +377,26 @@ func (c *Client) GetFileURL(filePath string) string {
 	return c.baseURL + filePath
 }
 
-// GetAuthHeader returns the Basic Auth header value if credentials are set
+// GetAuthHeader returns the Authorization header value if credentials are set.
+// It is empty for Digest auth, see UsesDigestAuth.
 func (c *Client) GetAuthHeader() string {
-	if c.username == "" {
+	if c.token != "" {
+		return "Bearer " + c.token
+	}
+	if c.username == "" || c.digest {
 		return ""
 	}
 	auth := c.username + ":" + c.password
 	return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
 }
 
+// UsesDigestAuth reports whether requests need a Digest handshake, so
+// callers can't authenticate with GetAuthHeader and must go through the
+// client (Open, OpenAt) instead
+func (c *Client) UsesDigestAuth() bool {
+	return c.digest
+}
+
 // SupportsRangeRequests checks if the server supports HTTP Range requests for a file
 func (c *Client) SupportsRangeRequests(ctx context.Context, filePath string) (bool, error) {
 	fileURL := c.GetFileURL(filePath)
@@ -261,8 +411,7 @@ func (c *Client) SupportsRangeRequests(ctx context.Context, filePath string) (bo
 		req.Header.Set("Authorization", auth)
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.http.Do(req)
 	if err != nil {
 		return false, err
 	}
@@ -0,0 +1,24 @@
+package webdav
+
+import "testing"
+
+func TestExtractFilename(t *testing.T) {
+	tests := []struct {
+		filePath, displayName, want string
+	}{
+		{"/videos/clip.mp4", "", "clip.mp4"},
+		{"/videos/my%20clip.mp4", "", "my clip.mp4"},
+		{"/videos/%E8%A7%86%E9%A2%91.mp4", "", "视频.mp4"},
+		{"/videos/a%2Fb.mp4", "", "a-b.mp4"},
+		{"/videos/100%.mp4", "", "100%.mp4"},
+		{"/d/0a1b2c", "Holiday%20Trip.mkv", "Holiday Trip.mkv"},
+		{"/d/0a1b2c.mkv", "Holiday Trip", "Holiday Trip.mkv"},
+		{"/d/clip.mp4", "..%2F..%2Fx", "..-..-x"},
+		{"/d/clip.mp4", "%2E%2E", "clip.mp4"},
+	}
+	for _, tt := range tests {
+		if got := ExtractFilename(tt.filePath, tt.displayName); got != tt.want {
+			t.Errorf("ExtractFilename(%q, %q) = %q, want %q", tt.filePath, tt.displayName, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package webdav
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/emersion/go-webdav"
+)
+
+// digestAuthClient answers HTTP Digest challenges (RFC 7616). The last
+// challenge is remembered so later requests authenticate up front, which
+// matters for bodies that can't be replayed such as streamed uploads.
+type digestAuthClient struct {
+	c        webdav.HTTPClient
+	username string
+	password string
+
+	mu        sync.Mutex
+	challenge *digestChallenge
+	nc        int
+}
+
+// digestChallenge holds the parameters of a WWW-Authenticate: Digest header
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+}
+
+func (c *digestAuthClient) Do(req *http.Request) (*http.Response, error) {
+	if auth := c.authorization(req); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := c.c.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := parseDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+	if challenge == nil {
+		return resp, nil
+	}
+	// Without a way to resend the body the 401 is all we can return
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		c.setChallenge(challenge)
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	c.setChallenge(challenge)
+	retry.Header.Set("Authorization", c.authorization(retry))
+	return c.c.Do(retry)
+}
+
+func (c *digestAuthClient) setChallenge(challenge *digestChallenge) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.challenge = challenge
+	c.nc = 0
+}
+
+// authorization returns the Authorization header for req, or "" before the
+// server has sent a challenge
+func (c *digestAuthClient) authorization(req *http.Request) string {
+	c.mu.Lock()
+	ch := c.challenge
+	if ch == nil {
+		c.mu.Unlock()
+		return ""
+	}
+	c.nc++
+	nc := fmt.Sprintf("%08x", c.nc)
+	c.mu.Unlock()
+
+	newHash := md5.New
+	algorithm := strings.ToUpper(ch.algorithm)
+	if strings.HasPrefix(algorithm, "SHA-256") {
+		newHash = sha256.New
+	}
+	h := func(s string) string {
+		return hexHash(newHash(), s)
+	}
+
+	cnonce := randomHex(16)
+	uri := req.URL.RequestURI()
+	ha1 := h(c.username + ":" + ch.realm + ":" + c.password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1 + ":" + ch.nonce + ":" + cnonce)
+	}
+	ha2 := h(req.Method + ":" + uri)
+
+	var response string
+	if ch.qop != "" {
+		response = h(strings.Join([]string{ha1, ch.nonce, nc, cnonce, ch.qop, ha2}, ":"))
+	} else {
+		response = h(ha1 + ":" + ch.nonce + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, c.username),
+		fmt.Sprintf(`realm="%s"`, ch.realm),
+		fmt.Sprintf(`nonce="%s"`, ch.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if ch.algorithm != "" {
+		parts = append(parts, "algorithm="+ch.algorithm)
+	}
+	if ch.opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, ch.opaque))
+	}
+	if ch.qop != "" {
+		parts = append(parts, "qop="+ch.qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	return "Digest " + strings.Join(parts, ", ")
+}
+
+// parseDigestChallenge returns the first Digest challenge among headers,
+// or nil if there is none or it uses an unsupported algorithm
+func parseDigestChallenge(headers []string) *digestChallenge {
+	for _, header := range headers {
+		scheme, params, _ := strings.Cut(strings.TrimSpace(header), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+
+		ch := &digestChallenge{}
+		for key, value := range parseAuthParams(params) {
+			switch strings.ToLower(key) {
+			case "realm":
+				ch.realm = value
+			case "nonce":
+				ch.nonce = value
+			case "opaque":
+				ch.opaque = value
+			case "algorithm":
+				ch.algorithm = value
+			case "qop":
+				// Only "auth" is supported; auth-int would need the body hashed
+				for _, q := range strings.Split(value, ",") {
+					if strings.TrimSpace(q) == "auth" {
+						ch.qop = "auth"
+					}
+				}
+			}
+		}
+
+		switch strings.ToUpper(ch.algorithm) {
+		case "", "MD5", "MD5-SESS", "SHA-256", "SHA-256-SESS":
+		default:
+			continue
+		}
+		if ch.nonce == "" {
+			continue
+		}
+		return ch
+	}
+	return nil
+}
+
+// parseAuthParams splits a comma separated list of key=value or
+// key="quoted value" pairs
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.TrimSpace(key)
+		rest = strings.TrimLeft(rest, " ")
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value = b.String()
+			s = rest[min(i+1, len(rest)):]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[key] = value
+	}
+	return params
+}
+
+func hexHash(h hash.Hash, s string) string {
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,76 @@
+package webdav
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUploadFileAbortsOnReadError(t *testing.T) {
+	bodyErr := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			w.WriteHeader(http.StatusMultiStatus) // The parent directory exists
+			return
+		}
+		_, err := io.Copy(io.Discard, r.Body)
+		bodyErr <- err
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	client, err := NewClient(strings.Replace(srv.URL, "http://", "webdav+http://", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &failingReaderAt{data: "the first half", err: errors.New("disk on fire")}
+	if err := client.UploadFile(context.Background(), r, 2*int64(len(r.data)), "/file.bin", DefaultUploadConfig()); err == nil {
+		t.Fatal("UploadFile succeeded although reading failed")
+	}
+	// The server must not see the truncated body as a complete upload
+	if err := <-bodyErr; err == nil {
+		t.Error("server received a complete PUT body")
+	}
+}
+
+// failingReaderAt returns data, then err for anything past it
+type failingReaderAt struct {
+	data string
+	err  error
+}
+
+func (r *failingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(r.data)) {
+		return 0, r.err
+	}
+	return copy(p, r.data[off:]), nil
+}
+
+func TestUploadFileSendsContentLength(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			w.WriteHeader(http.StatusMultiStatus) // The parent directory exists
+			return
+		}
+		// Like servers that refuse chunked uploads
+		if r.ContentLength < 0 {
+			w.WriteHeader(http.StatusLengthRequired)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	client, err := NewClient(strings.Replace(srv.URL, "http://", "webdav+http://", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := strings.NewReader("file contents")
+	if err := client.UploadFile(context.Background(), data, data.Size(), "/file.bin", DefaultUploadConfig()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,178 @@
+package webdav
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// partialUpdateType is the PATCH body type of SabreDAV's partial update
+// extension, which writes a byte range of an existing file
+const partialUpdateType = "application/x-sabredav-partialupdate"
+
+// UploadConfig controls how UploadFile splits an upload into parts
+type UploadConfig struct {
+	PartSize int64 // Bytes per part (0 to always send one PUT)
+	Streams  int   // Parts uploaded at the same time
+	Retries  int   // Times a failed part is sent again
+}
+
+// DefaultUploadConfig returns the settings used by vget upload
+func DefaultUploadConfig() UploadConfig {
+	return UploadConfig{
+		PartSize: 8 * 1024 * 1024,
+		Streams:  4,
+		Retries:  3,
+	}
+}
+
+// SupportsPartialUpdate reports whether the server accepts partial PUTs in
+// the form of SabreDAV partial updates (Nextcloud, ownCloud and other
+// sabre/dav based servers), which it advertises in reply to OPTIONS
+func (c *Client) SupportsPartialUpdate(ctx context.Context, filePath string) bool {
+	req, err := http.NewRequestWithContext(ctx, "OPTIONS", c.GetFileURL(filePath), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false
+	}
+
+	for _, v := range resp.Header.Values("Accept-Patch") {
+		if strings.Contains(v, partialUpdateType) {
+			return true
+		}
+	}
+	for _, v := range resp.Header.Values("DAV") {
+		if strings.Contains(v, "sabredav-partialupdate") {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadFile uploads size bytes read from r to remotePath, creating missing
+// parent directories. Files larger than one part are sent as parts in
+// parallel when the server supports partial updates; otherwise, and for
+// small files, the file is sent with a single streaming PUT.
+func (c *Client) UploadFile(ctx context.Context, r io.ReaderAt, size int64, remotePath string, config UploadConfig) error {
+	if err := c.mkdirAll(ctx, path.Dir(remotePath)); err != nil {
+		return err
+	}
+	if config.PartSize <= 0 || size <= config.PartSize || !c.SupportsPartialUpdate(ctx, path.Dir(remotePath)) {
+		return c.put(ctx, r, size, remotePath)
+	}
+
+	// A partial update only writes into an existing file
+	if err := c.put(ctx, strings.NewReader(""), 0, remotePath); err != nil {
+		return err
+	}
+	if err := c.uploadParts(ctx, r, size, remotePath, config); err != nil {
+		// Don't leave a file behind that has holes in it
+		c.client.RemoveAll(context.Background(), remotePath)
+		return fmt.Errorf("failed to upload %s: %w", remotePath, err)
+	}
+
+	info, err := c.client.Stat(ctx, remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", remotePath, err)
+	}
+	if info.Size != size {
+		c.client.RemoveAll(context.Background(), remotePath)
+		return fmt.Errorf("failed to upload %s: server has %d bytes, expected %d", remotePath, info.Size, size)
+	}
+	return nil
+}
+
+// uploadParts writes r to remotePath in PartSize pieces, Streams at a time
+func (c *Client) uploadParts(ctx context.Context, r io.ReaderAt, size int64, remotePath string, config UploadConfig) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	starts := make(chan int64)
+	go func() {
+		defer close(starts)
+		for start := int64(0); start < size; start += config.PartSize {
+			select {
+			case starts <- start:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for range max(config.Streams, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range starts {
+				end := min(start+config.PartSize, size)
+				if err := c.uploadPart(ctx, r, start, end, remotePath, config.Retries); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// uploadPart writes bytes [start, end) of r into remotePath, trying again
+// up to retries times
+func (c *Client) uploadPart(ctx context.Context, r io.ReaderAt, start, end int64, remotePath string, retries int) error {
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if lastErr = c.patchRange(ctx, r, start, end, remotePath); lastErr == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("part at byte %d: %w", start, lastErr)
+}
+
+// patchRange sends one partial update request
+func (c *Client) patchRange(ctx context.Context, r io.ReaderAt, start, end int64, remotePath string) error {
+	body := func() io.ReadCloser {
+		return io.NopCloser(io.NewSectionReader(r, start, end-start))
+	}
+	req, err := http.NewRequestWithContext(ctx, "PATCH", c.GetFileURL(remotePath), body())
+	if err != nil {
+		return err
+	}
+	// Replayable, so a Digest challenge can be answered
+	req.GetBody = func() (io.ReadCloser, error) {
+		return body(), nil
+	}
+	req.ContentLength = end - start
+	req.Header.Set("Content-Type", partialUpdateType)
+	req.Header.Set("X-Update-Range", fmt.Sprintf("bytes=%d-%d", start, end-1))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
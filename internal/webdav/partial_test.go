@@ -0,0 +1,166 @@
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/emersion/go-webdav"
+)
+
+// partialServer is a WebDAV server for a temp directory. With partial set
+// it also accepts SabreDAV partial updates, failing the first PATCH of
+// failOffset to exercise retries.
+type partialServer struct {
+	dir        string
+	partial    bool
+	failOffset int64
+
+	mu      sync.Mutex
+	patches int
+	puts    int
+	failed  bool
+}
+
+func (s *partialServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "OPTIONS" && s.partial:
+		w.Header().Set("Accept-Patch", partialUpdateType)
+		w.Header().Set("DAV", "1, 3, sabredav-partialupdate")
+		return
+	case r.Method == "PATCH" && s.partial:
+		var start, end int64
+		if _, err := fmt.Sscanf(r.Header.Get("X-Update-Range"), "bytes=%d-%d", &start, &end); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.patches++
+		fail := start == s.failOffset && !s.failed
+		if fail {
+			s.failed = true
+		}
+		s.mu.Unlock()
+		if fail {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+
+		f, err := os.OpenFile(filepath.Join(s.dir, r.URL.Path), os.O_WRONLY, 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		defer f.Close()
+		data, _ := io.ReadAll(r.Body)
+		if int64(len(data)) != end-start+1 {
+			http.Error(w, "body doesn't match the range", http.StatusBadRequest)
+			return
+		}
+		f.WriteAt(data, start)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case r.Method == "PUT":
+		s.mu.Lock()
+		s.puts++
+		s.mu.Unlock()
+	}
+	(&webdav.Handler{FileSystem: webdav.LocalFileSystem(s.dir)}).ServeHTTP(w, r)
+}
+
+func newPartialServer(t *testing.T, partial bool) (*partialServer, *Client) {
+	t.Helper()
+	s := &partialServer{dir: t.TempDir(), partial: partial, failOffset: -1}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	client, err := NewClient(strings.Replace(srv.URL, "http://", "webdav+http://", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, client
+}
+
+func uploadData(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 241)
+	}
+	return b
+}
+
+func TestUploadFileInParts(t *testing.T) {
+	s, client := newPartialServer(t, true)
+	s.failOffset = 2000
+	data := uploadData(4500)
+
+	config := UploadConfig{PartSize: 1000, Streams: 3, Retries: 1}
+	if err := client.UploadFile(context.Background(), bytes.NewReader(data), int64(len(data)), "/dir/file.bin", config); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(s.dir, "dir", "file.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("uploaded file differs from the local one")
+	}
+	// Five parts plus the retried one
+	if s.patches != 6 {
+		t.Errorf("%d PATCH requests, want 6", s.patches)
+	}
+}
+
+func TestUploadFileFallsBackToPut(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		partial bool
+		size    int
+	}{
+		{"no partial updates", false, 4500},
+		{"single part", true, 800},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, client := newPartialServer(t, tt.partial)
+			data := uploadData(tt.size)
+
+			config := UploadConfig{PartSize: 1000, Streams: 3}
+			if err := client.UploadFile(context.Background(), bytes.NewReader(data), int64(len(data)), "/file.bin", config); err != nil {
+				t.Fatalf("UploadFile: %v", err)
+			}
+
+			got, err := os.ReadFile(filepath.Join(s.dir, "file.bin"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatal("uploaded file differs from the local one")
+			}
+			if s.patches != 0 || s.puts != 1 {
+				t.Errorf("%d PATCH and %d PUT requests, want a single PUT", s.patches, s.puts)
+			}
+		})
+	}
+}
+
+func TestUploadFileRemovesFailedUpload(t *testing.T) {
+	s, client := newPartialServer(t, true)
+	s.failOffset = 1000
+	data := uploadData(3000)
+
+	config := UploadConfig{PartSize: 1000, Streams: 2, Retries: 0}
+	if err := client.UploadFile(context.Background(), bytes.NewReader(data), int64(len(data)), "/file.bin", config); err == nil {
+		t.Fatal("UploadFile succeeded although a part failed")
+	}
+	if _, err := os.Stat(filepath.Join(s.dir, "file.bin")); !os.IsNotExist(err) {
+		t.Error("incomplete upload left on the server")
+	}
+}
@@ -0,0 +1,59 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// propsPropfind asks for the extra properties go-webdav doesn't expose
+const propsPropfind = `<?xml version="1.0"?>
+<d:propfind xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns">
+  <d:prop><d:displayname/><oc:checksums/></d:prop>
+</d:propfind>`
+
+type propsMultistatus struct {
+	DisplayName string   `xml:"response>propstat>prop>displayname"`
+	Checksums   []string `xml:"response>propstat>prop>checksums>checksum"`
+}
+
+// fileProps holds the extra properties of a single file
+type fileProps struct {
+	DisplayName string
+	Checksum    string
+}
+
+// props fetches the display name and checksum of a file with a Depth 0 PROPFIND
+func (c *Client) props(ctx context.Context, filePath string) (*fileProps, error) {
+	req, err := http.NewRequestWithContext(ctx, "PROPFIND", c.GetFileURL(filePath), strings.NewReader(propsPropfind))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "0")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	if auth := c.GetAuthHeader(); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get properties of %s: %w", filePath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("failed to get properties of %s: status %d", filePath, resp.StatusCode)
+	}
+
+	var ms propsMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("failed to parse properties of %s: %w", filePath, err)
+	}
+
+	return &fileProps{
+		DisplayName: strings.TrimSpace(ms.DisplayName),
+		Checksum:    pickChecksum(strings.Join(ms.Checksums, " ")),
+	}, nil
+}
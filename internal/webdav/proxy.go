@@ -0,0 +1,64 @@
+package webdav
+
+import (
+	"io"
+	"net/http"
+)
+
+// proxyRequestHeaders are passed from the player to the WebDAV server
+var proxyRequestHeaders = []string{"Range", "If-Range", "If-None-Match", "If-Modified-Since"}
+
+// proxyResponseHeaders are passed from the WebDAV server back to the player
+var proxyResponseHeaders = []string{
+	"Content-Type", "Content-Length", "Content-Range", "Content-Encoding",
+	"Accept-Ranges", "ETag", "Last-Modified",
+}
+
+// ProxyHandler returns a handler that streams filePath from the server with
+// the client's credentials added. Range requests are forwarded as-is, so a
+// local player pointed at the handler can seek without knowing the password.
+func (c *Client) ProxyHandler(filePath string) http.Handler {
+	fileURL := c.GetFileURL(filePath)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		req, err := http.NewRequestWithContext(r.Context(), r.Method, fileURL, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, h := range proxyRequestHeaders {
+			if v := r.Header.Get(h); v != "" {
+				req.Header.Set(h, v)
+			}
+		}
+		if auth := c.GetAuthHeader(); auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		// Ranges the player asks for are of the file, not of a compressed
+		// stream; should the server encode anyway, the header goes along
+		req.Header.Set("Accept-Encoding", "identity")
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+
+		for _, h := range proxyResponseHeaders {
+			if v := resp.Header.Get(h); v != "" {
+				w.Header().Set(h, v)
+			}
+		}
+		w.WriteHeader(resp.StatusCode)
+		if r.Method == http.MethodGet {
+			io.Copy(w, resp.Body) // The player closing the connection ends the copy
+		}
+	})
+}
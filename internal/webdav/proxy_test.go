@@ -0,0 +1,113 @@
+package webdav
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxyHandlerForwardsRangeWithAuth(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/videos/clip.mp4" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "clip.mp4", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(strings.Replace(srv.URL, "http://", "webdav+http://user:secret@", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httptest.NewServer(client.ProxyHandler("/videos/clip.mp4"))
+	defer proxy.Close()
+
+	req, _ := http.NewRequest("GET", proxy.URL+"/clip.mp4", nil)
+	req.Header.Set("Range", "bytes=10-14")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Fatalf("status = %d, want 206", resp.StatusCode)
+	}
+	if string(body) != "abcde" {
+		t.Errorf("body = %q, want %q", body, "abcde")
+	}
+	if got := resp.Header.Get("Content-Range"); got != "bytes 10-14/20" {
+		t.Errorf("Content-Range = %q", got)
+	}
+
+	resp, err = http.Post(proxy.URL, "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestOpenAtDecodesUnrequestedGzip(t *testing.T) {
+	content := []byte("the whole file, compressed against our wishes")
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(content)
+	zw.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept-Encoding"); got != "identity" {
+			t.Errorf("Accept-Encoding = %q, want identity for a ranged request", got)
+		}
+		if r.URL.Path == "/ranged.bin" {
+			w.Header().Set("Content-Range", "bytes 4-/100")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(gz.Bytes())
+			return
+		}
+		// Ignores both the range and the encoding request
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gz.Bytes())
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(strings.Replace(srv.URL, "http://", "webdav+http://", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, resumed, err := client.OpenAt(t.Context(), "/file.bin", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed {
+		t.Error("resumed = true for a full 200 response")
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("body = %q, want the decoded file", got)
+	}
+
+	if _, _, err := client.OpenAt(t.Context(), "/ranged.bin", 4); err == nil {
+		t.Error("OpenAt accepted an encoded range response")
+	}
+}
@@ -0,0 +1,73 @@
+package webdav
+
+import (
+	"context"
+	"path"
+	"sort"
+	"sync"
+)
+
+// DefaultWalkWorkers is the default number of concurrent directory listings
+const DefaultWalkWorkers = 8
+
+// Walk recursively lists everything below root. Directories are listed
+// concurrently with at most workers requests in flight, which matters on
+// high-latency servers. Entries are returned sorted by path, with Path set
+// to the full path below root.
+func (c *Client) Walk(ctx context.Context, root string, workers int) ([]FileInfo, error) {
+	if workers <= 0 {
+		workers = DefaultWalkWorkers
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		result   []FileInfo
+		firstErr error
+		sem      = make(chan struct{}, workers)
+	)
+
+	var walk func(dir string)
+	walk = func(dir string) {
+		defer wg.Done()
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		entries, err := c.List(ctx, dir)
+		<-sem
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+			return
+		}
+		for _, e := range entries {
+			e.Path = path.Join(dir, e.Name)
+			result = append(result, e)
+			if e.IsDir {
+				wg.Add(1)
+				go walk(e.Path)
+			}
+		}
+	}
+
+	wg.Add(1)
+	go walk(root)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result, nil
+}